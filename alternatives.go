@@ -0,0 +1,154 @@
+package talia
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultAlternativeTLDs are the TLDs tried for a taken name with
+// --alternatives.
+const defaultAlternativeTLDs = "io,co,app,dev,net"
+
+// maxAlternativeChecks caps the candidates checked for each taken domain, so
+// --alternatives costs at most this many extra WHOIS queries per domain.
+const maxAlternativeChecks = 20
+
+// synonymSource returns up to n names similar in meaning to domain, e.g.
+// from the suggestion provider.
+type synonymSource func(domain string, n int) ([]string, error)
+
+// alternativeCandidates returns names to try in place of a taken domain, in
+// order of preference: the same name in other TLDs, synonyms, the name with
+// the prefixes and suffixes of "talia spin" in its own TLD, and edit-distance
+// variants (hyphenated, last letter doubled). The domain itself and
+// duplicates are skipped and the list is capped at maxAlternativeChecks.
+func alternativeCandidates(domain string, tlds, synonyms []string) []string {
+	label, tld, _ := strings.Cut(domain, ".")
+	var out []string
+	seen := map[string]bool{domain: true}
+	add := func(d string) {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if len(out) < maxAlternativeChecks && !seen[d] && strings.Contains(d, ".") {
+			seen[d] = true
+			out = append(out, d)
+		}
+	}
+
+	for _, t := range tlds {
+		add(label + "." + strings.TrimPrefix(t, "."))
+	}
+	for _, s := range synonyms {
+		add(s)
+	}
+	spun := spinWords([]string{label}, spinOptions{
+		prefixes: splitList("get,try,use"),
+		suffixes: splitList("hq,app,ly"),
+		double:   true,
+	})
+	for _, l := range spun {
+		add(l + "." + tld)
+	}
+	for _, v := range generateLookalikes(domain, []string{lookalikeHyphenation}) {
+		add(v.Domain)
+	}
+	return out
+}
+
+// findAlternatives attaches up to cfg.alternatives available alternatives to
+// each taken result. The candidates of all taken domains are checked in one
+// batch with the run's settings; a failed synonym lookup is reported and the
+// other candidates are still tried.
+func findAlternatives(results []checkResult, cfg runConfig) {
+	var taken []int
+	candidates := make(map[int][]string)
+	var batch []string
+	inBatch := make(map[string]bool)
+	for i, res := range results {
+		if res.Reason != ReasonTaken {
+			continue
+		}
+		var synonyms []string
+		if cfg.synonyms != nil {
+			var err error
+			if synonyms, err = cfg.synonyms(res.Domain, cfg.alternatives); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: synonyms for %s: %v\n", res.Domain, err)
+			}
+		}
+		taken = append(taken, i)
+		candidates[i] = alternativeCandidates(res.Domain, cfg.alternativeTLDs, synonyms)
+		for _, c := range candidates[i] {
+			if !inBatch[c] {
+				inBatch[c] = true
+				batch = append(batch, c)
+			}
+		}
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	fmt.Fprintf(cfg.status(), "Checking %d alternatives for %d taken domains...\n", len(batch), len(taken))
+	available := make(map[string]bool)
+	for _, res := range checkDomainsAll(batch, cfg) {
+		available[res.Domain] = res.Avail
+	}
+	for _, i := range taken {
+		for _, c := range candidates[i] {
+			if len(results[i].Alternatives) == cfg.alternatives {
+				break
+			}
+			if available[c] {
+				results[i].Alternatives = append(results[i].Alternatives, c)
+			}
+		}
+	}
+}
+
+// alternativesFlags are the --alternatives flags of file mode and "talia
+// check".
+type alternativesFlags struct {
+	count int
+	tlds  string
+	ai    bool
+}
+
+// addAlternativesFlags registers the alternatives flags on fs.
+func addAlternativesFlags(fs *flag.FlagSet) *alternativesFlags {
+	f := &alternativesFlags{}
+	fs.IntVar(&f.count, "alternatives", 0, "For each taken domain, find up to this many available alternatives and store them in 'alternatives'")
+	fs.StringVar(&f.tlds, "alternatives-tlds", defaultAlternativeTLDs, "Comma-separated TLDs to try taken names in with --alternatives")
+	fs.BoolVar(&f.ai, "alternatives-ai", false, "With --alternatives, also ask the suggestion provider for synonyms (needs OPENAI_API_KEY)")
+	return f
+}
+
+// apply copies the flags to cfg. With --alternatives-ai, synonyms come from
+// the suggestion provider at baseURL using model.
+func (f *alternativesFlags) apply(cfg *runConfig, model, baseURL string) error {
+	if f.count < 0 {
+		return fmt.Errorf("--alternatives must not be negative")
+	}
+	cfg.alternatives = f.count
+	cfg.alternativeTLDs = splitList(f.tlds)
+	if !f.ai || f.count == 0 {
+		return nil
+	}
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return fmt.Errorf("--alternatives-ai requires OPENAI_API_KEY")
+	}
+	cfg.synonyms = func(domain string, n int) ([]string, error) {
+		prompt := fmt.Sprintf("%s is taken. Suggest names with a similar meaning or feel to %q.", domain, strings.Split(domain, ".")[0])
+		recs, err := GenerateDomainSuggestions(apiKey, prompt, n, model, baseURL, []string{domain})
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(recs))
+		for i, r := range recs {
+			names[i] = r.Domain
+		}
+		return names, nil
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package talia
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestAlternativeCandidates checks the candidate order and the cap.
+func TestAlternativeCandidates(t *testing.T) {
+	t.Parallel()
+	got := alternativeCandidates("ab.com", []string{"io", ".com"}, []string{"Xy.com", "ab.io"})
+	want := "ab.io xy.com getab.com tryab.com useab.com abhq.com abapp.com ably.com abb.com a-b.com"
+	if strings.Join(got, " ") != want {
+		t.Errorf("candidates = %q, want %q", got, want)
+	}
+
+	if got := alternativeCandidates("averyveryverylongname.com", splitList(defaultAlternativeTLDs), nil); len(got) != maxAlternativeChecks {
+		t.Errorf("got %d candidates, want cap %d", len(got), maxAlternativeChecks)
+	}
+}
+
+// TestFindAlternatives attaches available candidates to taken results only,
+// keeps at most cfg.alternatives of them, and survives a synonym failure.
+func TestFindAlternatives(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.HandleFunc(func(q string) taliatest.Response {
+		switch q {
+		case "ab.io", "ably.com", "abb.com", "cd.io":
+			return taliatest.Response{Body: taliatest.Available(q)}
+		}
+		return taliatest.Response{Body: taliatest.Registered(q)}
+	})
+	addr := srv.Addr
+	results := []checkResult{
+		{Domain: "ab.com", Reason: ReasonTaken},
+		{Domain: "free.com", Avail: true, Reason: ReasonNoMatch},
+		{Domain: "cd.com", Reason: ReasonTaken},
+	}
+	cfg := runConfig{
+		whoisServer:     addr,
+		workers:         -1,
+		alternatives:    2,
+		alternativeTLDs: []string{"io"},
+		synonyms: func(domain string, n int) ([]string, error) {
+			return nil, errors.New("offline")
+		},
+	}
+	_, stderr := captureOutput(t, func() { findAlternatives(results, cfg) })
+
+	if got := strings.Join(results[0].Alternatives, " "); got != "ab.io ably.com" {
+		t.Errorf("ab.com alternatives = %q", got)
+	}
+	if results[1].Alternatives != nil {
+		t.Errorf("available domain got alternatives: %v", results[1].Alternatives)
+	}
+	if got := strings.Join(results[2].Alternatives, " "); got != "cd.io" {
+		t.Errorf("cd.com alternatives = %q", got)
+	}
+	if !strings.Contains(stderr, "synonyms for ab.com: offline") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestRunCLICheckSubcommandAlternatives stores alternatives in the JSON
+// records of talia check.
+func TestRunCLICheckSubcommandAlternatives(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.HandleFunc(func(q string) taliatest.Response { return taliatest.Response{Body: taliatest.Registered(q)} })
+	srv.Handle("taken.io", taliatest.Response{Body: taliatest.Available("taken.io")})
+	addr := srv.Addr
+	stdout, _ := captureOutput(t, func() {
+		args := []string{"check", "--format=json", "--lightspeed=max", "--whois=" + addr, "--alternatives=1", "--alternatives-tlds=io", "taken.com"}
+		if code := RunCLI(args); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	var recs []DomainRecord
+	if err := json.Unmarshal([]byte(stdout), &recs); err != nil {
+		t.Fatalf("unmarshal %q: %v", stdout, err)
+	}
+	if len(recs) != 1 || strings.Join(recs[0].Alternatives, " ") != "taken.io" {
+		t.Errorf("records = %+v", recs)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "")
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "--alternatives=1", "--alternatives-ai", "a.com"}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "requires OPENAI_API_KEY") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,108 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteFileAtomic verifies the contents are replaced, the existing mode is
+// kept, and no temporary files are left behind.
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list.json")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != "new" {
+		t.Errorf("contents = %q", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("leftover files: %v", entries)
+	}
+}
+
+// TestWriteFileAtomic_Symlink verifies the link target is updated and the
+// link itself survives.
+func TestWriteFileAtomic_Symlink(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.json")
+	link := filepath.Join(dir, "link.json")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink replaced: %v", err)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "new" {
+		t.Errorf("target contents = %q", got)
+	}
+}
+
+// TestWriteFileAtomic_ReadOnly ensures a read-only file is not replaced.
+func TestWriteFileAtomic_ReadOnly(t *testing.T) {
+	t.Parallel()
+	if os.Geteuid() == 0 {
+		t.Skip("running as root; file write permission errors won't occur")
+	}
+	path := filepath.Join(t.TempDir(), "ro.json")
+	if err := os.WriteFile(path, []byte("old"), 0400); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("new"), 0644); err == nil {
+		t.Error("expected error for read-only file")
+	}
+	if got, _ := os.ReadFile(path); string(got) != "old" {
+		t.Errorf("read-only file modified: %q", got)
+	}
+}
+
+// TestRunCLI_GroupedRewriteAtomic verifies a grouped run replaces its file
+// with a renamed temporary file rather than rewriting it in place.
+func TestRunCLI_GroupedRewriteAtomic(t *testing.T) {
+	addr := startWhoisServer(t, "No match for \"A.COM\".")
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "a.com"}}})
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + addr, "--sleep=0s", "--grouped-output", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.SameFile(before, after) {
+		t.Error("grouped file rewritten in place")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("leftover files: %v", entries)
+	}
+}
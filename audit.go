@@ -0,0 +1,138 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// auditArgs are the arguments of the current RunCLI call, recorded with
+// every audit entry.
+var auditArgs []string
+
+// auditRedactedFlags are the flags whose values may hold credentials (an
+// Authorization header, webhook URLs with tokens in them) and are logged as
+// "<redacted>".
+var auditRedactedFlags = map[string]bool{
+	"input-auth":   true,
+	"notify":       true,
+	"post-results": true,
+	"upload":       true,
+}
+
+// auditCounts are the domains of a file by bucket, as "talia export
+// --status" selects them.
+type auditCounts struct {
+	Available   int `json:"available"`
+	Unavailable int `json:"unavailable"`
+	Unverified  int `json:"unverified"`
+}
+
+// auditEntry is one line of the --audit-log file. Before is null when the
+// file didn't exist or couldn't be read.
+type auditEntry struct {
+	Time    time.Time    `json:"time"`
+	Command string       `json:"command"`
+	RunID   string       `json:"runId,omitempty"`
+	Path    string       `json:"path"`
+	Before  *auditCounts `json:"before"`
+	After   *auditCounts `json:"after"`
+	Args    []string     `json:"args"`
+}
+
+// countDomains counts the domains of the array, JSON Lines, or grouped file
+// at path, or returns nil if it can't be read.
+func countDomains(path string) *auditCounts {
+	records, err := readRecords(path)
+	if err != nil {
+		return nil
+	}
+	var c auditCounts
+	for _, rec := range records {
+		switch {
+		case exportSelection(bucketAvailable, rec):
+			c.Available++
+		case exportSelection(bucketUnverified, rec):
+			c.Unverified++
+		default:
+			c.Unavailable++
+		}
+	}
+	return &c
+}
+
+// redactArgs returns args with the values of auditRedactedFlags replaced.
+func redactArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	redactNext := false
+	for _, arg := range args {
+		if redactNext {
+			out = append(out, "<redacted>")
+			redactNext = false
+			continue
+		}
+		if arg == "--" {
+			return append(out, args[len(out):]...)
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !auditRedactedFlags[name] {
+			out = append(out, arg)
+			continue
+		}
+		if hasValue {
+			out = append(out, "--"+name+"=<redacted>")
+		} else {
+			out = append(out, arg)
+			redactNext = true
+		}
+	}
+	return out
+}
+
+// auditWrite runs write, which replaces the domain file at path, and then
+// appends an entry to the audit log named by TALIA_AUDIT_LOG (--audit-log)
+// with the file's counts before and after. Without an audit log it only
+// runs write. A failed write is not logged; a failure to log is a warning,
+// since the file has been written by then.
+func auditWrite(command, runID, path string, write func() error) error {
+	logPath := os.Getenv("TALIA_AUDIT_LOG")
+	if logPath == "" {
+		return write()
+	}
+	before := countDomains(path)
+	if err := write(); err != nil {
+		return err
+	}
+	entry := auditEntry{
+		Time:    time.Now().UTC(),
+		Command: command,
+		RunID:   runID,
+		Path:    path,
+		Before:  before,
+		After:   countDomains(path),
+		Args:    redactArgs(auditArgs),
+	}
+	if err := appendAudit(logPath, entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit log %s: %v\n", logPath, err)
+	}
+	return nil
+}
+
+// appendAudit appends entry to the log at path as one JSON line.
+func appendAudit(path string, entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,98 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestRedactArgs hides the values of credential-bearing flags in both forms.
+func TestRedactArgs(t *testing.T) {
+	got := redactArgs([]string{"--notify=https://hooks.slack.com/x", "--input-auth", "Bearer t", "--sleep=1s", "--", "--upload=s3://b"})
+	want := []string{"--notify=<redacted>", "--input-auth", "<redacted>", "--sleep=1s", "--", "--upload=s3://b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("redactArgs = %q, want %q", got, want)
+	}
+}
+
+// TestRunCLI_AuditLog appends one entry per file written, with the counts
+// before and after and the command line.
+func TestRunCLI_AuditLog(t *testing.T) {
+	t.Setenv("TALIA_AUDIT_LOG", "")
+	srv := newWhoisServer(t)
+	srv.Handle("free.com", taliatest.Response{Body: taliatest.Available("free.com")})
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "free.com"}}})
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "audit.jsonl")
+	list := filepath.Join(dir, "drops.txt")
+	if err := os.WriteFile(list, []byte("new.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--audit-log=" + logPath, "--whois=" + srv.Addr, "--sleep=0s", "--run-id=r1", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+		if code := RunCLI([]string{"import", "--audit-log=" + logPath, "--output=" + path, list}); code != 0 {
+			t.Errorf("import exit %d", code)
+		}
+	})
+
+	raw, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit log has %d lines:\n%s", len(lines), raw)
+	}
+	var entry auditEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Command != "check" || entry.RunID != "r1" || entry.Path != path {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Before == nil || *entry.Before != (auditCounts{Unverified: 1}) {
+		t.Errorf("before = %+v", entry.Before)
+	}
+	if entry.After == nil || *entry.After != (auditCounts{Available: 1}) {
+		t.Errorf("after = %+v", entry.After)
+	}
+	if !slices.Contains(entry.Args, "--sleep=0s") {
+		t.Errorf("args = %q", entry.Args)
+	}
+	entry = auditEntry{}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil || entry.Command != "import" || entry.After == nil || *entry.After != (auditCounts{Available: 1, Unverified: 1}) {
+		t.Errorf("import entry = %+v (%v)", entry, err)
+	}
+}
+
+// TestRunCLI_AuditLogDedupe logs the rewrite of a maintenance flag.
+func TestRunCLI_AuditLogDedupe(t *testing.T) {
+	t.Setenv("TALIA_AUDIT_LOG", "")
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "a.com"}, {Domain: "A.com"}}})
+	logPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--audit-log=" + logPath, "--dedupe", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+
+	raw, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry auditEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		t.Fatalf("audit log = %s (%v)", raw, err)
+	}
+	if entry.Command != "dedupe" || entry.Path != path || entry.After == nil || *entry.After != (auditCounts{Unverified: 1}) {
+		t.Errorf("entry = %+v", entry)
+	}
+}
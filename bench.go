@@ -0,0 +1,111 @@
+package talia
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+)
+
+// Profiles written by --pprof.
+const (
+	pprofCPU   = "cpu"
+	pprofMem   = "mem"
+	pprofTrace = "trace"
+)
+
+// defaultPprofFile returns the file --pprof writes kind to without
+// --pprof-file.
+func defaultPprofFile(kind string) string {
+	if kind == pprofTrace {
+		return "talia.trace"
+	}
+	return "talia-" + kind + ".pprof"
+}
+
+// startPprof starts recording the profile kind to path and returns the
+// function that stops it and writes the file. The heap profile is taken at
+// stop, after a garbage collection, so it shows what the run kept live.
+func startPprof(kind, path string) (func() error, error) {
+	if kind != pprofCPU && kind != pprofMem && kind != pprofTrace {
+		return nil, fmt.Errorf("--pprof must be %q, %q, or %q", pprofCPU, pprofMem, pprofTrace)
+	}
+	if path == "" {
+		path = defaultPprofFile(kind)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case pprofCPU:
+		err = pprof.StartCPUProfile(f)
+	case pprofTrace:
+		err = trace.Start(f)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() error {
+		switch kind {
+		case pprofCPU:
+			pprof.StopCPUProfile()
+		case pprofTrace:
+			trace.Stop()
+		case pprofMem:
+			runtime.GC()
+			err = pprof.WriteHeapProfile(f)
+		}
+		return errors.Join(err, f.Close())
+	}, nil
+}
+
+// errNotCached is the lookup error of a --bench domain that has no stored
+// response.
+var errNotCached = errors.New("not in the WHOIS cache")
+
+// runBench implements --bench: the domains of the file at path are checked
+// against the responses stored by --whois-cache, at any age, without a
+// network query, a sleep, or a file write, and the throughput is printed.
+// The checks run as configured otherwise (--lightspeed, --verbose), so
+// --pprof shows where the processing time goes.
+func runBench(cfg runConfig, path string) int {
+	records, err := readDomainList(path)
+	if err != nil {
+		return fail(cliError{Code: errCodeInputRead, Path: path}, "Error reading %s: %v", path, err)
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return fail(cliError{Code: errCodeGeneric}, "Error: --bench: %v", err)
+	}
+	domains := make([]string, len(records))
+	for i, rec := range records {
+		domains[i] = rec.Domain
+	}
+	cfg.whoisCache = newWhoisCache(filepath.Join(dir, "whois"), 0, cfg.timeSource())
+	cfg.whoisCache.replay = true
+	cfg.sleep, cfg.sleepOverrides, cfg.journal = 0, nil, nil
+
+	start := time.Now()
+	results := checkDomainsWhoisWith(domains, cfg, nil)
+	elapsed := time.Since(start)
+
+	missing := 0
+	for _, res := range results {
+		if res.Reason == ReasonError {
+			missing++
+		}
+	}
+	if len(domains) > 0 && missing == len(domains) {
+		return fail(cliError{Code: errCodeGeneric, Path: path, Hint: "run the file with --whois-cache first to record responses"}, "Error: --bench: none of the %d domains is in the WHOIS cache", len(domains))
+	}
+	rate := float64(len(domains)) / max(elapsed.Seconds(), 1e-9)
+	fmt.Printf("Bench: %d domains in %s (%.0f domains/s); %d replayed, %d not in the cache\n",
+		len(domains), elapsed.Round(time.Microsecond), rate, len(domains)-missing, missing)
+	return 0
+}
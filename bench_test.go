@@ -0,0 +1,66 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunCLIBench replays the responses stored by --whois-cache without a
+// query or a write, and profiles the run with --pprof.
+func TestRunCLIBench(t *testing.T) {
+	t.Setenv("TALIA_CACHE_DIR", t.TempDir())
+	srv := newWhoisServer(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"},{"domain":"b.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--bench", "--whois=" + srv.Addr, path}); code == 0 {
+			t.Error("bench without cached responses succeeded")
+		}
+	})
+	if !strings.Contains(stderr, "--whois-cache first") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois-cache=1h", "--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	before, _ := os.ReadFile(path)
+	profile := filepath.Join(dir, "cpu.pprof")
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--bench", "--pprof=cpu", "--pprof-file=" + profile, "--whois=" + srv.Addr, "--lightspeed=2", path}); code != 0 {
+			t.Errorf("exit %d: %s", code, stderr)
+		}
+	})
+	if !strings.Contains(stdout, "Bench: 2 domains") || !strings.Contains(stdout, "2 replayed, 0 not in the cache") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if n := len(srv.Queries()); n != 2 {
+		t.Errorf("%d queries, want the 2 of the cached run", n)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("--bench rewrote the file")
+	}
+	if info, err := os.Stat(profile); err != nil || info.Size() == 0 {
+		t.Errorf("profile not written: %v", err)
+	}
+}
+
+// TestRunCLIPprofErrors rejects unknown profile kinds and a file without a
+// kind.
+func TestRunCLIPprofErrors(t *testing.T) {
+	for _, args := range [][]string{{"--pprof=block", "x.json"}, {"--pprof-file=p.out", "x.json"}} {
+		captureOutput(t, func() {
+			if code := RunCLI(args); code == 0 {
+				t.Errorf("%v accepted", args)
+			}
+		})
+	}
+}
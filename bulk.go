@@ -0,0 +1,221 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Input formats for "talia import --format".
+const (
+	importFormatZone  = "zone"  // drop lists, zone files, zone diffs, one domain per line
+	importFormatComma = "comma" // domains separated by commas, semicolons, or spaces
+	importFormatCSV   = "csv"   // CSV with a header row naming a domain column
+)
+
+// validImportFormat reports whether f is an import format.
+func validImportFormat(f string) bool {
+	return f == importFormatZone || f == importFormatComma || f == importFormatCSV
+}
+
+// newLineParser returns a fresh parser for one file in format f.
+func newLineParser(f string) lineParser {
+	switch f {
+	case importFormatComma:
+		return parseCommaLine
+	case importFormatCSV:
+		return csvParser()
+	default:
+		return zoneParser()
+	}
+}
+
+// parseCommaLine reads every domain in a line of a comma-separated list, the
+// form registrar bulk-search boxes take: "a.com, b.com; c.com d.com".
+// Tokens that aren't domains are skipped, as are comment lines.
+func parseCommaLine(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+		return nil, nil
+	}
+	var domains []string
+	for _, token := range strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || r == ';' || unicode.IsSpace(r)
+	}) {
+		// zoneLineDomain reads a leading "-" as a zone diff marker.
+		if strings.HasPrefix(token, "-") {
+			continue
+		}
+		if domain := zoneLineDomain(token, ""); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+// csvDomainColumns are the header names recognized as the domain column of
+// a CSV import, compared case-insensitively.
+var csvDomainColumns = []string{"domain", "domain name", "domainname", "domain_name"}
+
+// csvParser returns a parser for CSV files such as registrar bulk-search
+// exports: the first non-blank line is the header, and the domain is read
+// from the column named like csvDomainColumns.
+func csvParser() lineParser {
+	column := -1
+	return func(line string) ([]string, error) {
+		if strings.TrimSpace(line) == "" {
+			return nil, nil
+		}
+		fields, err := csv.NewReader(strings.NewReader(line)).Read()
+		if err != nil {
+			return nil, err
+		}
+		if column < 0 {
+			for i, name := range fields {
+				for _, want := range csvDomainColumns {
+					if strings.EqualFold(strings.TrimSpace(name), want) {
+						column = i
+					}
+				}
+			}
+			if column < 0 {
+				return nil, fmt.Errorf("no Domain column in CSV header %q", line)
+			}
+			return nil, nil
+		}
+		if column >= len(fields) {
+			return nil, nil
+		}
+		if domain := zoneLineDomain(strings.TrimSpace(fields[column]), ""); domain != "" {
+			return []string{domain}, nil
+		}
+		return nil, nil
+	}
+}
+
+// Output formats for "talia export".
+const (
+	exportFormatLines = "lines" // one domain per line
+	exportFormatComma = "comma" // one line, comma-separated
+	exportFormatCSV   = "csv"   // Domain,Status,Price,Currency with a header row
+)
+
+// exportColumns are the columns of "talia export --format=csv". Domain comes
+// first, so registrar bulk tools that read the first column accept it.
+var exportColumns = []string{"Domain", "Status", "Price", "Currency"}
+
+// exportSelection picks records for "talia export" by bucket: "available",
+// "unavailable" (taken or unknown), "unverified", or "all".
+func exportSelection(status string, rec DomainRecord) bool {
+	checked := rec.Status != "" || rec.Reason != "" || rec.Available
+	switch status {
+	case "all":
+		return true
+	case bucketUnverified:
+		return !checked
+	case bucketUnavailable:
+		return checked && !rec.Available
+	default:
+		return rec.Available
+	}
+}
+
+// writeExport writes the domains of records to w in format.
+func writeExport(w io.Writer, format string, records []DomainRecord) error {
+	switch format {
+	case exportFormatComma:
+		domains := make([]string, len(records))
+		for i, rec := range records {
+			domains[i] = rec.Domain
+		}
+		if len(domains) == 0 {
+			return nil
+		}
+		_, err := fmt.Fprintln(w, strings.Join(domains, ", "))
+		return err
+	case exportFormatCSV:
+		rows := make([][]string, len(records))
+		for i, rec := range records {
+			price := ""
+			if rec.FirstYearPrice > 0 {
+				price = strconv.FormatFloat(rec.FirstYearPrice, 'f', 2, 64)
+			}
+			rows[i] = []string{rec.Domain, string(rec.Status), price, rec.PriceCurrency}
+		}
+		return writeRows(w, formatCSV, exportColumns, rows)
+	default:
+		for _, rec := range records {
+			if _, err := fmt.Fprintln(w, rec.Domain); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// runExportCommand implements "talia export <file>": the domains of an
+// array, JSON Lines, or grouped file are written in a plain bulk format that
+// registrar bulk-search tools accept, to stdout or --output.
+func runExportCommand(args []string) int {
+	fs := flag.NewFlagSet("talia export", flag.ContinueOnError)
+	format := fs.String("format", exportFormatLines, "Output format: 'lines' (one domain per line), 'comma' (comma-separated), or 'csv' (Domain,Status,Price,Currency)")
+	status := fs.String("status", bucketAvailable, "Which domains to export: 'available', 'unavailable', 'unverified', or 'all'")
+	curation := addCurationFlags(fs, "export")
+	output := fs.String("output", "", "Write to this file instead of stdout")
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: talia export [--format=lines|comma|csv] [--status=available|unavailable|unverified|all] [--output=file] <json-file>")
+		return 1
+	}
+	if *format != exportFormatLines && *format != exportFormatComma && *format != exportFormatCSV {
+		fmt.Fprintf(os.Stderr, "Error: --format must be %q, %q, or %q\n", exportFormatLines, exportFormatComma, exportFormatCSV)
+		return 1
+	}
+	switch *status {
+	case bucketAvailable, bucketUnavailable, bucketUnverified, "all":
+	default:
+		fmt.Fprintln(os.Stderr, "Error: --status must be available, unavailable, unverified, or all")
+		return 1
+	}
+
+	records, err := readRecords(files[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading file:", err)
+		return 1
+	}
+	var selected []DomainRecord
+	for _, rec := range curation.apply(records) {
+		if exportSelection(*status, rec) {
+			selected = append(selected, rec)
+		}
+	}
+
+	if *output == "" {
+		if err := writeExport(os.Stdout, *format, selected); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing export:", err)
+			return 1
+		}
+		return 0
+	}
+	var buf bytes.Buffer
+	if err := writeExport(&buf, *format, selected); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing export:", err)
+		return 1
+	}
+	if err := auditWrite("export", "", *output, func() error { return writeFileAtomic(*output, buf.Bytes(), 0644) }); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing file:", err)
+		return 1
+	}
+	fmt.Printf("Exported %d domains to %s\n", len(selected), *output)
+	return 0
+}
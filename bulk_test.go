@@ -0,0 +1,113 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestParseCommaLine reads every domain on a line and skips other tokens.
+func TestParseCommaLine(t *testing.T) {
+	t.Parallel()
+	got, _ := parseCommaLine("A.com, b.io;c.co.uk  d.net, notadomain, -e.com")
+	if want := []string{"a.com", "b.io", "c.co.uk", "d.net"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, _ := parseCommaLine("# a.com, b.com"); got != nil {
+		t.Errorf("comment line: %v", got)
+	}
+}
+
+// TestCSVParser finds the domain column by its header name.
+func TestCSVParser(t *testing.T) {
+	t.Parallel()
+	parse := csvParser()
+	var got []string
+	for _, line := range []string{"Status,Domain Name,Price", "", "Available,brand.com,12.98", `Taken,"Other.net",`, "short"} {
+		domains, err := parse(line)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		got = append(got, domains...)
+	}
+	if want := []string{"brand.com", "other.net"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := csvParser()("a,b,c"); err == nil || !strings.Contains(err.Error(), "no Domain column") {
+		t.Errorf("header without a domain column: %v", err)
+	}
+}
+
+// TestRunImportCommandFormats imports comma-separated and CSV files.
+func TestRunImportCommandFormats(t *testing.T) {
+	dir := t.TempDir()
+	comma := filepath.Join(dir, "list.txt")
+	csvFile := filepath.Join(dir, "bulk.csv")
+	if err := os.WriteFile(comma, []byte("a.com, b.com\nc.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(csvFile, []byte("Status,Domain\nAvailable,d.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"import", "--format=comma", comma}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "a.com\nb.com\nc.com\n" {
+		t.Errorf("comma: stdout = %q", stdout)
+	}
+	stdout, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"import", "--format=csv", csvFile}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "d.com\n" {
+		t.Errorf("csv: stdout = %q", stdout)
+	}
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"import", "--format=csv", comma}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "line 1: no Domain column") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestRunExportCommand writes the selected bucket in each bulk format.
+func TestRunExportCommand(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{
+		Available:   []GroupedDomain{{Domain: "a.com", Status: StatusAvailable, FirstYearPrice: 9.5, PriceCurrency: "USD"}, {Domain: "b.com", Rejected: true}},
+		Unavailable: []GroupedDomain{{Domain: "t.com", Reason: ReasonTaken}},
+		Unverified:  []DomainRecord{{Domain: "u.com"}},
+	})
+	run := func(args ...string) string {
+		stdout, _ := captureOutput(t, func() {
+			if code := RunCLI(append([]string{"export"}, args...)); code != 0 {
+				t.Errorf("%v: exit %d", args, code)
+			}
+		})
+		return stdout
+	}
+	if got := run(path); got != "a.com\nb.com\n" {
+		t.Errorf("lines = %q", got)
+	}
+	if got := run("--format=comma", "--status=all", "--exclude-rejected", path); got != "a.com, t.com, u.com\n" {
+		t.Errorf("comma = %q", got)
+	}
+	if got := run("--format=csv", "--status=unavailable", path); got != "Domain,Status,Price,Currency\nt.com,taken,,\n" {
+		t.Errorf("csv unavailable = %q", got)
+	}
+	if got := run("--format=csv", "--exclude-rejected", path); got != "Domain,Status,Price,Currency\na.com,available,9.50,USD\n" {
+		t.Errorf("csv = %q", got)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	run("--status=unverified", "--output="+out, path)
+	if raw, _ := os.ReadFile(out); string(raw) != "u.com\n" {
+		t.Errorf("output file = %q", raw)
+	}
+}
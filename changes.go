@@ -0,0 +1,119 @@
+package talia
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// volatileWhoisLines are lowercase markers of response lines that change on
+// every query, such as Verisign's ">>> Last update of whois database: ...
+// <<<", and so are left out of responseHash.
+var volatileWhoisLines = []string{
+	">>>",
+	"last update of whois database",
+	"query time",
+	"timestamp:",
+}
+
+// responseHash returns a hash of resp that ignores what changes between
+// identical answers: line endings, spacing, case, and the lines matching
+// volatileWhoisLines.
+func responseHash(resp string) string {
+	h := sha256.New()
+	for line := range strings.Lines(resp) {
+		line = normalizePhrase(line)
+		if line == "" || isVolatileWhoisLine(line) {
+			continue
+		}
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// isVolatileWhoisLine reports whether a normalized response line is one of
+// volatileWhoisLines.
+func isVolatileWhoisLine(line string) bool {
+	for _, m := range volatileWhoisLines {
+		if strings.Contains(line, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseChanged reports whether res got a different response than the
+// check that produced prev, while the verdict stayed the same: a transfer,
+// a status change, or new name servers on a domain that is still taken.
+func responseChanged(prev DomainRecord, res checkResult) bool {
+	return res.checked() && prev.ResponseHash != "" && res.ResponseHash != "" &&
+		res.ResponseHash != prev.ResponseHash && statusFor(res.Avail, res.Reason) == prev.Status
+}
+
+// Fields compared by fieldChanges.
+const (
+	changeRegistrar   = "registrar"
+	changeNameServers = "nameServers"
+)
+
+// Change is a parsed WHOIS field of a taken domain that differs from its
+// previous check: a registrar transfer or new name servers, often the first
+// sign that a domain is about to drop or has been sold. Name server lists
+// are joined with ", ".
+type Change struct {
+	Domain string `json:"domain"`
+	Field  string `json:"field"` // "registrar" or "nameServers"
+	Old    string `json:"old"`
+	New    string `json:"new"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s", c.Domain, c.Field, c.Old, c.New)
+}
+
+// fieldChanges compares the registrar and name servers of a domain that
+// was taken at its previous check (prev) and still is (res). Fields missing
+// on either side, as in records from older versions or --dns-precheck
+// verdicts, are not compared.
+func fieldChanges(prev DomainRecord, res checkResult) []Change {
+	if prev.Status != StatusTaken || !res.checked() || res.Reason != ReasonTaken {
+		return nil
+	}
+	var changes []Change
+	if prev.Registrar != "" && res.Registrar != "" && !strings.EqualFold(prev.Registrar, res.Registrar) {
+		changes = append(changes, Change{Domain: res.Domain, Field: changeRegistrar, Old: prev.Registrar, New: res.Registrar})
+	}
+	oldNS, newNS := slices.Sorted(slices.Values(prev.NameServers)), slices.Sorted(slices.Values(res.NameServers))
+	if len(oldNS) > 0 && len(newNS) > 0 && !slices.Equal(oldNS, newNS) {
+		changes = append(changes, Change{Domain: res.Domain, Field: changeNameServers, Old: strings.Join(oldNS, ", "), New: strings.Join(newNS, ", ")})
+	}
+	return changes
+}
+
+// trackChanges compares each result with the record it was checked from
+// (previous[i] is the input record of results[i], as the run read it). It
+// prints the domains whose response changed with the same verdict (see
+// responseChanged) and each registrar or name server change, which it also
+// stores on the result for the run's notifications.
+func trackChanges(cfg runConfig, previous []DomainRecord, results []checkResult) {
+	var changed []string
+	for i := range results {
+		if i >= len(previous) {
+			break
+		}
+		res := &results[i]
+		if responseChanged(previous[i], *res) {
+			changed = append(changed, fmt.Sprintf("%s (%s)", res.Domain, statusFor(res.Avail, res.Reason)))
+		}
+		res.Changes = fieldChanges(previous[i], *res)
+		for _, c := range res.Changes {
+			fmt.Fprintf(cfg.status(), "Changed: %s\n", c)
+		}
+	}
+	if len(changed) > 0 {
+		fmt.Fprintf(cfg.status(), "Response changed with the same verdict: %s\n", strings.Join(changed, ", "))
+	}
+}
@@ -0,0 +1,117 @@
+package talia
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+func TestResponseHash(t *testing.T) {
+	a := "Domain Name: A.COM\r\nRegistrar: Example\r\n>>> Last update of whois database: 2026-01-02T03:04:05Z <<<\r\n"
+	b := "domain name:   a.com\nRegistrar: Example\n\n>>> Last update of whois database: 2026-02-03T04:05:06Z <<<\n"
+	if responseHash(a) != responseHash(b) {
+		t.Error("hash changed with the spacing and the update time")
+	}
+	if c := strings.Replace(a, "Example", "Other", 1); responseHash(a) == responseHash(c) {
+		t.Error("hash ignored a registrar change")
+	}
+}
+
+func TestResponseChanged(t *testing.T) {
+	prev := DomainRecord{Domain: "a.com", Status: StatusTaken, ResponseHash: "old"}
+	taken := checkResult{Domain: "a.com", Reason: ReasonTaken, ResponseHash: "new"}
+	if !responseChanged(prev, taken) {
+		t.Error("changed taken response not reported")
+	}
+	if freed := (checkResult{Domain: "a.com", Avail: true, Reason: ReasonNoMatch, ResponseHash: "new"}); responseChanged(prev, freed) {
+		t.Error("verdict change reported as a response change")
+	}
+	if responseChanged(DomainRecord{Domain: "a.com", Status: StatusTaken}, taken) {
+		t.Error("record without a hash reported")
+	}
+}
+
+// TestRunCLI_ResponseChanged reports taken domains whose response changed
+// since the hash stored by the last check, and stores the new hash.
+func TestRunCLI_ResponseChanged(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("moved.com", taliatest.Response{Body: taliatest.Registered("moved.com")})
+	srv.Handle("same.com", taliatest.Response{Body: taliatest.Registered("same.com")})
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{
+		{Domain: "moved.com", Status: StatusTaken, ResponseHash: "0123"},
+		{Domain: "same.com", Status: StatusTaken, ResponseHash: responseHash(taliatest.Registered("same.com"))},
+	}})
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Response changed with the same verdict: moved.com (taken)\n") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gd := range data.Unavailable {
+		if gd.ResponseHash != responseHash(taliatest.Registered(gd.Domain)) {
+			t.Errorf("%s: responseHash = %q", gd.Domain, gd.ResponseHash)
+		}
+	}
+}
+
+func TestFieldChanges(t *testing.T) {
+	prev := DomainRecord{Domain: "a.com", Status: StatusTaken, Registrar: "Old Registrar", NameServers: []string{"ns2.old.net", "ns1.old.net"}}
+	res := checkResult{Domain: "a.com", Reason: ReasonTaken, Registrar: "New Registrar", NameServers: []string{"ns1.new.net"}}
+	got := fieldChanges(prev, res)
+	if len(got) != 2 || got[0].String() != "a.com registrar: Old Registrar -> New Registrar" ||
+		got[1].String() != "a.com nameServers: ns1.old.net, ns2.old.net -> ns1.new.net" {
+		t.Errorf("changes = %v", got)
+	}
+
+	same := checkResult{Domain: "a.com", Reason: ReasonTaken, Registrar: "old registrar", NameServers: []string{"ns1.old.net", "ns2.old.net"}}
+	if got := fieldChanges(prev, same); got != nil {
+		t.Errorf("reordered name servers and registrar case: %v", got)
+	}
+	if got := fieldChanges(prev, checkResult{Domain: "a.com", Reason: ReasonTaken}); got != nil {
+		t.Errorf("missing fields compared: %v", got)
+	}
+	if got := fieldChanges(prev, checkResult{Domain: "a.com", Avail: true, Reason: ReasonNoMatch}); got != nil {
+		t.Errorf("available domain compared: %v", got)
+	}
+}
+
+// TestRunCLI_ChangeNotification tells --notify targets about a registrar
+// transfer found by a re-check.
+func TestRunCLI_ChangeNotification(t *testing.T) {
+	var event Event
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&event)
+	}))
+	defer hook.Close()
+	srv := newWhoisServer(t)
+	srv.Handle("sold.com", taliatest.Response{Body: taliatest.Registered("sold.com")})
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{
+		{Domain: "sold.com", Status: StatusTaken, Registrar: "Parking Registrar LLC"},
+	}})
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--notify=" + hook.URL, path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	want := Change{Domain: "sold.com", Field: changeRegistrar, Old: "Parking Registrar LLC", New: "Example Registrar, Inc."}
+	if len(event.Changes) != 1 || event.Changes[0] != want {
+		t.Errorf("event changes = %+v", event.Changes)
+	}
+	if !strings.Contains(stdout, "Changed: "+want.String()) {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if !strings.Contains(event.Summary(), "1 registrar/name server changes") {
+		t.Errorf("summary = %q", event.Summary())
+	}
+}
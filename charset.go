@@ -0,0 +1,65 @@
+package talia
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// windows1252High maps the bytes 0x80-0x9F of Windows-1252 to Unicode. The
+// five bytes Windows-1252 leaves undefined map to the C1 controls of the
+// same value, as in ISO-8859-1, so every byte decodes to something.
+var windows1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeWhoisResponse returns a WHOIS response as UTF-8 text. WHOIS has no
+// way to declare a charset, so it is guessed:
+//
+//   - a UTF-16 byte order mark: UTF-16 in that byte order
+//   - valid UTF-8 (which includes plain ASCII): as is, minus a byte order mark
+//   - anything else: Windows-1252, the superset of ISO-8859-1 that European
+//     registries answer in
+//
+// Multi-byte legacy encodings such as Shift-JIS come out garbled, but the
+// ASCII in them is kept, and the result is always valid UTF-8, so JSON
+// output never replaces bytes silently.
+func decodeWhoisResponse(data []byte) string {
+	switch {
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return decodeUTF16(data[2:], true)
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return decodeUTF16(data[2:], false)
+	case utf8.Valid(data):
+		return string(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF")))
+	}
+	var b strings.Builder
+	b.Grow(len(data) + len(data)/4)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xA0:
+			b.WriteRune(windows1252High[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// decodeUTF16 decodes big- or little-endian UTF-16; an odd trailing byte is
+// dropped.
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		hi, lo := data[2*i], data[2*i+1]
+		if !bigEndian {
+			hi, lo = lo, hi
+		}
+		units[i] = uint16(hi)<<8 | uint16(lo)
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,57 @@
+package talia
+
+import (
+	"encoding/json"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+func TestDecodeWhoisResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"ascii", []byte("No match for \"A.COM\".\r\n"), "No match for \"A.COM\".\r\n"},
+		{"utf-8", []byte("Registrant: Müller GmbH"), "Registrant: Müller GmbH"},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFStatus: free"), "Status: free"},
+		{"latin-1", []byte("Registrant: M\xFCller GmbH, Z\xFCrich"), "Registrant: Müller GmbH, Zürich"},
+		{"windows-1252", []byte("Price: \x80 10 \x96 \x93quoted\x94"), "Price: € 10 – “quoted”"},
+		{"undefined 1252 byte", []byte("a\x81b"), "a\u0081b"},
+		{"utf-16be", []byte("\xFE\xFF\x00S\x00t\x00a\x00t\x00u\x00s\x00:\x00 \x00f\x00r\x00e\x00e"), "Status: free"},
+		{"utf-16le", []byte("\xFF\xFEf\x00\xFC\x00r\x00"), "für"},
+	} {
+		got := decodeWhoisResponse(tc.in)
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: invalid UTF-8 %q", tc.name, got)
+		}
+	}
+}
+
+// TestCheckOneLatin1 classifies a Latin-1 response and stores its log
+// without replacement characters.
+func TestCheckOneLatin1(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("strasse.de", taliatest.Response{Body: "Domain: stra\xDFe.de\nStatus:\tfree\n"})
+
+	res := checkOne("strasse.de", runConfig{whoisServer: srv.Addr, verbose: true})
+	if !res.Avail {
+		t.Fatalf("result = %+v", res)
+	}
+	out, err := json.Marshal(res.record())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rec DomainRecord
+	if err := json.Unmarshal(out, &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Log != "Domain: straße.de\nStatus:\tfree\n" {
+		t.Errorf("log = %q", rec.Log)
+	}
+}
@@ -0,0 +1,222 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// cleanOptions are the steps of "talia clean".
+type cleanOptions struct {
+	lowercase    bool // fold domains to lowercase, trimming spaces and a trailing dot
+	dedupe       bool // keep the first record of each domain
+	sort         bool // sort records by domain (within each bucket)
+	stripLogs    bool // remove the "log" field
+	dropRejected bool // remove records marked rejected
+	toGrouped    bool // convert an array file to grouped format
+}
+
+// cleanStats counts what "talia clean" changed.
+type cleanStats struct {
+	recased, duplicates, logs, rejected int
+	sorted, converted                   bool
+}
+
+// String summarizes s for the command's output.
+func (s cleanStats) String() string {
+	var parts []string
+	if s.recased > 0 {
+		parts = append(parts, fmt.Sprintf("fixed casing of %d domains", s.recased))
+	}
+	if s.duplicates > 0 {
+		parts = append(parts, fmt.Sprintf("removed %d duplicates", s.duplicates))
+	}
+	if s.rejected > 0 {
+		parts = append(parts, fmt.Sprintf("dropped %d rejected", s.rejected))
+	}
+	if s.logs > 0 {
+		parts = append(parts, fmt.Sprintf("stripped %d logs", s.logs))
+	}
+	if s.sorted {
+		parts = append(parts, "sorted by domain")
+	}
+	if s.converted {
+		parts = append(parts, "converted to grouped format")
+	}
+	if len(parts) == 0 {
+		return "nothing to change"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cleanFields gives cleanList access to the fields it edits on one record
+// type: the domain and log, and whether the record is rejected.
+type cleanFields[T any] func(*T) (domain, log *string, rejected bool)
+
+// cleanList applies opts to items, one array or bucket. seen is shared
+// across the buckets of a grouped file, so a domain in two buckets is kept
+// only in the first.
+func cleanList[T any](items []T, opts cleanOptions, seen map[string]bool, fields cleanFields[T], stats *cleanStats) []T {
+	kept := items[:0:0]
+	for _, item := range items {
+		domain, log, rejected := fields(&item)
+		if opts.dropRejected && rejected {
+			stats.rejected++
+			continue
+		}
+		if opts.lowercase {
+			if d := foldDomain(*domain); d != *domain {
+				*domain = d
+				stats.recased++
+			}
+		}
+		if opts.dedupe {
+			if seen[dupKey(*domain)] {
+				stats.duplicates++
+				continue
+			}
+			seen[dupKey(*domain)] = true
+		}
+		if opts.stripLogs && *log != "" {
+			*log = ""
+			stats.logs++
+		}
+		kept = append(kept, item)
+	}
+	if opts.sort {
+		byDomain := func(a, b T) int {
+			da, _, _ := fields(&a)
+			db, _, _ := fields(&b)
+			return strings.Compare(*da, *db)
+		}
+		if !slices.IsSortedFunc(kept, byDomain) {
+			slices.SortStableFunc(kept, byDomain)
+			stats.sorted = true
+		}
+	}
+	return kept
+}
+
+func recordFields(r *DomainRecord) (*string, *string, bool)   { return &r.Domain, &r.Log, r.Rejected }
+func groupedFields(g *GroupedDomain) (*string, *string, bool) { return &g.Domain, &g.Log, g.Rejected }
+
+// cleanedArrayToGrouped converts records to grouped format (see
+// ConvertArrayToGrouped).
+func cleanedArrayToGrouped(records []DomainRecord) ExtendedGroupedData {
+	return ExtendedGroupedData(ConvertArrayToGrouped(records))
+}
+
+// cleanFile applies opts to the array, JSON Lines, or grouped file at path
+// (including its pending log) and rewrites it if anything changed.
+func cleanFile(path string, opts cleanOptions) (cleanStats, error) {
+	var stats cleanStats
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return stats, err
+	}
+	seen := make(map[string]bool)
+
+	var out []byte
+	var records []DomainRecord
+	jsonLines := isJSONLines(path)
+	if jsonLines {
+		if records, err = parseJSONLines(raw); err != nil {
+			return stats, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	} else if json.Unmarshal(raw, &records) != nil {
+		records = nil
+	}
+	switch {
+	case records != nil || jsonLines:
+		records = cleanList(records, opts, seen, recordFields, &stats)
+		switch {
+		case opts.toGrouped && jsonLines:
+			return stats, fmt.Errorf("--to-grouped can't write grouped JSON to the JSON Lines file %s", path)
+		case opts.toGrouped:
+			stats.converted = true
+			out, err = json.MarshalIndent(cleanedArrayToGrouped(records), "", "  ")
+		case jsonLines:
+			out, err = marshalJSONLines(records)
+		default:
+			if records == nil {
+				records = []DomainRecord{}
+			}
+			out, err = json.MarshalIndent(records, "", "  ")
+		}
+	default:
+		var ext ExtendedGroupedData
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return stats, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if err := applyPendingLog(path, &ext); err != nil {
+			return stats, err
+		}
+		ext.Available = cleanList(ext.Available, opts, seen, groupedFields, &stats)
+		ext.Unavailable = cleanList(ext.Unavailable, opts, seen, groupedFields, &stats)
+		ext.Unverified = cleanList(ext.Unverified, opts, seen, recordFields, &stats)
+		out, err = json.MarshalIndent(ext, "", "  ")
+	}
+	if err != nil {
+		return stats, err
+	}
+	if bytes.Equal(bytes.TrimSpace(out), bytes.TrimSpace(raw)) {
+		return stats, clearPendingLog(path)
+	}
+	return stats, auditWrite("clean", "", path, func() error {
+		if err := writeFileAtomic(path, out, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(path)
+	})
+}
+
+// runCleanCommand implements "talia clean <file>...": each file is
+// normalized in place. With no step flags it fixes casing, removes
+// duplicates, and sorts; --all adds the steps that drop data or change the
+// format.
+func runCleanCommand(args []string) int {
+	fs := flag.NewFlagSet("talia clean", flag.ContinueOnError)
+	var opts cleanOptions
+	fs.BoolVar(&opts.lowercase, "lowercase", false, "Lowercase domains and trim spaces and trailing dots")
+	fs.BoolVar(&opts.dedupe, "dedupe", false, "Keep only the first record of each domain (available, then unavailable, then unverified)")
+	fs.BoolVar(&opts.sort, "sort", false, "Sort records by domain within each bucket")
+	fs.BoolVar(&opts.stripLogs, "strip-logs", false, "Remove the 'log' field from every record")
+	fs.BoolVar(&opts.dropRejected, "drop-rejected", false, "Remove records marked \"rejected\": true")
+	fs.BoolVar(&opts.toGrouped, "to-grouped", false, "Convert an array file to grouped format (unchecked records become unverified)")
+	all := fs.Bool("all", false, "Apply every step")
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: talia clean [--lowercase] [--dedupe] [--sort] [--strip-logs] [--drop-rejected] [--to-grouped] [--all] <json-file>...")
+		return 1
+	}
+	switch {
+	case *all:
+		opts = cleanOptions{lowercase: true, dedupe: true, sort: true, stripLogs: true, dropRejected: true, toGrouped: true}
+	case opts == cleanOptions{}:
+		opts = cleanOptions{lowercase: true, dedupe: true, sort: true}
+	}
+	status := 0
+	for _, path := range files {
+		// --all keeps a JSON Lines file JSON Lines rather than failing.
+		fileOpts := opts
+		if *all && isJSONLines(path) {
+			fileOpts.toGrouped = false
+		}
+		stats, err := cleanFile(path, fileOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning %s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %s\n", path, stats)
+	}
+	return status
+}
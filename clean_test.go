@@ -0,0 +1,93 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunCleanCommandDefault fixes casing, dedupes across buckets, and sorts
+// a grouped file, leaving logs and rejected records alone.
+func TestRunCleanCommandDefault(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{
+		Available:   []GroupedDomain{{Domain: "Zeta.com", Log: "x"}, {Domain: "alpha.com"}},
+		Unavailable: []GroupedDomain{{Domain: "ZETA.com"}, {Domain: "no.com", Rejected: true}},
+		Unverified:  []DomainRecord{{Domain: "beta.com."}},
+	})
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"clean", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "fixed casing of 3 domains, removed 1 duplicates, sorted by domain") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Available) != 2 || data.Available[0].Domain != "alpha.com" || data.Available[1].Domain != "zeta.com" || data.Available[1].Log != "x" {
+		t.Errorf("available = %+v", data.Available)
+	}
+	if len(data.Unavailable) != 1 || !data.Unavailable[0].Rejected || data.Unverified[0].Domain != "beta.com" {
+		t.Errorf("file = %+v", data)
+	}
+
+	stdout, _ = captureOutput(t, func() { RunCLI([]string{"clean", path}) })
+	if !strings.Contains(stdout, "nothing to change") {
+		t.Errorf("second run: stdout = %q", stdout)
+	}
+}
+
+// TestRunCleanCommandAll upgrades an array file to grouped format, dropping
+// rejected records and logs.
+func TestRunCleanCommandAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.json")
+	list := `[{"domain":"b.com","available":true,"status":"available","reason":"NO_MATCH","log":"l"},{"domain":"c.com","rejected":true},{"domain":"a.com"},{"domain":"t.com","status":"taken","reason":"TAKEN"}]`
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"clean", "--all", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	raw, _ := os.ReadFile(path)
+	var ext ExtendedGroupedData
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		t.Fatalf("not grouped: %s", raw)
+	}
+	if len(ext.Available) != 1 || ext.Available[0].Log != "" || len(ext.Unavailable) != 1 ||
+		len(ext.Unverified) != 1 || ext.Unverified[0].Domain != "a.com" {
+		t.Errorf("file = %s", raw)
+	}
+}
+
+// TestRunCleanCommandJSONLines keeps JSON Lines files in their format and
+// refuses to convert them.
+func TestRunCleanCommandJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.jsonl")
+	if err := os.WriteFile(path, []byte("{\"domain\":\"B.com\"}\n{\"domain\":\"a.com\"}\n{\"domain\":\"b.com\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"clean", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	raw, _ := os.ReadFile(path)
+	if string(raw) != "{\"domain\":\"a.com\"}\n{\"domain\":\"b.com\"}\n" {
+		t.Errorf("file = %q", raw)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"clean", "--to-grouped", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "JSON Lines") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
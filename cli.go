@@ -3,21 +3,82 @@
 package talia
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
 // checkResult holds the result of a single domain availability check.
 type checkResult struct {
-	Domain string
-	Avail  bool
-	Reason AvailabilityReason
-	Log    string
+	Domain         string
+	Avail          bool
+	Reason         AvailabilityReason
+	Log            string
+	LogFile        string     // see logSpill
+	ResponseHash   string     // see responseHash
+	Privacy        bool       // WHOIS contact data is redacted by policy
+	Registrar      string     // parsed from the WHOIS response of taken domains
+	CreatedAt      time.Time  // likewise
+	ExpiresAt      time.Time  // likewise
+	EPPStatus      []string   // likewise
+	NameServers    []string   // likewise
+	Registrant     string     // likewise, but not stored; see markOwned
+	Changes        []Change   // not stored; see trackChanges
+	Parked         bool       // see parkedWhois and addParkedProbes
+	HTTP           httpProbe  // see addHTTPProbes
+	OwnedByUs      bool       // see markOwned
+	Confidence     float64    // see confidence.go
+	Alternatives   []string   // see findAlternatives
+	EstimatedValue float64    // see addValuations
+	Price          priceQuote // see addPrices
+	CheckedAt      time.Time
+	RunID          string
+	Attempts       int // checks of the domain so far, including this one
+}
+
+// record converts res to the DomainRecord written to output files.
+func (res checkResult) record() DomainRecord {
+	return DomainRecord{
+		Domain:           res.Domain,
+		Available:        res.Avail,
+		Status:           statusFor(res.Avail, res.Reason),
+		Reason:           res.Reason,
+		Log:              res.Log,
+		LogFile:          res.LogFile,
+		ResponseHash:     res.ResponseHash,
+		PrivacyProtected: res.Privacy,
+		Registrar:        res.Registrar,
+		CreatedAt:        res.CreatedAt,
+		ExpiresAt:        res.ExpiresAt,
+		EPPStatus:        res.EPPStatus,
+		OnHold:           hasStatus(res.EPPStatus, holdStatuses),
+		InRedemption:     hasStatus(res.EPPStatus, redemptionStatuses),
+		NameServers:      res.NameServers,
+		Parked:           res.Parked,
+		HTTPStatus:       res.HTTP.Status,
+		HTTPRedirect:     res.HTTP.Redirect,
+		HTTPError:        res.HTTP.Error,
+		Alternatives:     res.Alternatives,
+		EstimatedValue:   res.EstimatedValue,
+		FirstYearPrice:   res.Price.Price,
+		PriceCurrency:    res.Price.Currency,
+		Premium:          res.Price.Premium,
+		OwnedByUs:        res.OwnedByUs,
+		Confidence:       res.Confidence,
+		CheckedAt:        res.CheckedAt,
+		RunID:            res.RunID,
+		Attempts:         res.Attempts,
+	}
 }
 
 // shouldIncludeLog determines whether to include the WHOIS log in output.
@@ -25,62 +86,254 @@ func shouldIncludeLog(verbose bool, reason AvailabilityReason) bool {
 	return verbose || reason == ReasonError
 }
 
-// checkDomains performs WHOIS checks on a list of domains and returns the results.
-// If workers > 0, it uses parallel processing with the specified number of workers.
-// If workers == 0, it uses sequential processing with sleep between checks.
-func checkDomains(domains []string, whoisServer string, sleep time.Duration, verbose bool, workers int) []checkResult {
-	if workers > 0 {
-		return checkDomainsParallel(domains, whoisServer, verbose, workers)
+// dnsPrecheckLog is recorded for domains the DNS pre-check found delegated.
+const dnsPrecheckLog = "DNS pre-check: nameservers found, WHOIS skipped"
+
+// checkDomains checks a list of domains and returns the results in input order.
+// With cfg.dnsPrecheck set, delegated domains are first filtered out by a
+// parallel DNS pass and marked taken without a WHOIS query; the rest go to
+// WHOIS. If cfg.workers is non-zero, WHOIS uses parallel processing with that
+// many workers (-1 for one per domain); if 0, it checks sequentially with
+// cfg.sleep between checks.
+func checkDomains(domains []string, cfg runConfig) []checkResult {
+	if cfg.runID != "" {
+		fmt.Fprintln(cfg.status(), "Run ID:", cfg.runID)
 	}
-	return checkDomainsSequential(domains, whoisServer, sleep, verbose)
+	results := checkDomainsAll(domains, cfg)
+	reportUnchecked(cfg, results)
+	cfg.whoisCache.reportHits(cfg)
+	cfg.logSpill.report(cfg)
+	enrichResults(results, cfg)
+	return results
 }
 
-// checkDomainsSequential performs WHOIS checks sequentially with sleep between checks.
-func checkDomainsSequential(domains []string, whoisServer string, sleep time.Duration, verbose bool) []checkResult {
-	results := make([]checkResult, 0, len(domains))
-	prog := newProgress(len(domains))
-	stats := newCheckStats()
-
-	for _, domain := range domains {
-		avail, reason, logData, err := CheckDomainAvailability(domain, whoisServer)
-		if err != nil {
-			avail = false
-			reason = ReasonError
-			logData = fmt.Sprintf("Error: %v", err)
+// enrichResults stamps the run ID on checked results and adds what cfg asks
+// for beyond the verdict: alternatives, valuations, prices, parking and
+// site probes, ownership, and the log size limit.
+func enrichResults(results []checkResult, cfg runConfig) {
+	for i := range results {
+		if !results[i].checked() {
+			continue
 		}
+		results[i].RunID = cfg.runID
+		results[i].Attempts = 1
+	}
+	if cfg.alternatives > 0 {
+		findAlternatives(results, cfg)
+	}
+	if cfg.valuationURL != "" {
+		addValuations(results, cfg)
+	}
+	if cfg.pricingURL != "" {
+		addPrices(results, cfg)
+	}
+	if cfg.parkedProbeURL != "" {
+		addParkedProbes(results, cfg)
+	}
+	if cfg.httpProbeURL != "" {
+		addHTTPProbes(results, cfg)
+	}
+	if cfg.portfolio != nil {
+		markOwned(results, cfg)
+	}
+	truncateResultLogs(results, cfg.maxLogBytes)
+}
 
-		prog.IncrementAndPrint(domain, avail, reason)
-		stats.Record(avail, reason)
+// checkDomainsAll runs the optional DNS pre-check and the WHOIS phase.
+func checkDomainsAll(domains []string, cfg runConfig) []checkResult {
+	if !cfg.dnsPrecheck {
+		return checkDomainsWhois(domains, cfg)
+	}
+
+	resolver := cfg.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	delegated := dnsPrecheck(resolver, domains, cfg.dnsConcurrency)
+
+	results := make([]checkResult, len(domains))
+	var pending []string
+	var pendingIdx []int
+	for i, domain := range domains {
+		if !delegated[i] {
+			pending = append(pending, domain)
+			pendingIdx = append(pendingIdx, i)
+			continue
+		}
+		res := checkResult{Domain: domain, Reason: ReasonTaken, Confidence: dnsTakenConfidence, CheckedAt: cfg.timeSource().Now().UTC()}
+		if shouldIncludeLog(cfg.verbose, ReasonTaken) {
+			res.Log = dnsPrecheckLog
+		}
+		results[i] = res
+	}
+	fmt.Fprintf(cfg.status(), "DNS pre-check: %d of %d domains delegated, skipping WHOIS for them\n", len(domains)-len(pending), len(domains))
 
-		log := ""
-		if shouldIncludeLog(verbose, reason) {
-			log = logData
+	if len(pending) > 0 {
+		for j, res := range checkDomainsWhois(pending, cfg) {
+			// No NS records agrees with an available verdict.
+			if res.Avail {
+				res.Confidence = agreeingConfidence(res.Confidence, dnsNoDelegationConfidence)
+			}
+			results[pendingIdx[j]] = res
 		}
+	}
+	return results
+}
+
+// checkDomainsWhois runs the WHOIS phase, in parallel or sequentially
+// depending on cfg.workers (-1 means one worker per domain). With
+// cfg.journal set, results are journaled as they complete (see journal.check).
+func checkDomainsWhois(domains []string, cfg runConfig) []checkResult {
+	if cfg.journal != nil {
+		return cfg.journal.check(domains, cfg)
+	}
+	return checkDomainsWhoisWith(domains, cfg, nil)
+}
+
+// checkDomainsWhoisWith is checkDomainsWhois without the journal, calling
+// onResult (if non-nil) as each check completes.
+func checkDomainsWhoisWith(domains []string, cfg runConfig, onResult func(checkResult)) []checkResult {
+	if cfg.workers != 0 {
+		return checkParallel(cfg, domains, onResult)
+	}
+	return checkSequential(cfg, domains, onResult)
+}
+
+// checkOne performs a single WHOIS check with cfg's lookup settings and
+// applies the log policy. An empty cfg.whoisServer routes the query by TLD
+// (see routeServer); cfg.queryFormats picks the query line for it,
+// cfg.limits bounds its time, cfg.dialer (if non-nil) picks the local
+// address, cfg.whoisCache (if non-nil) may answer it, cfg.logSpill (if
+// non-nil) may move the log to a file, and cfg's clock stamps the result.
+func checkOne(domain string, cfg runConfig) checkResult {
+	var avail bool
+	var reason AvailabilityReason
+	var logData string
+	var err error
+	whoisServer := cfg.whoisServer
+	if whoisServer == "" {
+		whoisServer, err = routeServer(domain)
+	}
+	client := &cachedWhoisClient{cache: cfg.whoisCache, dialer: cfg.dialer, client: NetWhoisClient{Server: whoisServer, QueryFormat: cfg.queryFormats.forDomain(domain, whoisServer), Timeout: cfg.limits.perDomain}}
+	if err == nil {
+		avail, reason, logData, err = CheckDomainAvailabilityWithClient(domain, client)
+	}
+	if err != nil {
+		avail = false
+		reason = ReasonError
+		logData = fmt.Sprintf("Error: %v", err)
+	}
 
-		results = append(results, checkResult{
-			Domain: domain,
-			Avail:  avail,
-			Reason: reason,
-			Log:    log,
-		})
+	log, logFile := "", ""
+	if shouldIncludeLog(cfg.verbose, reason) {
+		log, logFile = cfg.logSpill.keep(domain, logData)
+	}
 
-		time.Sleep(sleep)
+	res := checkResult{
+		Domain:     domain,
+		Avail:      avail,
+		Reason:     reason,
+		Log:        log,
+		LogFile:    logFile,
+		Confidence: whoisConfidence(domain, reason, logData),
+		CheckedAt:  cfg.timeSource().Now().UTC(),
+	}
+	if reason != ReasonError {
+		res.ResponseHash = responseHash(logData)
+	}
+	if !client.fetchedAt.IsZero() {
+		// A cached response is as old as its lookup.
+		res.CheckedAt = client.fetchedAt
 	}
+	if reason == ReasonTaken {
+		info := parseWhois(logData)
+		res.Privacy = isPrivacyProtected(logData)
+		res.Registrar = info.Registrar
+		res.CreatedAt = info.CreatedAt
+		res.ExpiresAt = info.ExpiresAt
+		res.EPPStatus = info.Statuses
+		res.Registrant = info.Registrant
+		res.NameServers = info.NameServers
+		res.Parked = parkedWhois(info.NameServers, info.Registrar)
+	}
+	return res
+}
 
+// checkSequential performs WHOIS checks one at a time with cfg (see
+// checkOne), sleeping cfg.sleepFor(domain) after each. Progress is printed
+// to cfg's status output, and onResult, if non-nil, gets each result as it
+// completes. When that output and stdin are terminals, the sleep shows a
+// countdown and the user can pause the run or skip a domain (see
+// runControl). Once cfg.limits' deadline passes or its goal is reached, the
+// remaining domains are left unchecked (see uncheckedResult).
+func checkSequential(cfg runConfig, domains []string, onResult func(checkResult)) []checkResult {
+	out, limits, clk := cfg.status(), cfg.limits, cfg.timeSource()
+	results := make([]checkResult, 0, len(domains))
+	prog := newProgress(out, len(domains))
+	stats := newCheckStats(out)
+	ctl := newRunControl(out)
+
+	for i, domain := range domains {
+		if limits.stopped(clk.Now()) {
+			for _, rest := range domains[i:] {
+				results = append(results, uncheckedResult(rest))
+			}
+			break
+		}
+		if ctl.skipNext() {
+			res := skippedResult(domain, clk)
+			prog.IncrementAndPrint(domain, res.Avail, res.Reason)
+			stats.Record(res.Avail, res.Reason)
+			results = append(results, res)
+			continue
+		}
+		res := checkOne(domain, cfg)
+		limits.goal.record(res)
+		prog.IncrementAndPrint(domain, res.Avail, res.Reason)
+		stats.Record(res.Avail, res.Reason)
+		if onResult != nil {
+			onResult(res)
+		}
+		results = append(results, res)
+
+		// A pause that would outlast the deadline ends the run now.
+		pause := cfg.sleepFor(domain)
+		if limits.stopped(clk.Now().Add(pause)) {
+			for _, rest := range domains[i+1:] {
+				results = append(results, uncheckedResult(rest))
+			}
+			break
+		}
+		ctl.wait(clk, pause)
+	}
+
+	prog.Finish()
 	stats.PrintSummary()
 	return results
 }
 
-// checkDomainsParallel performs WHOIS checks using a worker pool.
+// checkDomainsParallel performs WHOIS checks against whoisServer using a
+// worker pool, with no other run settings.
 func checkDomainsParallel(domains []string, whoisServer string, verbose bool, workers int) []checkResult {
+	return checkParallel(runConfig{whoisServer: whoisServer, verbose: verbose, workers: workers}, domains, nil)
+}
+
+// checkParallel performs WHOIS checks with cfg (see checkOne) using a pool
+// of cfg.workers workers. Progress is printed to cfg's status output, and
+// onResult, if non-nil, gets each result as it completes (from the worker
+// goroutines). Once cfg.limits' deadline passes or its goal is reached, no
+// more domains are handed to the workers and the rest are left unchecked.
+// Checks already running finish, so a goal may be overshot.
+func checkParallel(cfg runConfig, domains []string, onResult func(checkResult)) []checkResult {
+	out, limits, clk, workers := cfg.status(), cfg.limits, cfg.timeSource(), cfg.workers
 	// workers == -1 means unlimited (one per domain)
 	if workers < 0 || workers > len(domains) {
 		workers = len(domains)
 	}
 
 	results := make([]checkResult, len(domains))
-	prog := newProgress(len(domains))
-	stats := newCheckStats()
+	prog := newProgress(out, len(domains))
+	stats := newCheckStats(out)
 
 	// Job represents a domain to check with its index
 	type job struct {
@@ -88,7 +341,9 @@ func checkDomainsParallel(domains []string, whoisServer string, verbose bool, wo
 		domain string
 	}
 
-	jobs := make(chan job, len(domains))
+	// Unbuffered, so a domain counts as dispatched only once a worker has
+	// taken it.
+	jobs := make(chan job)
 	var wg sync.WaitGroup
 
 	// Start workers
@@ -97,42 +352,197 @@ func checkDomainsParallel(domains []string, whoisServer string, verbose bool, wo
 		go func() {
 			defer wg.Done()
 			for j := range jobs {
-				avail, reason, logData, err := CheckDomainAvailability(j.domain, whoisServer)
-				if err != nil {
-					avail = false
-					reason = ReasonError
-					logData = fmt.Sprintf("Error: %v", err)
-				}
-
-				prog.IncrementAndPrint(j.domain, avail, reason)
-				stats.Record(avail, reason)
-
-				log := ""
-				if shouldIncludeLog(verbose, reason) {
-					log = logData
-				}
-
-				results[j.index] = checkResult{
-					Domain: j.domain,
-					Avail:  avail,
-					Reason: reason,
-					Log:    log,
+				res := checkOne(j.domain, cfg)
+				limits.goal.record(res)
+				prog.IncrementAndPrint(j.domain, res.Avail, res.Reason)
+				stats.Record(res.Avail, res.Reason)
+				if onResult != nil {
+					onResult(res)
 				}
+				results[j.index] = res
 			}
 		}()
 	}
 
 	// Send jobs
 	for i, domain := range domains {
+		if limits.stopped(clk.Now()) {
+			for k := i; k < len(domains); k++ {
+				results[k] = uncheckedResult(domains[k])
+			}
+			break
+		}
 		jobs <- job{index: i, domain: domain}
 	}
 	close(jobs)
 
 	wg.Wait()
+	prog.Finish()
 	stats.PrintSummary()
 	return results
 }
 
+// runConfig carries the settings shared by the array and grouped check paths.
+// RunCLI builds one from its flags; the exported RunCLI* helpers build one from
+// their positional arguments.
+type runConfig struct {
+	whoisServer   string
+	sleep         time.Duration
+	verbose       bool
+	groupedOutput bool
+	outputFile    string
+	workers       int
+
+	// postURL, when set, receives the results after the run (see postResults).
+	postURL    string
+	postFormat string
+
+	// dnsPrecheck filters out delegated domains with a parallel DNS pass
+	// before WHOIS; resolver overrides net.DefaultResolver in tests.
+	dnsPrecheck    bool
+	dnsConcurrency int
+	resolver       nsResolver
+
+	// retryErrors keeps ERROR results in unverified instead of filing them
+	// as unavailable, so the next run retries them.
+	retryErrors bool
+
+	// maxAttempts, when positive, stops retrying a domain whose check has
+	// failed that many times (see giveUp).
+	maxAttempts int
+
+	// skipKnown drops domains already in the grouped file's available or
+	// unavailable bucket before checking (see skipKnown).
+	skipKnown bool
+
+	// recheckDue moves the checked domains of a grouped file that are due
+	// by their schedule back to unverified (see requeueDue).
+	recheckDue bool
+
+	// shard, when active, checks only the domains of one --shard and holds
+	// the rest back like curation does.
+	shard shardSpec
+
+	// whoisCache is shared by every lookup of the run, so duplicates are
+	// looked up once (see whoisCache).
+	whoisCache *whoisCache
+
+	// curation holds back records by their favorite and rejected marks
+	// (see curationFilter); held records are written back unchanged.
+	curation curationFilter
+
+	// maxLogBytes caps each stored WHOIS log (see truncateLog); 0 is no limit.
+	maxLogBytes int
+
+	// logSpill, when set, moves the logs past --log-budget to files (see
+	// logSpill).
+	logSpill *logSpill
+
+	// sleepOverrides replaces sleep for specific servers or TLDs, and
+	// sleepJitter randomizes the result (see sleepFor).
+	sleepOverrides sleepOverrides
+	sleepJitter    time.Duration
+
+	// queryFormats overrides the query line sent to specific servers or
+	// TLDs (see parseQueryFormats).
+	queryFormats queryFormats
+
+	// failOnError makes the run exit non-zero if any check ended in ERROR,
+	// after the results have been written.
+	failOnError bool
+
+	// printAvailable writes the bare names of available domains to stdout
+	// once the results are saved (--print=available).
+	printAvailable bool
+
+	// alternatives, when positive, is how many available alternatives to
+	// find for each taken domain, trying alternativeTLDs and, if set,
+	// synonyms (see findAlternatives).
+	alternatives    int
+	alternativeTLDs []string
+	synonyms        synonymSource
+
+	// valuationURL, when set, is the valuation API queried for available
+	// and dropping domains, with valuationAuth as its Authorization header.
+	valuationURL  string
+	valuationAuth string
+
+	// pricingURL, when set, is the pricing API queried for available
+	// domains; premiumOver is the premium threshold (see quoteDomain).
+	pricingURL  string
+	pricingAuth string
+	premiumOver float64
+
+	// parkedProbeURL, when set, is the page fetched for taken domains to
+	// tag parking and for-sale pages (see addParkedProbes).
+	parkedProbeURL string
+
+	// httpProbeURL, when set, is the address requested for taken domains
+	// to record whether their site is live (see addHTTPProbes).
+	httpProbeURL string
+
+	// portfolio, when set, marks taken domains that are ours (see
+	// markOwned).
+	portfolio *portfolio
+
+	// runID is stamped on every result and sent with --post-results (see
+	// runid.go).
+	runID string
+
+	// splitDir, when set, receives the buckets of the grouped file the run
+	// wrote as separate files (see splitGroupedFile).
+	splitDir string
+
+	// upload, when set, receives the file the run wrote (see uploadFile),
+	// with uploadAuth or uploadCreds as credentials.
+	upload      uploadTarget
+	uploadAuth  string
+	uploadCreds s3Credentials
+
+	// notifiers are told about the finished run, along with those added by
+	// RegisterNotifier (see notifyRun).
+	notifiers []Notifier
+
+	// journal, when set, records each WHOIS result as it completes so an
+	// interrupted run can resume (see journal.go). noJournal (--no-journal)
+	// keeps startJournal from opening one.
+	journal   *journal
+	noJournal bool
+
+	// clock stamps results and paces sequential checks; nil means the
+	// system clock (see timeSource).
+	clock clock
+
+	// limits bounds each lookup and the WHOIS phase as a whole
+	// (--per-domain-timeout, --run-timeout, and --stop-on-available).
+	limits checkLimits
+
+	// dialer sends WHOIS connections from the --source-ip addresses; nil
+	// lets the operating system choose.
+	dialer *whoisDialer
+
+	// order is the order domains are checked in: orderFile or
+	// orderPriority (--order). Results are written in the file's order
+	// either way.
+	order string
+
+	// readOnly runs the checks without modifying any file: the document
+	// that would have been written is printed instead (--read-only).
+	readOnly bool
+
+	// statusOut receives progress and summary output; nil means stdout.
+	// Modes that print results on stdout point it at stderr.
+	statusOut *os.File
+}
+
+// status returns where progress output goes.
+func (cfg runConfig) status() *os.File {
+	if cfg.statusOut != nil {
+		return cfg.statusOut
+	}
+	return os.Stdout
+}
+
 // RunCLIDomainArray handles the original array input logic (non-grouped or grouped output).
 func RunCLIDomainArray(
 	whoisServer, inputPath string,
@@ -142,69 +552,156 @@ func RunCLIDomainArray(
 	outputFile string,
 	workers int,
 ) int {
-	// Extract domain names for checking
-	domainNames := make([]string, len(domains))
-	for i := range domains {
-		domainNames[i] = domains[i].Domain
+	cfg := runConfig{
+		whoisServer:   whoisServer,
+		sleep:         sleep,
+		verbose:       verbose,
+		groupedOutput: groupedOutput,
+		outputFile:    outputFile,
+		workers:       workers,
+		runID:         newRunID(time.Now()),
 	}
+	return runDomainArray(cfg, inputPath, domains)
+}
 
-	results := checkDomains(domainNames, whoisServer, sleep, verbose, workers)
+// runDomainArray is the implementation behind RunCLIDomainArray.
+func runDomainArray(cfg runConfig, inputPath string, domains []DomainRecord) int {
+	if cfg.splitDir != "" && !cfg.groupedOutput {
+		return fail(cliError{Code: errCodeUsage}, "Error: --split-output with a domain list requires --grouped-output")
+	}
+	if cfg.recheckDue {
+		return fail(cliError{Code: errCodeUsage, Path: inputPath}, "Error: --recheck-due requires a grouped file")
+	}
+	if cfg.skipKnown {
+		// Without --output-file the grouped file is the list itself, in
+		// which every domain counts as known.
+		if !cfg.groupedOutput || cfg.outputFile == "" {
+			return fail(cliError{Code: errCodeUsage}, "Error: --skip-known with a domain list requires --grouped-output and --output-file")
+		}
+		existing, err := readGroupedFile(cfg.outputFile)
+		if err != nil {
+			return fail(cliError{Code: errCodeInputRead, Path: cfg.outputFile}, "Error reading %s: %v", cfg.outputFile, err)
+		}
+		domains = skipKnown(cfg, domains, knownDomains(existing), cfg.outputFile)
+	}
+	// all keeps the held-back records for the rewritten list. Grouped
+	// output over the list merges into the converted original, where the
+	// held records are unverified (see ConvertArrayToGrouped).
+	all := domains
+	var pos []int
+	holdsBack := cfg.holdsBack(domains)
+	if holdsBack {
+		domains, _, pos = splitHeld(cfg, domains)
+	}
+
+	// Both modes write to the output file if set, else back to the input.
+	journalTarget := inputPath
+	if cfg.outputFile != "" {
+		journalTarget = cfg.outputFile
+	}
+	startJournal(&cfg, journalTarget)
+	defer cfg.journal.close()
 
-	if !groupedOutput {
+	results := checkRecords(domains, cfg)
+	for i := range results {
+		results[i].Attempts += domains[i].Attempts
+	}
+	trackChanges(cfg, domains, results)
+
+	// doc and written are the document this run wrote and the file it went
+	// to, for --post-results and --upload. In grouped mode the run's results
+	// are merged into the file, so doc is the merged document.
+	var doc any
+	var written string
+	if !cfg.groupedOutput {
 		// =========== Non-Grouped Mode ===========
 		for i, res := range results {
-			domains[i].Available = res.Avail
-			domains[i].Reason = res.Reason
-			domains[i].Log = res.Log
+			if !res.checked() {
+				continue
+			}
+			rec := res.record()
+			rec.keepInputFields(domains[i])
+			domains[i] = rec
+		}
+		if holdsBack {
+			for i, p := range pos {
+				all[p] = domains[i]
+			}
+			domains = all
 		}
 
-		out, err := json.MarshalIndent(domains, "", "  ")
+		// With --output-file the input list is left untouched.
+		target := inputPath
+		if cfg.outputFile != "" {
+			target = cfg.outputFile
+		}
+		var out []byte
+		var err error
+		if isJSONLines(target) {
+			out, err = marshalJSONLines(domains)
+		} else {
+			out, err = json.MarshalIndent(domains, "", "  ")
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
-			return 1
+			return fail(cliError{Code: errCodeOutputWrite, Path: target}, "Error marshaling JSON: %v", err)
 		}
-		if err := os.WriteFile(inputPath, out, 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
-			return 1
+		doc = domains
+		if cfg.readOnly {
+			emitReadOnly(cfg, target, out)
+		} else {
+			if err := auditWrite("check", cfg.runID, target, func() error { return writeFileAtomic(target, out, 0644) }); err != nil {
+				return fail(cliError{Code: errCodeOutputWrite, Path: target}, "Error writing file: %v", err)
+			}
+			fmt.Fprintln(cfg.status(), "Processing complete. Updated file:", target)
+			written = target
 		}
-		fmt.Println("Processing complete. Updated file:", inputPath)
 	} else {
 		// =========== Grouped Mode ===========
+		giveUp(results, cfg.maxAttempts)
 		groupedData := GroupedData{}
-		for _, res := range results {
-			gd := GroupedDomain{
-				Domain: res.Domain,
-				Reason: res.Reason,
-				Log:    res.Log,
-			}
-			if res.Avail {
-				groupedData.Available = append(groupedData.Available, gd)
-			} else {
-				groupedData.Unavailable = append(groupedData.Unavailable, gd)
-			}
+		for i, res := range results {
+			addGroupedResult(&groupedData, res, domains[i], cfg.retryErrors)
 		}
 
-		if outputFile == "" {
-			mergedOut, err := json.MarshalIndent(groupedData, "", "  ")
+		if cfg.readOnly {
+			target := cmp.Or(cfg.outputFile, inputPath)
+			existing, err := readGroupedFile(target)
+			if err != nil {
+				return fail(cliError{Code: errCodeInputRead, Path: target}, "Error reading %s: %v", target, err)
+			}
+			merged := mergeGrouped(existing, groupedData)
+			out, err := json.MarshalIndent(merged, "", "  ")
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error marshaling grouped JSON: %v\n", err)
-				return 1
+				return fail(cliError{Code: errCodeOutputWrite, Path: target}, "Error marshaling JSON: %v", err)
 			}
-			if err := os.WriteFile(inputPath, mergedOut, 0644); err != nil {
-				fmt.Fprintf(os.Stderr, "Error writing grouped JSON to %s: %v\n", inputPath, err)
-				return 1
+			emitReadOnly(cfg, target, out)
+			doc = merged
+		} else if cfg.outputFile == "" {
+			// Merge into whatever the input already holds so earlier results
+			// for domains not in this run are kept.
+			if err := auditWrite("check", cfg.runID, inputPath, func() error { return WriteGroupedFile(inputPath, groupedData) }); err != nil {
+				return fail(cliError{Code: errCodeOutputWrite, Path: inputPath}, "Error writing grouped JSON to %s: %v", inputPath, err)
 			}
-			fmt.Println("Processing complete in grouped-output mode (overwrote input).")
+			fmt.Fprintln(cfg.status(), "Processing complete in grouped-output mode (overwrote input).")
+			written = inputPath
 		} else {
-			if err := WriteGroupedFile(outputFile, groupedData); err != nil {
-				fmt.Fprintf(os.Stderr, "Error writing grouped file: %v\n", err)
-				return 1
+			if err := auditWrite("check", cfg.runID, cfg.outputFile, func() error { return WriteGroupedFile(cfg.outputFile, groupedData) }); err != nil {
+				return fail(cliError{Code: errCodeOutputWrite, Path: cfg.outputFile}, "Error writing grouped file: %v", err)
 			}
-			fmt.Println("Processing complete in grouped-output mode (wrote to separate file).")
+			fmt.Fprintln(cfg.status(), "Processing complete in grouped-output mode (wrote to separate file).")
+			written = cfg.outputFile
+		}
+		if written != "" {
+			merged, err := readGroupedFile(written)
+			if err != nil {
+				return fail(cliError{Code: errCodeInputRead, Path: written}, "Error reading %s: %v", written, err)
+			}
+			doc = merged
 		}
 	}
 
-	return 0
+	finishJournal(cfg)
+	return finishRun(cfg, written, doc, checkedResults(results))
 }
 
 // RunCLIGroupedInput handles input that's already in the grouped JSON format with unverified domains
@@ -216,92 +713,370 @@ func RunCLIGroupedInput(
 	outputFile string,
 	workers int,
 ) int {
-	finalOutputFile := outputFile
-	if !groupedOutput || outputFile == "" {
+	cfg := runConfig{
+		whoisServer:   whoisServer,
+		sleep:         sleep,
+		verbose:       verbose,
+		groupedOutput: groupedOutput,
+		outputFile:    outputFile,
+		workers:       workers,
+		runID:         newRunID(time.Now()),
+	}
+	return runGroupedInput(cfg, inputPath, ext)
+}
+
+// runGroupedInput is the implementation behind RunCLIGroupedInput.
+func runGroupedInput(cfg runConfig, inputPath string, ext ExtendedGroupedData) int {
+	finalOutputFile := cfg.outputFile
+	if finalOutputFile == "" {
 		finalOutputFile = inputPath
 	}
 
+	if err := applyPendingLog(inputPath, &ext); err != nil {
+		return fail(cliError{Code: errCodeInputRead, Path: inputPath}, "Error reading pending results for %s: %v", inputPath, err)
+	}
 	if ext.Available == nil {
 		ext.Available = []GroupedDomain{}
 	}
 	if ext.Unavailable == nil {
 		ext.Unavailable = []GroupedDomain{}
 	}
-
-	// Extract domain names for checking
-	domainNames := make([]string, len(ext.Unverified))
-	for i := range ext.Unverified {
-		domainNames[i] = ext.Unverified[i].Domain
+	if cfg.recheckDue {
+		requeueDue(cfg, &ext, cfg.timeSource().Now())
+	}
+	if cfg.skipKnown {
+		ext.Unverified = skipKnown(cfg, ext.Unverified, knownDomains(GroupedData(ext)), inputPath)
+	}
+	var held []DomainRecord
+	if cfg.holdsBack(ext.Unverified) {
+		ext.Unverified, held, _ = splitHeld(cfg, ext.Unverified)
 	}
 
-	results := checkDomains(domainNames, whoisServer, sleep, verbose, workers)
+	startJournal(&cfg, finalOutputFile)
+	defer cfg.journal.close()
 
-	for _, res := range results {
-		gd := GroupedDomain{
-			Domain: res.Domain,
-			Reason: res.Reason,
-			Log:    res.Log,
-		}
-		if res.Avail {
-			ext.Available = append(ext.Available, gd)
-		} else {
-			ext.Unavailable = append(ext.Unavailable, gd)
-		}
+	results := checkRecords(ext.Unverified, cfg)
+	for i := range results {
+		results[i].Attempts += ext.Unverified[i].Attempts
 	}
+	trackChanges(cfg, ext.Unverified, results)
+	giveUp(results, cfg.maxAttempts)
 
+	checked := ext.Unverified
 	ext.Unverified = nil
+	for i, res := range results {
+		addGroupedResult((*GroupedData)(&ext), res, checked[i], cfg.retryErrors)
+	}
+	ext.Unverified = append(ext.Unverified, held...)
 
 	out, err := json.MarshalIndent(ext, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling grouped JSON: %v\n", err)
-		return 1
+		return fail(cliError{Code: errCodeOutputWrite, Path: finalOutputFile}, "Error marshaling grouped JSON: %v", err)
 	}
-	if err := os.WriteFile(finalOutputFile, out, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing grouped JSON to %s: %v\n", finalOutputFile, err)
-		return 1
+	if cfg.readOnly {
+		emitReadOnly(cfg, finalOutputFile, out)
+		return finishRun(cfg, "", ext, checkedResults(results))
+	}
+	err = auditWrite("check", cfg.runID, finalOutputFile, func() error {
+		if err := writeFileAtomic(finalOutputFile, out, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(finalOutputFile)
+	})
+	if err != nil {
+		return fail(cliError{Code: errCodeOutputWrite, Path: finalOutputFile}, "Error writing grouped JSON to %s: %v", finalOutputFile, err)
 	}
 
 	if finalOutputFile == inputPath {
-		fmt.Println("Processed grouped input (with unverified) and overwrote original file.")
+		fmt.Fprintln(cfg.status(), "Processed grouped input (with unverified) and overwrote original file.")
 	} else {
-		fmt.Println("Processed grouped input (with unverified) and wrote results to:", finalOutputFile)
+		fmt.Fprintln(cfg.status(), "Processed grouped input (with unverified) and wrote results to:", finalOutputFile)
+	}
+
+	finishJournal(cfg)
+	return finishRun(cfg, finalOutputFile, ext, checkedResults(results))
+}
+
+// finishRun does the work that follows writing a run's results to path:
+// splitting the grouped file for --split-output, uploading the file (see
+// uploadRunResults), posting the results (see postRunResults), printing the
+// available domains for --print=available, and, with cfg.failOnError,
+// failing the run if any check errored. It returns the process exit code.
+func finishRun(cfg runConfig, path string, doc any, results []checkResult) int {
+	if code := splitRunResults(cfg, path); code != 0 {
+		return code
+	}
+	if code := uploadRunResults(cfg, path); code != 0 {
+		return code
+	}
+	if code := postRunResults(cfg, doc, results); code != 0 {
+		return code
+	}
+	if code := notifyRun(cfg, path, results); code != 0 {
+		return code
+	}
+	if cfg.printAvailable {
+		for _, res := range results {
+			if res.Avail {
+				fmt.Println(res.Domain)
+			}
+		}
+	}
+	if !cfg.failOnError {
+		return 0
 	}
+	errored := 0
+	for _, res := range results {
+		if res.Reason == ReasonError || res.Reason == ReasonGivenUp {
+			errored++
+		}
+	}
+	if errored > 0 {
+		// Nothing got through: more likely the server or network than the
+		// domains.
+		code := errCodeChecksFailed
+		if errored == len(results) {
+			code = errCodeWhoisUnreachable
+		}
+		return fail(cliError{Code: code}, "Error: %d of %d checks failed (--fail-on-error)", errored, len(results))
+	}
+	return 0
+}
 
+// postRunResults sends the run's results to cfg.postURL if one is configured.
+// It returns the process exit code.
+func postRunResults(cfg runConfig, doc any, results []checkResult) int {
+	if cfg.postURL == "" {
+		return 0
+	}
+	if err := postResults(http.DefaultClient, cfg.postURL, cfg.postFormat, cfg.runID, doc, resultRecords(results)); err != nil {
+		return fail(cliError{Code: errCodeDelivery, Path: cfg.postURL}, "Error posting results: %v", err)
+	}
+	fmt.Fprintln(cfg.status(), "Posted results to", cfg.postURL)
 	return 0
 }
 
+// parseWorkers turns a --lightspeed value into a worker count: "" is
+// sequential (0), "max" is one worker per domain (-1), and a number is that
+// many workers. It falls back to the TALIA_LIGHTSPEED env var.
+func parseWorkers(ls string) int {
+	if ls == "" {
+		ls = os.Getenv("TALIA_LIGHTSPEED")
+	}
+	if ls == "" {
+		return 0
+	}
+	if ls == "max" {
+		return -1 // sentinel for "use domain count"
+	}
+	n, err := strconv.Atoi(ls)
+	if err != nil || n < 1 {
+		// invalid value defaults to 10
+		return 10
+	}
+	return n
+}
+
+// openAIBaseURL returns the API base URL: the --api-base value, else
+// OPENAI_API_BASE, else the OpenAI default.
+func openAIBaseURL(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("OPENAI_API_BASE"); env != "" {
+		return env
+	}
+	return defaultOpenAIBase
+}
+
+// openAIModel returns the model name: TALIA_MODEL replaces the --model value
+// only while it is the default.
+func openAIModel(flagValue string) string {
+	if flagValue == defaultOpenAIModel {
+		if env := os.Getenv("TALIA_MODEL"); env != "" {
+			return env
+		}
+	}
+	return flagValue
+}
+
+// printModeAvailable is the only --print value: bare available domain names.
+const printModeAvailable = "available"
+
 // skipEnvFile is a test hook to skip loading .env files during tests.
 var skipEnvFile bool
 
 // RunCLI is the main entry point for Talia logic.
 func RunCLI(args []string) int {
-	// Load .env file from current directory (silently ignore if not found)
+	auditArgs = args
+	args, restore := applyGlobalFlags(args)
+	defer restore()
+	errorReported.Store(false)
+	code := runCLI(args)
+	if code != 0 && !errorReported.Load() {
+		emitError(cliError{Code: errCodeGeneric, Message: fmt.Sprintf("exit status %d; see the messages above", code)})
+	}
+	return code
+}
+
+// runCLI is the implementation behind RunCLI.
+func runCLI(args []string) int {
+
+	// Load .env file from current directory, then the config file (silently
+	// ignore if not found). Neither overrides variables already set.
+	shell := environKeys()
 	if !skipEnvFile {
 		_ = LoadEnvFile(".env")
+		if dir, err := configDir(); err == nil {
+			_ = LoadEnvFile(filepath.Join(dir, configFileName))
+		}
+	}
+	if err := loadProfile(shell); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+
+	if code, ok := runSubcommand(args); ok {
+		return code
 	}
 
 	fs := flag.NewFlagSet("talia", flag.ContinueOnError)
-	whoisServer := fs.String("whois", "", "WHOIS server, e.g. whois.verisign-grs.com:43 (env: WHOIS_SERVER)")
+	whoisServer := fs.String("whois", "", "WHOIS server, e.g. whois.verisign-grs.com:43 (env: WHOIS_SERVER); default: by TLD")
 	sleep := fs.Duration("sleep", 2*time.Second, "Time to sleep between domain checks (default 2s)")
+	sleepJitter := fs.Duration("sleep-jitter", 0, "Randomize each sleep by up to ± this amount, e.g. 500ms")
+	sleepPerServer := fs.String("sleep-per-server", "", "Per-server or per-TLD sleep overrides, e.g. 'whois.nic.io:43=5s,.io=5s'")
+	queryFormat := fs.String("query-format", "", "WHOIS query template with %s for the domain, e.g. 'domain %s', optionally per server or TLD: 'whois.denic.de:43=-T dn %s,.jp=%s/e'")
 	verbose := fs.Bool("verbose", false, "Include WHOIS log in 'log' field even for successful checks")
 	groupedOutput := fs.Bool("grouped-output", false, "Enable grouped output (JSON object with 'available','unavailable')")
-	outputFile := fs.String("output-file", "", "Path to grouped output file (if set, input file remains unmodified)")
+	outputFile := fs.String("output-file", "", "Write results to this file instead of the input file (grouped results are merged into it)")
 	suggest := fs.Int("suggest", 0, "Number of domain suggestions to generate (env: TALIA_SUGGEST)")
 	suggestParallel := fs.Int("suggest-parallel", 1, "Number of parallel suggestion requests to run (env: TALIA_SUGGEST_PARALLEL)")
+	suggestRetries := fs.Int("suggest-retries", 3, "Follow-up requests allowed when fewer valid new suggestions than requested come back (0 = none)")
 	prompt := fs.String("prompt", "", "Optional prompt to influence domain suggestions (env: TALIA_PROMPT)")
+	style := fs.String("style", "", "Naming style preset for suggestions: "+strings.Join(styleNames(), ", ")+" (env: TALIA_STYLE)")
+	suggestLanguage := fs.String("suggest-language", "", "Ask for names in this language or market, e.g. es or pt-BR; IDN names are converted to punycode (env: TALIA_SUGGEST_LANGUAGE)")
 	model := fs.String("model", defaultOpenAIModel, "OpenAI model to use for suggestions (env: TALIA_MODEL)")
 	apiBase := fs.String("api-base", "", "Base URL for OpenAI-compatible API (env: OPENAI_API_BASE)")
 	fresh := fs.Bool("fresh", false, "Don't pass existing domains to AI (allows duplicates, starts fresh)")
 	clean := fs.Bool("clean", false, "Clean and normalize domains in the file (removes invalid domains)")
+	dedupe := fs.Bool("dedupe", false, "Remove duplicate domains from the file in place, keeping the first occurrence")
+	logBudget := fs.Int("log-budget", 0, "Hold at most this many bytes of WHOIS logs in memory; later logs are written to --log-dir and referenced by 'logFile' (0 = no limit)")
+	logDir := fs.String("log-dir", "", "Directory for logs past --log-budget (default: the results file without its extension, plus .logs)")
+	maxLogBytes := fs.Int("max-log-bytes", 0, "Truncate stored WHOIS logs to this many bytes, keeping head and tail (0 = no limit)")
+	stripLogs := fs.Bool("strip-logs", false, "Remove the 'log' field from every record in the file, then exit")
+	migrateCheckedAt := fs.Bool("migrate-checked-at", false, "Stamp checked records written by older versions with 'checkedAt' (the file's modification time, or --checked-at), then exit")
+	checkedAt := fs.String("checked-at", "", "With --migrate-checked-at, the check time to stamp: a date (2024-05-01) or an RFC 3339 time")
+	migrateStatus := fs.Bool("migrate-status", false, "Add the 'status' field to checked records written by older versions, then exit")
 	noVerify := fs.Bool("no-verify", false, "Skip WHOIS verification after generating suggestions")
 	merge := fs.Bool("merge", false, "Merge multiple domain files")
 	output := fs.String("o", "", "Output file for merge (if not set, merges into first file)")
 	exportAvailable := fs.String("export-available", "", "Export available domains to a text file")
+	maxAge := fs.Duration("max-age", 0, "With --export-available, refuse to export if any available domain was checked longer ago than this, e.g. 1h (0 = no limit)")
+	recheckStaleFlag := fs.Bool("recheck-stale", false, "With --max-age, re-check the too-old available domains (updating the file) instead of refusing")
 	lightspeed := fs.String("lightspeed", "", "Parallel workers: number or 'max' (env: TALIA_LIGHTSPEED)")
+	postURL := fs.String("post-results", "", "POST the final results to this HTTP(S) URL after the run")
+	postFormat := fs.String("post-format", postFormatJSON, "Body format for --post-results: 'json' (final document) or 'ndjson' (one record per line)")
+	dnsPrecheckFlag := fs.Bool("dns-precheck", false, "Resolve NS records first and skip WHOIS for domains that are clearly registered")
+	dnsConcurrency := fs.Int("dns-concurrency", defaultDNSConcurrency, "Number of concurrent DNS lookups during --dns-precheck")
+	pipeline := fs.Bool("pipeline", false, "With --suggest and --whois, check suggestions while later requests are still generating")
+	failOnError := fs.Bool("fail-on-error", false, "Exit with status 1 if any check ended in ERROR (results are still written)")
+	printFlag := fs.String("print", "", "Print results to stdout for piping; 'available' prints bare available domain names (other output goes to stderr)")
+	retryErrors := fs.Bool("retry-errors", false, "In grouped mode, keep domains whose check errored in 'unverified' so the next run retries them")
+	curation := addCurationFlags(fs, "check")
+	shardFlag := fs.String("shard", "", "Check only shard K of N, e.g. 3/8: the domains whose hash mod N is K-1, so N machines can split one file (combine the grouped outputs with talia merge)")
+	recheckDueFlag := fs.Bool("recheck-due", false, "For a grouped file, also re-check the available and unavailable domains that are due: errors hourly, available and soon-expiring domains daily, other taken domains monthly")
+	skipKnownFlag := fs.Bool("skip-known", false, "Only check domains not already in the grouped file's available or unavailable bucket (the --output-file for a domain list)")
+	maxAttempts := fs.Int("max-attempts", 0, "With --retry-errors, mark a domain GIVEN_UP instead of retrying it once this many checks have failed (0 = no limit)")
+	inputAuth := fs.String("input-auth", "", "Authorization header sent when the input is an HTTP(S) URL, e.g. 'Bearer <token>' (env: TALIA_INPUT_AUTH)")
+	order := fs.String("order", orderFile, "Order to check domains in: 'file', or 'priority' (highest 'priority' field first)")
+	var stopOnAvailable stopOnAvailableFlag
+	fs.Var(&stopOnAvailable, "stop-on-available", "Stop starting new checks once this many available domains are found (bare flag: 1) and write the results so far; unchecked domains stay as they were")
+	noJournal := fs.Bool("no-journal", false, "Don't journal completed checks to <output>.journal, so an interrupted run can't be resumed")
+	readOnly := fs.Bool("read-only", false, "Check and print the resulting document on stdout without modifying any file (the WHOIS cache is read but not written)")
+	bench := fs.Bool("bench", false, "Check the file's domains against the responses stored by --whois-cache, without queries, sleeps, or writes, and print the throughput")
+	pprofKind := fs.String("pprof", "", "Write a Go profile of the run: 'cpu', 'mem', or 'trace'")
+	pprofFile := fs.String("pprof-file", "", "File for --pprof (default talia-cpu.pprof, talia-mem.pprof, or talia.trace)")
+	perDomainTimeout := fs.Duration("per-domain-timeout", 0, "Give up on a domain's WHOIS lookup after this long, connecting included, and record an error (0 = no limit)")
+	runTimeout := fs.Duration("run-timeout", 0, "Stop starting new checks this long after the run began and write the results so far; unchecked domains stay as they were (0 = no limit)")
+	sourceIP := fs.String("source-ip", "", "Send WHOIS queries from this local address, or cycle through a comma-separated list of them (default: chosen by the system)")
+	ipVersion := fs.String("ip-version", "auto", "IP version for WHOIS connections: '4', '6', or 'auto' (either, IPv6 first with a quick IPv4 fallback)")
+	whoisCacheTTL := fs.Duration("whois-cache", 0, "Reuse WHOIS responses younger than this, e.g. 24h, kept in the cache directory (0 = no cache)")
+	portfolioFile := fs.String("portfolio", "", "File of domains we own (plus 'registrant:' and 'nameserver:' patterns); taken domains that match get ownedByUs (env: TALIA_PORTFOLIO)")
+	splitOutput := fs.String("split-output", "", "After a grouped run, also write each bucket to <dir>/available.json, unavailable.json, and unverified.json, with an index.json")
+	upload := fs.String("upload", "", "After the run, PUT the output file to s3://bucket/key (AWS_* env credentials) or an HTTP(S) URL (env: TALIA_UPLOAD_AUTH)")
+	notify := fs.String("notify", "", "Comma-separated targets told about each finished run: http(s) webhook URLs, Slack incoming webhook URLs, or mailto:address (env: TALIA_SMTP_*)")
+	notifyTemplate := fs.String("notify-template", "", "File holding a Go template over the run event that replaces the JSON body posted to --notify webhooks")
+	// Handled by applyGlobalFlags before parsing; registered for -h.
+	_ = fs.String("cache-dir", "", "Cache directory (env: TALIA_CACHE_DIR); default: $XDG_CACHE_HOME/talia or the user cache directory")
+	_ = fs.String("error-format", "", "'json' adds a JSON error object (code, message, path, hint) to error output on stderr (env: TALIA_ERROR_FORMAT)")
+	_ = fs.String("profile", "", "Named profile from config.env whose settings are used as flag defaults (env: TALIA_PROFILE)")
+	_ = fs.String("config-dir", "", "Config directory holding config.env (env: TALIA_CONFIG_DIR); default: $XDG_CONFIG_HOME/talia or the user config directory")
+	runIDFlag := fs.String("run-id", "", "ID stamped on results and webhook posts to correlate runs (env: TALIA_RUN_ID); default: generated")
+	alternatives := addAlternativesFlags(fs)
+	valuation := addValuationFlags(fs)
+	pricing := addPricingFlags(fs)
+	parked := addParkedFlags(fs)
+	httpProbe := addHTTPProbeFlags(fs)
 
+	if err := applyProfile(fs); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
 	if err := fs.Parse(args); err != nil {
-		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
-		return 1
+		return fail(cliError{Code: errCodeUsage}, "Error parsing flags: %v", err)
+	}
+
+	if *pprofKind != "" {
+		stop, err := startPprof(*pprofKind, *pprofFile)
+		if err != nil {
+			return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+		}
+		defer func() {
+			if err := stop(); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning: writing profile:", err)
+			}
+		}()
+	} else if *pprofFile != "" {
+		return fail(cliError{Code: errCodeUsage}, "Error: --pprof-file requires --pprof")
+	}
+
+	overrides, err := parseSleepOverrides(*sleepPerServer)
+	if err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	queries, err := parseQueryFormats(*queryFormat)
+	if err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+
+	if *readOnly {
+		if name := readOnlyConflict(fs); name != "" {
+			return fail(cliError{Code: errCodeUsage}, "Error: --read-only can't be combined with --%s, which writes files", name)
+		}
+	}
+	if *maxAttempts < 0 || (*maxAttempts > 0 && !*retryErrors) {
+		return fail(cliError{Code: errCodeUsage}, "Error: --max-attempts must be positive and requires --retry-errors")
+	}
+	if *maxAge < 0 || (*maxAge > 0 && *exportAvailable == "") {
+		return fail(cliError{Code: errCodeUsage}, "Error: --max-age must be positive and requires --export-available")
+	}
+	if *recheckStaleFlag && *maxAge == 0 {
+		return fail(cliError{Code: errCodeUsage}, "Error: --recheck-stale requires --max-age")
+	}
+	if *checkedAt != "" && !*migrateCheckedAt {
+		return fail(cliError{Code: errCodeUsage}, "Error: --checked-at requires --migrate-checked-at")
+	}
+	if _, err := styledPrompt(*style, ""); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if _, err := languagePrompt(*suggestLanguage, ""); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+
+	if *printFlag != "" && *printFlag != printModeAvailable {
+		return fail(cliError{Code: errCodeUsage}, "Error: --print must be %q", printModeAvailable)
+	}
+
+	if *postFormat != postFormatJSON && *postFormat != postFormatNDJSON {
+		return fail(cliError{Code: errCodeUsage}, "Error: --post-format must be %q or %q", postFormatJSON, postFormatNDJSON)
 	}
 
 	// Get target file from args or env var
@@ -312,15 +1087,13 @@ func RunCLI(args []string) int {
 		targetFile = envFile
 	}
 	if targetFile == "" {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] <json-file> (or set TALIA_FILE env var)\n", fs.Name())
-		return 1
+		return fail(cliError{Code: errCodeUsage}, "Usage: %s [options] <json-file> (or set TALIA_FILE env var)", fs.Name())
 	}
 	if *clean {
 		// Auto-detect format: try JSON first, fall back to plain text
 		raw, readErr := os.ReadFile(targetFile)
 		if readErr != nil {
-			fmt.Fprintln(os.Stderr, "Error reading file:", readErr)
-			return 1
+			return fail(cliError{Code: errCodeInputRead, Path: targetFile}, "Error reading file: %v", readErr)
 		}
 		var removed []string
 		var err error
@@ -330,8 +1103,7 @@ func RunCLI(args []string) int {
 			removed, err = cleanTextFile(targetFile)
 		}
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error cleaning file:", err)
-			return 1
+			return fail(cliError{Code: errCodeGeneric, Path: targetFile}, "Error cleaning file: %v", err)
 		}
 		if len(removed) > 0 {
 			fmt.Printf("Removed %d invalid domains:\n", len(removed))
@@ -345,16 +1117,64 @@ func RunCLI(args []string) int {
 		return 0
 	}
 
+	if *dedupe {
+		dups, err := dedupeFile(targetFile)
+		if err != nil {
+			return fail(cliError{Code: errCodeGeneric, Path: targetFile}, "Error deduplicating file: %v", err)
+		}
+		if len(dups) == 0 {
+			fmt.Println("No duplicate domains found.")
+			return 0
+		}
+		fmt.Printf("Removed duplicates of %d domains:\n", len(dups))
+		for _, d := range dups {
+			fmt.Printf("  - %s (kept %s, removed %s)\n", d.Domain, d.Locations[0], strings.Join(d.Locations[1:], ", "))
+		}
+		fmt.Println("Deduplicated", targetFile)
+		return 0
+	}
+
+	if *stripLogs {
+		n, err := stripLogsFile(targetFile)
+		if err != nil {
+			return fail(cliError{Code: errCodeGeneric, Path: targetFile}, "Error stripping logs: %v", err)
+		}
+		fmt.Printf("Removed logs from %d records in %s\n", n, targetFile)
+		return 0
+	}
+
+	if *migrateCheckedAt {
+		var at time.Time
+		if *checkedAt != "" {
+			if at, err = parseCheckedAt(*checkedAt); err != nil {
+				return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+			}
+		}
+		n, err := migrateCheckedAtFile(targetFile, at)
+		if err != nil {
+			return fail(cliError{Code: errCodeGeneric, Path: targetFile}, "Error migrating file: %v", err)
+		}
+		fmt.Printf("Added checkedAt to %d records in %s\n", n, targetFile)
+		return 0
+	}
+
+	if *migrateStatus {
+		n, err := migrateStatusFile(targetFile)
+		if err != nil {
+			return fail(cliError{Code: errCodeGeneric, Path: targetFile}, "Error migrating file: %v", err)
+		}
+		fmt.Printf("Added status to %d records in %s\n", n, targetFile)
+		return 0
+	}
+
 	if *merge {
 		// In merge mode, all positional args are input files
 		inputFiles := fs.Args()
 		if len(inputFiles) < 1 {
-			fmt.Fprintln(os.Stderr, "Error: --merge requires at least one input file")
-			return 1
+			return fail(cliError{Code: errCodeUsage}, "Error: --merge requires at least one input file")
 		}
 		if len(inputFiles) < 2 && *output == "" {
-			fmt.Fprintln(os.Stderr, "Error: --merge requires at least 2 files, or use -o to specify output")
-			return 1
+			return fail(cliError{Code: errCodeUsage}, "Error: --merge requires at least 2 files, or use -o to specify output")
 		}
 
 		outputFile := *output
@@ -365,48 +1185,128 @@ func RunCLI(args []string) int {
 
 		added, err := mergeFiles(outputFile, inputFiles)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error merging files:", err)
-			return 1
+			return failMerge(err)
 		}
 		fmt.Printf("Merged %d domains into %s\n", added, outputFile)
 		return 0
 	}
 
-	if *exportAvailable != "" {
-		added, err := exportAvailableDomains(targetFile, *exportAvailable)
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Error exporting available domains:", err)
-			return 1
+	workers := parseWorkers(*lightspeed)
+
+	cfg := runConfig{
+		whoisServer:    *whoisServer,
+		sleep:          *sleep,
+		verbose:        *verbose,
+		groupedOutput:  *groupedOutput,
+		outputFile:     *outputFile,
+		workers:        workers,
+		postURL:        *postURL,
+		postFormat:     *postFormat,
+		dnsPrecheck:    *dnsPrecheckFlag,
+		dnsConcurrency: *dnsConcurrency,
+		retryErrors:    *retryErrors,
+		maxAttempts:    *maxAttempts,
+		skipKnown:      *skipKnownFlag,
+		recheckDue:     *recheckDueFlag,
+		curation:       *curation,
+		splitDir:       *splitOutput,
+		maxLogBytes:    *maxLogBytes,
+		sleepOverrides: overrides,
+		sleepJitter:    *sleepJitter,
+		queryFormats:   queries,
+		failOnError:    *failOnError,
+		printAvailable: *printFlag == printModeAvailable,
+		runID:          runID(*runIDFlag, time.Now()),
+		readOnly:       *readOnly,
+		noJournal:      *noJournal,
+	}
+	if cfg.order, err = parseOrder(*order); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if cfg.printAvailable || cfg.readOnly {
+		// Keep stdout for the domain names (or the document) alone.
+		cfg.statusOut = os.Stderr
+	}
+	if err := alternatives.apply(&cfg, openAIModel(*model), openAIBaseURL(*apiBase)); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := valuation.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := pricing.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := parked.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := httpProbe.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if *logBudget < 0 || (*logDir != "" && *logBudget == 0) {
+		return fail(cliError{Code: errCodeUsage}, "Error: --log-budget must be positive, and --log-dir requires it")
+	}
+	if *logBudget > 0 {
+		dir := *logDir
+		if dir == "" {
+			dir = defaultLogDir(cmp.Or(cfg.outputFile, targetFile))
 		}
-		fmt.Printf("Exported %d available domains to %s\n", added, *exportAvailable)
-		return 0
+		cfg.logSpill = newLogSpill(dir, *logBudget)
+	}
+	if *shardFlag != "" {
+		if cfg.shard, err = parseShard(*shardFlag); err != nil {
+			return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+		}
+	}
+	if cfg.limits, err = newCheckLimits(*perDomainTimeout, *runTimeout, time.Now()); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	cfg.limits.goal = newAvailableGoal(int(stopOnAvailable))
+	if cfg.dialer, err = newWhoisDialer(*sourceIP, *ipVersion); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := applyWhoisCache(&cfg, *whoisCacheTTL); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := applyPortfolio(&cfg, *portfolioFile); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := applyUpload(&cfg, *upload); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := applyNotify(&cfg, *notify, *notifyTemplate); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
 	}
 
-	// Parse lightspeed flag: "" = sequential, "max" = unlimited, number = worker count
-	// Falls back to TALIA_LIGHTSPEED env var
-	workers := 0
-	ls := *lightspeed
-	if ls == "" {
-		ls = os.Getenv("TALIA_LIGHTSPEED")
+	if *bench {
+		return runBench(cfg, targetFile)
 	}
-	if ls != "" {
-		if ls == "max" {
-			workers = -1 // sentinel for "use domain count"
-		} else {
-			n, err := strconv.Atoi(ls)
-			if err != nil || n < 1 {
-				// invalid value defaults to 10
-				workers = 10
-			} else {
-				workers = n
+
+	if *exportAvailable != "" {
+		if *recheckStaleFlag && *maxAge > 0 {
+			if code := recheckStale(cfg, targetFile, *maxAge, time.Now()); code != 0 {
+				return code
+			}
+		}
+		if *maxAge > 0 {
+			var stale *staleError
+			if err := checkFreshness(targetFile, *maxAge, time.Now()); errors.As(err, &stale) {
+				return fail(cliError{Code: errCodeStaleResults, Path: targetFile, Hint: "add --recheck-stale to check them again first, or re-run the file"}, "Error: %v", err)
+			} else if err != nil {
+				return fail(cliError{Code: errCodeInputRead, Path: targetFile}, "Error reading %s: %v", targetFile, err)
 			}
 		}
+		added, err := exportAvailableDomains(targetFile, *exportAvailable)
+		if err != nil {
+			return fail(cliError{Code: errCodeGeneric, Path: targetFile}, "Error exporting available domains: %v", err)
+		}
+		fmt.Printf("Exported %d available domains to %s\n", added, *exportAvailable)
+		return 0
 	}
 
 	// Determine suggest count: use flag if provided, otherwise check env var
 	// But only use env var if file has no unverified domains to check
 	suggestCount := *suggest
-	if suggestCount == 0 {
+	if suggestCount == 0 && !cfg.readOnly {
 		if envSuggest := os.Getenv("TALIA_SUGGEST"); envSuggest != "" {
 			if n, err := strconv.Atoi(envSuggest); err == nil && n > 0 {
 				// Check if file has unverified domains - if so, don't use env var
@@ -425,25 +1325,29 @@ func RunCLI(args []string) int {
 	}
 
 	if suggestCount > 0 {
-		baseURL := *apiBase
-		if baseURL == "" {
-			baseURL = os.Getenv("OPENAI_API_BASE")
-		}
-		if baseURL == "" {
-			baseURL = defaultOpenAIBase
-		}
+		baseURL := openAIBaseURL(*apiBase)
 		// Use env var if --prompt not provided
 		promptText := *prompt
 		if promptText == "" {
 			promptText = os.Getenv("TALIA_PROMPT")
 		}
-		// Use env var if --model not provided (and not default)
-		modelName := *model
-		if modelName == defaultOpenAIModel {
-			if envModel := os.Getenv("TALIA_MODEL"); envModel != "" {
-				modelName = envModel
-			}
+		styleName := *style
+		if styleName == "" {
+			styleName = os.Getenv("TALIA_STYLE")
+		}
+		promptText, err = styledPrompt(styleName, promptText)
+		if err != nil {
+			return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
 		}
+		language := *suggestLanguage
+		if language == "" {
+			language = os.Getenv("TALIA_SUGGEST_LANGUAGE")
+		}
+		promptText, err = languagePrompt(language, promptText)
+		if err != nil {
+			return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+		}
+		modelName := openAIModel(*model)
 		// Read existing domains to avoid duplicates (unless --fresh is set)
 		var existingDomains []string
 		if !*fresh {
@@ -462,9 +1366,37 @@ func RunCLI(args []string) int {
 			parallelReqs = 1
 		}
 
-		fmt.Printf("Starting %d parallel requests (each requesting %d suggestions)...\n", parallelReqs, suggestCount)
+		// Auto-verify suggestions if --whois is provided (or env var) and --no-verify is not set
+		whois := *whoisServer
+		if whois == "" {
+			whois = os.Getenv("WHOIS_SERVER")
+		}
+		verify := whois != "" && !*noVerify
+		// Use 100ms sleep for auto-verification (or lightspeed if set)
+		verifyCfg := cfg
+		verifyCfg.whoisServer = whois
+		verifyCfg.sleep = 100 * time.Millisecond
+		verifyCfg.groupedOutput = true
+		verifyCfg.outputFile = ""
+
+		// With --pipeline, suggestions are checked as each request returns.
+		var pipe *suggestPipeline
+		if *pipeline && verify {
+			startJournal(&verifyCfg, targetFile)
+			defer verifyCfg.journal.close()
+			pipe = newSuggestPipeline(verifyCfg, readExistingDomains(targetFile))
+		}
+
+		fmt.Fprintf(cfg.status(), "Starting %d parallel requests (each requesting %d suggestions)...\n", parallelReqs, suggestCount)
 
 		apiKey := os.Getenv("OPENAI_API_KEY")
+		generate := func(n int, exclude []string) ([]DomainRecord, error) {
+			list, err := GenerateDomainSuggestions(apiKey, promptText, n, modelName, baseURL, exclude)
+			if err == nil && language != "" {
+				list = idnSuggestions(list)
+			}
+			return list, err
+		}
 		var allResults []DomainRecord
 		var resultsMu sync.Mutex
 		var wg sync.WaitGroup
@@ -477,7 +1409,7 @@ func RunCLI(args []string) int {
 			wg.Add(1)
 			go func(reqNum int) {
 				defer wg.Done()
-				list, err := GenerateDomainSuggestions(apiKey, promptText, suggestCount, modelName, baseURL, existingDomains)
+				list, err := generate(suggestCount, existingDomains)
 
 				completedMu.Lock()
 				completed++
@@ -485,7 +1417,7 @@ func RunCLI(args []string) int {
 				completedMu.Unlock()
 
 				if err != nil {
-					fmt.Printf("  [%d/%d] Request %d failed: %v\n", current, parallelReqs, reqNum+1, err)
+					fmt.Fprintf(cfg.status(), "  [%d/%d] Request %d failed: %v\n", current, parallelReqs, reqNum+1, err)
 					errMu.Lock()
 					if firstErr == nil {
 						firstErr = err
@@ -493,67 +1425,124 @@ func RunCLI(args []string) int {
 					errMu.Unlock()
 					return
 				}
-				fmt.Printf("  [%d/%d] Request %d returned %d suggestions\n", current, parallelReqs, reqNum+1, len(list))
+				fmt.Fprintf(cfg.status(), "  [%d/%d] Request %d returned %d suggestions\n", current, parallelReqs, reqNum+1, len(list))
 				resultsMu.Lock()
 				allResults = append(allResults, list...)
 				resultsMu.Unlock()
+				if pipe != nil {
+					pipe.Add(list)
+				}
 			}(i)
 		}
 		wg.Wait()
 
+		// Top up when the model came back short after invalid and
+		// duplicate names are dropped.
+		if len(allResults) > 0 || firstErr == nil {
+			want := suggestCount * parallelReqs
+			extra, got := topUpSuggestions(cfg.status(), want, *suggestRetries, readExistingDomains(targetFile), allResults, existingDomains,
+				func(n int, exclude []string) ([]DomainRecord, error) {
+					list, err := generate(n, exclude)
+					if err == nil && pipe != nil {
+						pipe.Add(list)
+					}
+					return list, err
+				})
+			allResults = append(allResults, extra...)
+			if got < want {
+				fmt.Fprintf(os.Stderr, "Warning: only %d of %d requested suggestions were valid and new\n", got, want)
+			}
+		}
+
+		if pipe != nil {
+			results := pipe.Finish()
+			if firstErr != nil && len(allResults) == 0 {
+				return fail(cliError{Code: errCodeSuggest}, "Error generating suggestions: %v", firstErr)
+			}
+			if firstErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: some requests failed: %v\n", firstErr)
+			}
+			ext, err := writeCheckedSuggestions(targetFile, verifyCfg.runID, results, pipe.suggested, verifyCfg.retryErrors)
+			if err != nil {
+				return fail(cliError{Code: errCodeOutputWrite, Path: targetFile}, "Error writing suggestions file: %v", err)
+			}
+			finishJournal(verifyCfg)
+			checked := checkedResults(results)
+			fmt.Fprintf(cfg.status(), "Collected %d suggestions total, checked %d new domains, wrote to %s\n", len(allResults), len(checked), targetFile)
+			return finishRun(verifyCfg, targetFile, ext, checked)
+		}
+
 		if firstErr != nil && len(allResults) == 0 {
-			fmt.Fprintln(os.Stderr, "Error generating suggestions:", firstErr)
-			return 1
+			return fail(cliError{Code: errCodeSuggest}, "Error generating suggestions: %v", firstErr)
 		}
 		if firstErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: some requests failed: %v\n", firstErr)
 		}
 
 		if err := writeSuggestionsFile(targetFile, allResults); err != nil {
-			fmt.Fprintln(os.Stderr, "Error writing suggestions file:", err)
-			return 1
+			return fail(cliError{Code: errCodeOutputWrite, Path: targetFile}, "Error writing suggestions file: %v", err)
 		}
-		fmt.Printf("Collected %d suggestions total, wrote to %s (duplicates removed)\n", len(allResults), targetFile)
+		fmt.Fprintf(cfg.status(), "Collected %d suggestions total, wrote to %s (duplicates removed)\n", len(allResults), targetFile)
 
-		// Auto-verify suggestions if --whois is provided (or env var) and --no-verify is not set
-		whois := *whoisServer
-		if whois == "" {
-			whois = os.Getenv("WHOIS_SERVER")
-		}
-		if whois != "" && !*noVerify {
-			fmt.Println("Verifying suggestions...")
+		if verify {
+			fmt.Fprintln(cfg.status(), "Verifying suggestions...")
 			inputPath := targetFile
 			raw, err := os.ReadFile(inputPath)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", inputPath, err)
-				return 1
+				return fail(cliError{Code: errCodeInputRead, Path: inputPath}, "Error reading %s: %v", inputPath, err)
 			}
 			var ext ExtendedGroupedData
 			if err := json.Unmarshal(raw, &ext); err != nil {
-				fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", inputPath, err)
-				return 1
+				return fail(cliError{Code: errCodeInputParse, Path: inputPath}, "%s", parseDiagnostic(inputPath, raw, []parseAttempt{{Format: "grouped object", Err: err}}))
 			}
-			// Use 100ms sleep for auto-verification (or lightspeed if set)
-			verifySleep := 100 * time.Millisecond
-			return RunCLIGroupedInput(whois, inputPath, ext, verifySleep, *verbose, true, "", workers)
+			return runGroupedInput(verifyCfg, inputPath, ext)
 		}
 		return 0
 	}
 
-	// Use env var if --whois not provided
-	if *whoisServer == "" {
-		*whoisServer = os.Getenv("WHOIS_SERVER")
-	}
-	if *whoisServer == "" {
-		fmt.Fprintln(os.Stderr, "Error: --whois=<server:port> is required (or set WHOIS_SERVER env var)")
-		return 1
+	// Use env var if --whois not provided; without either, each domain is
+	// routed to its TLD's server.
+	if cfg.whoisServer == "" {
+		cfg.whoisServer = os.Getenv("WHOIS_SERVER")
 	}
 
 	inputPath := targetFile
-	raw, err := os.ReadFile(inputPath)
+	var raw []byte
+	if isURL(inputPath) {
+		// A remote list can't be updated in place; results go to --output-file.
+		if cfg.outputFile == "" {
+			return fail(cliError{Code: errCodeUsage, Path: inputPath, Hint: "pass --output-file=<path> to say where the results go"}, "Error: reading the input from a URL requires --output-file")
+		}
+		auth := *inputAuth
+		if auth == "" {
+			auth = os.Getenv("TALIA_INPUT_AUTH")
+		}
+		raw, err = fetchInput(http.DefaultClient, inputPath, auth)
+		if err == nil && !json.Valid(raw) && !isJSONLines(inputPath) {
+			domains, err := textListRecords(raw)
+			if err != nil {
+				return fail(cliError{Code: errCodeInputParse, Path: inputPath}, "Error parsing %s: %v", inputPath, err)
+			}
+			return runDomainArray(cfg, inputPath, domains)
+		}
+	} else {
+		raw, err = os.ReadFile(inputPath)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", inputPath, err)
-		return 1
+		return fail(cliError{Code: errCodeInputRead, Path: inputPath}, "Error reading %s: %v", inputPath, err)
+	}
+
+	if isJSONLines(inputPath) {
+		domains, err := parseJSONLines(raw)
+		if err != nil {
+			return fail(cliError{Code: errCodeInputParse, Path: inputPath}, "Error parsing JSON Lines in %s: %v", inputPath, err)
+		}
+		// A grouped object can't be merged into a JSON Lines file.
+		if cfg.groupedOutput && (cfg.outputFile == "" || isJSONLines(cfg.outputFile)) {
+			return fail(cliError{Code: errCodeUsage, Path: inputPath}, "Error: --grouped-output with JSON Lines input requires a .json --output-file")
+		}
+		warnDuplicates(inputPath, findArrayDuplicates(domains))
+		return runDomainArray(cfg, inputPath, domains)
 	}
 
 	// Attempt to parse input as a simple array of DomainRecord.
@@ -561,16 +1550,21 @@ func RunCLI(args []string) int {
 	err = json.Unmarshal(raw, &domains)
 	if err == nil {
 		// Plain slice of domain records
-		return RunCLIDomainArray(*whoisServer, inputPath, domains, *sleep, *verbose, *groupedOutput, *outputFile, workers)
+		warnDuplicates(inputPath, findArrayDuplicates(domains))
+		return runDomainArray(cfg, inputPath, domains)
 	}
 
 	// If that fails, try to parse as a grouped JSON that might contain unverified.
 	var ext ExtendedGroupedData
-	if err2 := json.Unmarshal(raw, &ext); err2 == nil {
-		return RunCLIGroupedInput(*whoisServer, inputPath, ext, *sleep, *verbose, *groupedOutput, *outputFile, workers)
+	err2 := json.Unmarshal(raw, &ext)
+	if err2 == nil {
+		warnDuplicates(inputPath, findGroupedDuplicates(ext))
+		return runGroupedInput(cfg, inputPath, ext)
 	}
 
 	// If both fail, then it's truly invalid JSON or an unexpected format.
-	fmt.Fprintf(os.Stderr, "Error parsing JSON in %s: %v\n", inputPath, err)
-	return 1
+	return fail(cliError{Code: errCodeInputParse, Path: inputPath, Hint: "expected a JSON array of domain records, a grouped object, or a .jsonl file"}, "%s", parseDiagnostic(inputPath, raw, []parseAttempt{
+		{Format: "array of domain records", Err: err},
+		{Format: "grouped object", Err: err2},
+	}))
 }
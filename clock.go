@@ -0,0 +1,25 @@
+package talia
+
+import "time"
+
+// clock is the time source of a check run: it stamps CheckedAt and waits
+// between sequential queries. Tests substitute a fake so they run instantly
+// and can assert on the delays requested.
+type clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// systemClock is the real clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// timeSource returns cfg.clock, or the system clock if it is unset.
+func (cfg runConfig) timeSource() clock {
+	if cfg.clock != nil {
+		return cfg.clock
+	}
+	return systemClock{}
+}
@@ -0,0 +1,55 @@
+package talia
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that never blocks: Sleep records the delay and
+// advances Now by it.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+// TestCheckDomainsSequentialUsesClock runs an hour-paced sequential check
+// instantly and asserts on the requested sleeps and the timestamps.
+func TestCheckDomainsSequentialUsesClock(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clk := &fakeClock{now: start}
+	cfg := runConfig{
+		whoisServer:    startWhoisServer(t, "No match for domain\n"),
+		sleep:          time.Hour,
+		sleepOverrides: sleepOverrides{".io": time.Minute},
+		clock:          clk,
+	}
+	var results []checkResult
+	_, _ = captureOutput(t, func() {
+		results = checkDomains([]string{"a.com", "b.io", "c.com"}, cfg)
+	})
+
+	if want := []time.Duration{time.Hour, time.Minute, time.Hour}; !slices.Equal(clk.sleeps, want) {
+		t.Errorf("sleeps = %v, want %v", clk.sleeps, want)
+	}
+	for i, want := range []time.Time{start, start.Add(time.Hour), start.Add(time.Hour + time.Minute)} {
+		if !results[i].CheckedAt.Equal(want) {
+			t.Errorf("%s checked at %v, want %v", results[i].Domain, results[i].CheckedAt, want)
+		}
+	}
+}
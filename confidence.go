@@ -0,0 +1,68 @@
+package talia
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Confidence values for each signal. They are deliberately conservative:
+// WHOIS pattern matching is heuristic, and only the phrases of
+// not-found-phrases.json are recognized.
+const (
+	// whoisStrongConfidence is used when the response clearly matches the
+	// verdict: "No match for" the domain, or a "Domain Name:" line for it.
+	whoisStrongConfidence = 0.9
+	// whoisWeakConfidence is used for a taken verdict with no domain line,
+	// e.g. an unfamiliar response format or a rate-limit notice.
+	whoisWeakConfidence = 0.6
+	// dnsTakenConfidence is used when the DNS pre-check found nameservers.
+	dnsTakenConfidence = 0.95
+	// dnsNoDelegationConfidence is the weight of "no NS records" as a sign
+	// that a domain is available; unregistered and undelegated domains look
+	// the same in DNS, so it only adds to a WHOIS verdict.
+	dnsNoDelegationConfidence = 0.5
+)
+
+// confidenceHalfLife is how long it takes DecayedConfidence to halve: the
+// older a check, the likelier the domain has changed hands since.
+const confidenceHalfLife = 30 * 24 * time.Hour
+
+// whoisConfidence scores a WHOIS verdict by how clearly the response matched.
+func whoisConfidence(domain string, reason AvailabilityReason, resp string) float64 {
+	upper := strings.ToUpper(resp)
+	switch reason {
+	case ReasonNoMatch:
+		if strings.Contains(upper, "NO MATCH FOR \""+strings.ToUpper(domain)+"\"") {
+			return agreeingConfidence(whoisStrongConfidence, whoisWeakConfidence)
+		}
+		return whoisStrongConfidence
+	case ReasonTaken:
+		if strings.Contains(upper, "DOMAIN NAME: "+strings.ToUpper(domain)) {
+			return whoisStrongConfidence
+		}
+		return whoisWeakConfidence
+	default:
+		return 0
+	}
+}
+
+// agreeingConfidence combines two independent signals that agree: the verdict
+// is wrong only if both are wrong.
+func agreeingConfidence(a, b float64) float64 {
+	return 1 - (1-a)*(1-b)
+}
+
+// DecayedConfidence returns rec's confidence adjusted for the age of the
+// check at now, halving every confidenceHalfLife. Records without a
+// timestamp return their stored confidence unchanged.
+func DecayedConfidence(rec DomainRecord, now time.Time) float64 {
+	if rec.CheckedAt.IsZero() {
+		return rec.Confidence
+	}
+	age := now.Sub(rec.CheckedAt)
+	if age <= 0 {
+		return rec.Confidence
+	}
+	return rec.Confidence * math.Pow(0.5, float64(age)/float64(confidenceHalfLife))
+}
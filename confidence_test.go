@@ -0,0 +1,57 @@
+package talia
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestWhoisConfidence checks that clearer responses score higher.
+func TestWhoisConfidence(t *testing.T) {
+	t.Parallel()
+	echoed := whoisConfidence("foo.com", ReasonNoMatch, `No match for "FOO.COM".`)
+	generic := whoisConfidence("foo.com", ReasonNoMatch, "No match for domain")
+	if echoed <= generic || generic != whoisStrongConfidence {
+		t.Errorf("no-match scores: echoed=%v generic=%v", echoed, generic)
+	}
+
+	if got := whoisConfidence("foo.com", ReasonTaken, "Domain Name: FOO.COM\n"); got != whoisStrongConfidence {
+		t.Errorf("taken with domain line = %v", got)
+	}
+	if got := whoisConfidence("foo.com", ReasonTaken, "Query rate exceeded"); got != whoisWeakConfidence {
+		t.Errorf("taken without domain line = %v", got)
+	}
+	if got := whoisConfidence("foo.com", ReasonError, ""); got != 0 {
+		t.Errorf("error confidence = %v", got)
+	}
+}
+
+// TestDecayedConfidence verifies the half-life decay and the no-timestamp case.
+func TestDecayedConfidence(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	rec := DomainRecord{Confidence: 0.8, CheckedAt: now.Add(-confidenceHalfLife)}
+	if got := DecayedConfidence(rec, now); math.Abs(got-0.4) > 1e-9 {
+		t.Errorf("after one half-life = %v, want 0.4", got)
+	}
+	rec.CheckedAt = time.Time{}
+	if got := DecayedConfidence(rec, now); got != 0.8 {
+		t.Errorf("without timestamp = %v, want 0.8", got)
+	}
+}
+
+// TestCheckDomains_DNSAgreementRaisesConfidence verifies an available WHOIS
+// verdict gains confidence when the DNS pre-check found no delegation.
+func TestCheckDomains_DNSAgreementRaisesConfidence(t *testing.T) {
+	cfg := runConfig{whoisServer: startWhoisServer(t, "No match for domain\n"), dnsPrecheck: true, resolver: &fakeResolver{delegated: map[string]bool{"taken.com": true}}}
+	var results []checkResult
+	_, _ = captureOutput(t, func() {
+		results = checkDomains([]string{"free.com", "taken.com"}, cfg)
+	})
+	if results[0].Confidence <= whoisStrongConfidence {
+		t.Errorf("free.com confidence %v not raised by DNS", results[0].Confidence)
+	}
+	if results[1].Confidence != dnsTakenConfidence || results[1].CheckedAt.IsZero() {
+		t.Errorf("unexpected pre-check result: %+v", results[1])
+	}
+}
@@ -0,0 +1,97 @@
+package talia
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// curationFilter selects records by the hand-set favorite and rejected
+// marks, for checks and reports. The zero value keeps everything.
+type curationFilter struct {
+	favoritesOnly   bool
+	excludeRejected bool
+}
+
+// addCurationFlags registers --favorites-only and --exclude-rejected on fs.
+// verb says what the flags limit: "check" or "show".
+func addCurationFlags(fs *flag.FlagSet, verb string) *curationFilter {
+	c := &curationFilter{}
+	fs.BoolVar(&c.favoritesOnly, "favorites-only", false, "Only "+verb+" records marked \"favorite\": true")
+	fs.BoolVar(&c.excludeRejected, "exclude-rejected", false, "Don't "+verb+" records marked \"rejected\": true")
+	return c
+}
+
+// active reports whether c filters anything.
+func (c curationFilter) active() bool {
+	return c.favoritesOnly || c.excludeRejected
+}
+
+// keep reports whether rec passes c.
+func (c curationFilter) keep(rec DomainRecord) bool {
+	if c.favoritesOnly && !rec.Favorite {
+		return false
+	}
+	return !c.excludeRejected || !rec.Rejected
+}
+
+// apply returns the records that pass c, in order.
+func (c curationFilter) apply(records []DomainRecord) []DomainRecord {
+	if !c.active() {
+		return records
+	}
+	var out []DomainRecord
+	for _, rec := range records {
+		if c.keep(rec) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// holdsBack reports whether cfg's run checks only some of records, by their
+// curation marks, by --shard, or because some are placeholders.
+func (cfg runConfig) holdsBack(records []DomainRecord) bool {
+	return cfg.curation.active() || cfg.shard.active() || slices.ContainsFunc(records, isPlaceholder)
+}
+
+// isPlaceholder reports whether rec has no domain yet, like the records
+// "talia new" appends for filling in by hand.
+func isPlaceholder(rec DomainRecord) bool {
+	return strings.TrimSpace(rec.Domain) == ""
+}
+
+// splitHeld divides records into those to check and those held back by
+// their curation marks, for another shard, or as placeholders, which a run
+// must write back unchanged. pos maps each checked record to its index in
+// records. It reports the numbers held back on cfg's status output.
+func splitHeld(cfg runConfig, records []DomainRecord) (checked, held []DomainRecord, pos []int) {
+	marked, sharded, blank := 0, 0, 0
+	for i, rec := range records {
+		switch {
+		case isPlaceholder(rec):
+			held = append(held, rec)
+			blank++
+		case !cfg.curation.keep(rec):
+			held = append(held, rec)
+			marked++
+		case !cfg.shard.keep(rec.Domain):
+			held = append(held, rec)
+			sharded++
+		default:
+			checked = append(checked, rec)
+			pos = append(pos, i)
+		}
+	}
+	if marked > 0 {
+		fmt.Fprintf(cfg.status(), "Holding back %d domains by favorite/rejected marks\n", marked)
+	}
+	if blank > 0 {
+		fmt.Fprintf(cfg.status(), "Skipping %d records without a domain\n", blank)
+	}
+	if cfg.shard.active() {
+		fmt.Fprintf(cfg.status(), "Shard %s: checking %d domains, leaving %d to the other shards\n", cfg.shard, len(checked), sharded)
+	}
+	return checked, held, pos
+}
@@ -0,0 +1,118 @@
+package talia
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRunCLI_CurationGrouped holds rejected entries back from the check
+// and keeps the marks on checked records.
+func TestRunCLI_CurationGrouped(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := writeGroupedFixture(t, GroupedData{
+		Unverified: []DomainRecord{{Domain: "fav.com", Favorite: true}, {Domain: "no.com", Rejected: true}, {Domain: "plain.com"}},
+	})
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--exclude-rejected", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"fav.com", "plain.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+	if !strings.Contains(stdout, "Holding back 1 domains") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Unverified) != 1 || data.Unverified[0].Domain != "no.com" || !data.Unverified[0].Rejected {
+		t.Errorf("unverified = %+v", data.Unverified)
+	}
+	if len(data.Available) != 2 || !data.Available[0].Favorite {
+		t.Errorf("available = %+v", data.Available)
+	}
+}
+
+// TestRunCLI_CurationArray checks only favorites of a list and writes the
+// others back in place.
+func TestRunCLI_CurationArray(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"},{"domain":"b.com","favorite":true},{"domain":"c.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--favorites-only", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"b.com"}) {
+		t.Errorf("queries = %v, want only b.com", got)
+	}
+	raw, _ := os.ReadFile(path)
+	var recs []DomainRecord
+	if err := json.Unmarshal(raw, &recs); err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 || recs[0].Domain != "a.com" || !recs[0].CheckedAt.IsZero() ||
+		recs[1].Domain != "b.com" || !recs[1].Favorite || recs[1].Status == "" {
+		t.Errorf("file = %s", raw)
+	}
+}
+
+// TestRunReportCommandCuration filters a report by the marks and shows them
+// in FLAGS.
+func TestRunReportCommandCuration(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{
+		Available:   []GroupedDomain{{Domain: "fav.com", Favorite: true}, {Domain: "plain.com"}},
+		Unavailable: []GroupedDomain{{Domain: "no.com", Rejected: true}},
+	})
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"report", "--format=csv", "--exclude-rejected", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[1][0] != "fav.com" || rows[1][5] != "favorite" || rows[2][0] != "plain.com" {
+		t.Errorf("rows = %q", rows)
+	}
+
+	stdout, _ = captureOutput(t, func() {
+		RunCLI([]string{"report", "--format=csv", "--favorites-only", path})
+	})
+	if rows, _ := csv.NewReader(strings.NewReader(stdout)).ReadAll(); len(rows) != 2 || rows[1][0] != "fav.com" {
+		t.Errorf("favorites only: rows = %q", rows)
+	}
+}
+
+// TestRunCLI_CurationArrayGrouped leaves records held back from a list in
+// unverified when the list becomes a grouped file.
+func TestRunCLI_CurationArrayGrouped(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"free.com"},{"domain":"taken1.com","rejected":true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--exclude-rejected", "--grouped-output", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Unavailable) != 0 || len(data.Unverified) != 1 || data.Unverified[0].Domain != "taken1.com" || !data.Unverified[0].Rejected {
+		t.Errorf("file = %+v", data)
+	}
+}
@@ -0,0 +1,175 @@
+package talia
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// bloomDedupThreshold is the estimated domain count above which dedup switches
+// from an in-memory map to a bloom filter with an on-disk exact check.
+const bloomDedupThreshold = 1_000_000
+
+// bloomFalsePositiveRate is the target false-positive rate of the bloom filter.
+// False positives only cost a disk lookup, never a wrong answer.
+const bloomFalsePositiveRate = 0.01
+
+// Spill layout for bloomSet: domains are hashed into spillBuckets files and
+// buffered in memory until a bucket holds spillFlushSize entries.
+const (
+	spillBuckets   = 256
+	spillFlushSize = 256
+)
+
+// domainSet records which domains have been seen during dedup.
+type domainSet interface {
+	// Add inserts domain and reports whether it was not already present.
+	Add(domain string) (bool, error)
+	// Close releases any resources (such as spill files) held by the set.
+	Close() error
+}
+
+// newDomainSet returns a set sized for roughly expected domains: a plain map
+// for ordinary inputs, or a memory-bounded bloomSet for very large ones.
+func newDomainSet(expected int) (domainSet, error) {
+	if expected < bloomDedupThreshold {
+		return mapSet{}, nil
+	}
+	return newBloomSet(expected)
+}
+
+// mapSet is an exact in-memory domainSet.
+type mapSet map[string]struct{}
+
+func (m mapSet) Add(domain string) (bool, error) {
+	if _, ok := m[domain]; ok {
+		return false, nil
+	}
+	m[domain] = struct{}{}
+	return true, nil
+}
+
+func (m mapSet) Close() error { return nil }
+
+// bloomSet is a domainSet whose memory use is bounded by a bloom filter plus
+// small per-bucket write buffers. Every added domain is also spilled to one of
+// spillBuckets files on disk; when the filter reports a possible duplicate,
+// only that bucket is scanned to get an exact answer.
+type bloomSet struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+
+	dir     string
+	pending [spillBuckets][]string
+}
+
+// newBloomSet sizes a bloom filter for expected entries at
+// bloomFalsePositiveRate and creates a temporary spill directory.
+func newBloomSet(expected int) (*bloomSet, error) {
+	n := float64(max(expected, 1))
+	m := uint64(math.Ceil(-n * math.Log(bloomFalsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/n*math.Ln2)))
+
+	dir, err := os.MkdirTemp("", "talia-dedup-*")
+	if err != nil {
+		return nil, fmt.Errorf("create dedup spill dir: %w", err)
+	}
+	return &bloomSet{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		dir:  dir,
+	}, nil
+}
+
+// bloomHashes returns the two base hashes used for double hashing.
+func bloomHashes(domain string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(domain))
+	h1 := h.Sum64()
+	h2 := (h1 >> 33) | (h1 << 31)
+	return h1, h2 | 1
+}
+
+func (b *bloomSet) Add(domain string) (bool, error) {
+	h1, h2 := bloomHashes(domain)
+	maybe := true
+	for i := range b.k {
+		pos := (h1 + i*h2) % b.m
+		if b.bits[pos/64]&(1<<(pos%64)) == 0 {
+			maybe = false
+			b.bits[pos/64] |= 1 << (pos % 64)
+		}
+	}
+
+	bucket := h1 % spillBuckets
+	if maybe {
+		found, err := b.spilled(bucket, domain)
+		if err != nil || found {
+			return false, err
+		}
+	}
+
+	b.pending[bucket] = append(b.pending[bucket], domain)
+	if len(b.pending[bucket]) >= spillFlushSize {
+		return true, b.flush(bucket)
+	}
+	return true, nil
+}
+
+// spilled reports whether domain was already recorded in bucket, checking the
+// in-memory buffer first and then the bucket's spill file.
+func (b *bloomSet) spilled(bucket uint64, domain string) (bool, error) {
+	for _, d := range b.pending[bucket] {
+		if d == domain {
+			return true, nil
+		}
+	}
+	f, err := os.Open(b.bucketPath(bucket))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read dedup spill: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if scanner.Text() == domain {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// flush appends the buffered domains for bucket to its spill file.
+func (b *bloomSet) flush(bucket uint64) error {
+	f, err := os.OpenFile(b.bucketPath(bucket), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("write dedup spill: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, d := range b.pending[bucket] {
+		_, _ = w.WriteString(d)
+		_ = w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write dedup spill: %w", err)
+	}
+	b.pending[bucket] = b.pending[bucket][:0]
+	return f.Close()
+}
+
+func (b *bloomSet) bucketPath(bucket uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%03d", bucket))
+}
+
+func (b *bloomSet) Close() error {
+	return os.RemoveAll(b.dir)
+}
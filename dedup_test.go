@@ -0,0 +1,67 @@
+package talia
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestBloomSetExactDedup verifies the bloom-backed set never drops a new domain
+// and always catches duplicates, even when the filter is undersized and
+// produces many false positives.
+func TestBloomSetExactDedup(t *testing.T) {
+	t.Parallel()
+	set, err := newBloomSet(50) // deliberately small for 2,000 entries
+	if err != nil {
+		t.Fatalf("newBloomSet: %v", err)
+	}
+	dir := set.dir
+	defer func() {
+		if err := set.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("spill dir not removed: %v", err)
+		}
+	}()
+
+	for i := range 2000 {
+		added, err := set.Add(fmt.Sprintf("domain%d.com", i))
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if !added {
+			t.Fatalf("domain%d.com reported as duplicate on first insert", i)
+		}
+	}
+	for i := range 2000 {
+		added, err := set.Add(fmt.Sprintf("domain%d.com", i))
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if added {
+			t.Fatalf("domain%d.com not detected as duplicate", i)
+		}
+	}
+}
+
+// TestNewDomainSetChoosesImplementation checks the size-based selection.
+func TestNewDomainSetChoosesImplementation(t *testing.T) {
+	t.Parallel()
+	small, err := newDomainSet(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := small.(mapSet); !ok {
+		t.Errorf("small set is %T, want mapSet", small)
+	}
+
+	large, err := newDomainSet(bloomDedupThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = large.Close() }()
+	if _, ok := large.(*bloomSet); !ok {
+		t.Errorf("large set is %T, want *bloomSet", large)
+	}
+}
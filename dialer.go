@@ -0,0 +1,89 @@
+package talia
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+)
+
+// whoisDialer holds how WHOIS connections are made: the local addresses to
+// send them from (--source-ip) and the IP version to use (--ip-version). A
+// nil *whoisDialer dials as the operating system chooses.
+type whoisDialer struct {
+	sourceIPs []net.IP
+	next      atomic.Uint64
+
+	// network is "tcp4" or "tcp6", or "" for either: then the server's
+	// addresses are tried happy-eyeballs style, IPv6 first with a quick
+	// fallback to IPv4.
+	network string
+}
+
+// ipVersionNetworks maps the values of --ip-version to dial networks.
+var ipVersionNetworks = map[string]string{
+	"auto": "",
+	"4":    "tcp4",
+	"6":    "tcp6",
+}
+
+// parseSourceIPs parses the comma-separated addresses of --source-ip and
+// checks that each is assigned to this machine, so a typo fails the run
+// instead of every lookup.
+func parseSourceIPs(s string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		ip := net.ParseIP(field)
+		if ip == nil {
+			return nil, fmt.Errorf("--source-ip: %q is not an IP address", field)
+		}
+		ln, err := net.Listen("tcp", net.JoinHostPort(ip.String(), "0"))
+		if err != nil {
+			return nil, fmt.Errorf("--source-ip: %s is not a local address: %w", ip, err)
+		}
+		_ = ln.Close()
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// newWhoisDialer returns the dialer for --source-ip and --ip-version, or nil
+// when neither changes anything.
+func newWhoisDialer(sourceIP, ipVersion string) (*whoisDialer, error) {
+	network, ok := ipVersionNetworks[ipVersion]
+	if !ok && ipVersion != "" {
+		return nil, fmt.Errorf("--ip-version must be 4, 6, or auto")
+	}
+	ips, err := parseSourceIPs(sourceIP)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if (network == "tcp4" && ip.To4() == nil) || (network == "tcp6" && ip.To4() != nil) {
+			return nil, fmt.Errorf("--source-ip %s doesn't match --ip-version=%s", ip, ipVersion)
+		}
+	}
+	if len(ips) == 0 && network == "" {
+		return nil, nil
+	}
+	return &whoisDialer{sourceIPs: ips, network: network}, nil
+}
+
+// apply sets up client for its next connection. With several source
+// addresses, successive connections cycle through them, from every worker,
+// so each address carries an equal share of the queries.
+func (d *whoisDialer) apply(client *NetWhoisClient) {
+	if d == nil {
+		return
+	}
+	client.Network = d.network
+	if len(d.sourceIPs) == 0 {
+		return
+	}
+	n := d.next.Add(1) - 1
+	client.LocalAddr = &net.TCPAddr{IP: d.sourceIPs[n%uint64(len(d.sourceIPs))]}
+}
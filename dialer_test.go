@@ -0,0 +1,115 @@
+package talia
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseSourceIPs(t *testing.T) {
+	ips, err := parseSourceIPs("127.0.0.1, ::1")
+	if err != nil {
+		// Some sandboxes have no IPv6 loopback.
+		if ips, err = parseSourceIPs("127.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(ips) == 0 || !ips[0].Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("ips = %v", ips)
+	}
+	if _, err := parseSourceIPs("not-an-ip"); err == nil || !strings.Contains(err.Error(), "not an IP address") {
+		t.Errorf("err = %v", err)
+	}
+	// TEST-NET-3 is never assigned to a machine.
+	if _, err := parseSourceIPs("203.0.113.7"); err == nil || !strings.Contains(err.Error(), "not a local address") {
+		t.Errorf("err = %v", err)
+	}
+	if d, err := newWhoisDialer("", "auto"); d != nil || err != nil {
+		t.Errorf("empty --source-ip = %v, %v", d, err)
+	}
+}
+
+// TestWhoisDialerCycles hands out the source addresses in turn.
+func TestWhoisDialerCycles(t *testing.T) {
+	a, b := net.IPv4(10, 0, 0, 1), net.IPv4(10, 0, 0, 2)
+	d := &whoisDialer{sourceIPs: []net.IP{a, b}}
+	var got []string
+	for range 4 {
+		var c NetWhoisClient
+		d.apply(&c)
+		got = append(got, c.LocalAddr.(*net.TCPAddr).IP.String())
+	}
+	if strings.Join(got, " ") != "10.0.0.1 10.0.0.2 10.0.0.1 10.0.0.2" {
+		t.Errorf("addresses = %v", got)
+	}
+	var none NetWhoisClient
+	(*whoisDialer)(nil).apply(&none)
+	if none.LocalAddr != nil {
+		t.Error("nil dialer set an address")
+	}
+}
+
+// TestCheckOneSourceIP connects from the configured address.
+func TestCheckOneSourceIP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	var mu sync.Mutex
+	var from string
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		mu.Lock()
+		from = conn.RemoteAddr().(*net.TCPAddr).IP.String()
+		mu.Unlock()
+		_, _ = conn.Write([]byte("No match for domain\n"))
+	}()
+
+	d, err := newWhoisDialer("127.0.0.1", "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := checkOne("free.com", runConfig{whoisServer: ln.Addr().String(), dialer: d}); res.Reason != ReasonNoMatch {
+		t.Fatalf("result = %+v", res)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if from != "127.0.0.1" {
+		t.Errorf("connected from %q", from)
+	}
+}
+
+func TestNewWhoisDialerIPVersion(t *testing.T) {
+	d, err := newWhoisDialer("", "6")
+	if err != nil || d == nil || d.network != "tcp6" {
+		t.Fatalf("dialer = %+v, %v", d, err)
+	}
+	var c NetWhoisClient
+	d.apply(&c)
+	if c.Network != "tcp6" || c.LocalAddr != nil {
+		t.Errorf("client = %+v", c)
+	}
+	if _, err := newWhoisDialer("", "5"); err == nil {
+		t.Error("--ip-version=5 accepted")
+	}
+	if _, err := newWhoisDialer("127.0.0.1", "6"); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Errorf("IPv4 source with --ip-version=6: %v", err)
+	}
+}
+
+// TestNetWhoisClientNetwork fails to reach an IPv4-only server over IPv6.
+func TestNetWhoisClientNetwork(t *testing.T) {
+	addr := startWhoisServer(t, "No match for domain\n")
+	if _, err := (NetWhoisClient{Server: addr, Network: "tcp4"}).Lookup("a.com"); err != nil {
+		t.Errorf("tcp4: %v", err)
+	}
+	if _, err := (NetWhoisClient{Server: addr, Network: "tcp6"}).Lookup("a.com"); err == nil {
+		t.Error("tcp6 reached a 127.0.0.1 server")
+	}
+}
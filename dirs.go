@@ -0,0 +1,101 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFileName is the env-style config file in the config directory,
+// loaded after ./.env (see RunCLI).
+const configFileName = "config.env"
+
+// cacheDir returns Talia's cache directory (server database, TLD info):
+// $TALIA_CACHE_DIR (or --cache-dir), else $XDG_CACHE_HOME/talia, else
+// "talia" under the platform's user cache directory.
+func cacheDir() (string, error) {
+	return taliaDir("TALIA_CACHE_DIR", "XDG_CACHE_HOME", os.UserCacheDir)
+}
+
+// configDir returns Talia's config directory: $TALIA_CONFIG_DIR (or
+// --config-dir), else $XDG_CONFIG_HOME/talia, else "talia" under the
+// platform's user config directory.
+func configDir() (string, error) {
+	return taliaDir("TALIA_CONFIG_DIR", "XDG_CONFIG_HOME", os.UserConfigDir)
+}
+
+// taliaDir resolves a directory from Talia's own variable, then the XDG
+// one (honored on every platform, not only Linux), then the platform default.
+func taliaDir(own, xdg string, platform func() (string, error)) (string, error) {
+	if dir := os.Getenv(own); dir != "" {
+		return dir, nil
+	}
+	// The XDG spec says relative paths are invalid and must be ignored.
+	if base := os.Getenv(xdg); filepath.IsAbs(base) {
+		return filepath.Join(base, "talia"), nil
+	}
+	base, err := platform()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "talia"), nil
+}
+
+// globalFlags maps the flags accepted before or after any subcommand to the
+// variables they set.
+var globalFlags = map[string]string{
+	"audit-log":    "TALIA_AUDIT_LOG",
+	"cache-dir":    "TALIA_CACHE_DIR",
+	"config-dir":   "TALIA_CONFIG_DIR",
+	"error-format": "TALIA_ERROR_FORMAT",
+	"profile":      "TALIA_PROFILE",
+}
+
+// applyGlobalFlags removes --audit-log, --cache-dir, --config-dir,
+// --error-format, and --profile (as --flag=value or --flag value, anywhere
+// before "--") from args and exports them as their TALIA_* variables, so
+// every subcommand and the config file lookup see them. It returns the
+// remaining arguments and a function that puts the variables back as they
+// were, so a later RunCLI call in the same process doesn't inherit them.
+func applyGlobalFlags(args []string) (rest []string, restore func()) {
+	saved := make(map[string]*string)
+	restore = func() {
+		for env, prev := range saved {
+			if prev == nil {
+				_ = os.Unsetenv(env)
+			} else {
+				_ = os.Setenv(env, *prev)
+			}
+		}
+	}
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...), restore
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		env, ok := globalFlags[name]
+		if !ok || !strings.HasPrefix(arg, "-") {
+			out = append(out, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				out = append(out, arg) // let the flag parser report it
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		if _, done := saved[env]; !done {
+			if prev, ok := os.LookupEnv(env); ok {
+				saved[env] = &prev
+			} else {
+				saved[env] = nil
+			}
+		}
+		_ = os.Setenv(env, value)
+	}
+	return out, restore
+}
@@ -0,0 +1,62 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestCacheAndConfigDir checks the TALIA_*, XDG, and platform fallbacks.
+func TestCacheAndConfigDir(t *testing.T) {
+	t.Setenv("TALIA_CACHE_DIR", "")
+	t.Setenv("TALIA_CONFIG_DIR", "/etc/talia-test")
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+
+	if dir, _ := cacheDir(); dir != filepath.FromSlash("/xdg/cache/talia") {
+		t.Errorf("cacheDir = %q", dir)
+	}
+	if dir, _ := configDir(); dir != "/etc/talia-test" {
+		t.Errorf("configDir = %q", dir)
+	}
+
+	t.Setenv("XDG_CACHE_HOME", "relative")
+	if dir, _ := cacheDir(); dir == filepath.Join("relative", "talia") {
+		t.Errorf("relative XDG_CACHE_HOME used: %q", dir)
+	}
+}
+
+// TestApplyDirFlags strips both flag forms and exports them.
+func TestApplyDirFlags(t *testing.T) {
+	t.Setenv("TALIA_CACHE_DIR", "")
+	t.Setenv("TALIA_CONFIG_DIR", "")
+	got, restore := applyGlobalFlags([]string{"tld-info", "--cache-dir=/c", "com", "-config-dir", "/cfg", "--", "--cache-dir=/no"})
+	if want := []string{"tld-info", "com", "--", "--cache-dir=/no"}; !slices.Equal(got, want) {
+		t.Errorf("args = %q, want %q", got, want)
+	}
+	if os.Getenv("TALIA_CACHE_DIR") != "/c" || os.Getenv("TALIA_CONFIG_DIR") != "/cfg" {
+		t.Errorf("env = %q, %q", os.Getenv("TALIA_CACHE_DIR"), os.Getenv("TALIA_CONFIG_DIR"))
+	}
+	restore()
+	if os.Getenv("TALIA_CACHE_DIR") != "" || os.Getenv("TALIA_CONFIG_DIR") != "" {
+		t.Errorf("env after restore = %q, %q", os.Getenv("TALIA_CACHE_DIR"), os.Getenv("TALIA_CONFIG_DIR"))
+	}
+}
+
+// TestRunCLIGlobalFlagsDontLeak leaves the environment as it was for the
+// next RunCLI call in the process.
+func TestRunCLIGlobalFlagsDontLeak(t *testing.T) {
+	t.Setenv("TALIA_ERROR_FORMAT", "")
+	_, stderr := captureOutput(t, func() {
+		RunCLI([]string{"--error-format=json", "missing.json"})
+		RunCLI([]string{"missing.json"})
+	})
+	if n := strings.Count(stderr, `"code":`); n != 1 {
+		t.Errorf("%d JSON errors, want 1 from the first call:\n%s", n, stderr)
+	}
+	if os.Getenv("TALIA_ERROR_FORMAT") != "" {
+		t.Errorf("TALIA_ERROR_FORMAT = %q", os.Getenv("TALIA_ERROR_FORMAT"))
+	}
+}
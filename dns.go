@@ -0,0 +1,50 @@
+package talia
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// defaultDNSConcurrency is the number of DNS lookups the pre-check keeps in
+// flight when --dns-concurrency is not set. DNS resolvers tolerate far more
+// parallelism than WHOIS servers, so this is much higher than --lightspeed.
+const defaultDNSConcurrency = 256
+
+// dnsLookupTimeout bounds each individual pre-check lookup.
+const dnsLookupTimeout = 5 * time.Second
+
+// nsResolver abstracts the DNS lookups used by the pre-check.
+type nsResolver interface {
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+}
+
+// dnsPrecheck resolves NS records for all domains in parallel batches of up to
+// concurrency lookups and reports, per index, whether the domain is delegated.
+// A delegated domain is registered, so its WHOIS query can be skipped. Lookup
+// failures (NXDOMAIN, timeouts) report false and leave the domain to WHOIS.
+func dnsPrecheck(resolver nsResolver, domains []string, concurrency int) []bool {
+	if concurrency < 1 {
+		concurrency = defaultDNSConcurrency
+	}
+	delegated := make([]bool, len(domains))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, domain := range domains {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+			defer cancel()
+			ns, err := resolver.LookupNS(ctx, domain)
+			delegated[i] = err == nil && len(ns) > 0
+		}()
+	}
+
+	wg.Wait()
+	return delegated
+}
@@ -0,0 +1,94 @@
+package talia
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResolver reports NS records for the domains in delegated and tracks the
+// peak number of concurrent lookups.
+type fakeResolver struct {
+	delegated map[string]bool
+	delay     time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (f *fakeResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.peak {
+		f.peak = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	time.Sleep(f.delay)
+	if f.delegated[name] {
+		return []*net.NS{{Host: "ns1.example.net."}}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+// TestDNSPrecheck verifies delegation results are reported per index and that
+// lookups run concurrently up to the configured limit.
+func TestDNSPrecheck(t *testing.T) {
+	t.Parallel()
+	r := &fakeResolver{
+		delegated: map[string]bool{"taken.com": true, "also-taken.com": true},
+		delay:     10 * time.Millisecond,
+	}
+	domains := []string{"taken.com", "free.com", "also-taken.com", "free2.com", "free3.com", "free4.com"}
+
+	got := dnsPrecheck(r, domains, 3)
+	want := []bool{true, false, true, false, false, false}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: delegated=%v want %v", domains[i], got[i], want[i])
+		}
+	}
+	if r.peak > 3 {
+		t.Errorf("peak concurrency %d exceeds limit 3", r.peak)
+	}
+	if r.peak < 2 {
+		t.Errorf("expected lookups to overlap, peak was %d", r.peak)
+	}
+}
+
+// TestCheckDomains_DNSPrecheckSkipsWhois verifies delegated domains never reach
+// the WHOIS server and that results keep input order.
+func TestCheckDomains_DNSPrecheckSkipsWhois(t *testing.T) {
+	srv := newWhoisServer(t)
+
+	cfg := runConfig{
+		whoisServer: srv.Addr,
+		verbose:     true,
+		dnsPrecheck: true,
+		resolver:    &fakeResolver{delegated: map[string]bool{"taken.com": true}},
+	}
+	var results []checkResult
+	_, _ = captureOutput(t, func() {
+		results = checkDomains([]string{"free.com", "taken.com", "free2.com"}, cfg)
+	})
+
+	if got := srv.Queries(); !slices.Equal(got, []string{"free.com", "free2.com"}) {
+		t.Errorf("WHOIS queries = %v, want free.com and free2.com", got)
+	}
+	if results[1].Domain != "taken.com" || results[1].Reason != ReasonTaken || results[1].Log != dnsPrecheckLog {
+		t.Errorf("unexpected pre-check result: %+v", results[1])
+	}
+	if results[0].Domain != "free.com" || !results[0].Avail || results[2].Domain != "free2.com" || !results[2].Avail {
+		t.Errorf("unexpected WHOIS results: %+v", results)
+	}
+}
@@ -0,0 +1,163 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// duplicateDomain is a domain that occurs more than once in a file, with the
+// location of every occurrence: "[3]" for array files, "available[0]" and
+// similar for grouped files.
+type duplicateDomain struct {
+	Domain    string
+	Locations []string
+}
+
+// foldDomain folds case, surrounding whitespace, and a trailing root dot
+// (Brand.co.uk. is brand.co.uk).
+func foldDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
+
+// dupKey is the comparison key for duplicate detection. It only folds the
+// domain (see foldDomain), so subdomains such as shop.brand.co.uk stay
+// distinct from brand.co.uk, and domains --clean would reject are still
+// reported.
+func dupKey(domain string) string {
+	return foldDomain(domain)
+}
+
+// dupTracker records occurrences in file order.
+type dupTracker struct {
+	order []string
+	locs  map[string][]string
+}
+
+func newDupTracker() *dupTracker {
+	return &dupTracker{locs: make(map[string][]string)}
+}
+
+// see records an occurrence and reports whether it is the first one.
+func (t *dupTracker) see(domain, loc string) bool {
+	k := dupKey(domain)
+	prev, ok := t.locs[k]
+	if !ok {
+		t.order = append(t.order, k)
+	}
+	t.locs[k] = append(prev, loc)
+	return !ok
+}
+
+func (t *dupTracker) duplicates() []duplicateDomain {
+	var out []duplicateDomain
+	for _, k := range t.order {
+		if locs := t.locs[k]; len(locs) > 1 {
+			out = append(out, duplicateDomain{Domain: k, Locations: locs})
+		}
+	}
+	return out
+}
+
+// findArrayDuplicates reports domains repeated in an array file.
+func findArrayDuplicates(records []DomainRecord) []duplicateDomain {
+	t := newDupTracker()
+	for i, rec := range records {
+		t.see(rec.Domain, fmt.Sprintf("[%d]", i))
+	}
+	return t.duplicates()
+}
+
+// findGroupedDuplicates reports domains repeated within or across the buckets
+// of a grouped file.
+func findGroupedDuplicates(ext ExtendedGroupedData) []duplicateDomain {
+	t := newDupTracker()
+	for i, gd := range ext.Available {
+		t.see(gd.Domain, fmt.Sprintf("available[%d]", i))
+	}
+	for i, gd := range ext.Unavailable {
+		t.see(gd.Domain, fmt.Sprintf("unavailable[%d]", i))
+	}
+	for i, rec := range ext.Unverified {
+		t.see(rec.Domain, fmt.Sprintf("unverified[%d]", i))
+	}
+	return t.duplicates()
+}
+
+// warnDuplicates prints one warning line per duplicate to stderr.
+func warnDuplicates(path string, dups []duplicateDomain) {
+	for _, d := range dups {
+		fmt.Fprintf(os.Stderr, "Warning: %s: duplicate domain %s at %s\n", path, d.Domain, strings.Join(d.Locations, ", "))
+	}
+	if len(dups) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d duplicate domains in %s (run with --dedupe to fix)\n", len(dups), path)
+	}
+}
+
+// dedupeFile removes duplicate domains from the array or grouped file at path,
+// keeping the first occurrence. For grouped files buckets are scanned in the
+// order available, unavailable, unverified, so a checked result wins over a
+// pending one. It returns the duplicates that were removed.
+func dedupeFile(path string) ([]duplicateDomain, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	var dups []duplicateDomain
+	var records []DomainRecord
+	if err := json.Unmarshal(raw, &records); err == nil {
+		dups = findArrayDuplicates(records)
+		t := newDupTracker()
+		kept := make([]DomainRecord, 0, len(records))
+		for _, rec := range records {
+			if t.see(rec.Domain, "") {
+				kept = append(kept, rec)
+			}
+		}
+		out = kept
+	} else {
+		var ext ExtendedGroupedData
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if err := applyPendingLog(path, &ext); err != nil {
+			return nil, err
+		}
+		dups = findGroupedDuplicates(ext)
+		t := newDupTracker()
+		var kept ExtendedGroupedData
+		for _, gd := range ext.Available {
+			if t.see(gd.Domain, "") {
+				kept.Available = append(kept.Available, gd)
+			}
+		}
+		for _, gd := range ext.Unavailable {
+			if t.see(gd.Domain, "") {
+				kept.Unavailable = append(kept.Unavailable, gd)
+			}
+		}
+		for _, rec := range ext.Unverified {
+			if t.see(rec.Domain, "") {
+				kept.Unverified = append(kept.Unverified, rec)
+			}
+		}
+		out = kept
+	}
+
+	if len(dups) == 0 {
+		return nil, nil
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return dups, auditWrite("dedupe", "", path, func() error {
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(path)
+	})
+}
@@ -0,0 +1,82 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFindGroupedDuplicates verifies duplicates within and across buckets are
+// reported with every location, case-insensitively.
+func TestFindGroupedDuplicates(t *testing.T) {
+	t.Parallel()
+	ext := ExtendedGroupedData{
+		Available:   []GroupedDomain{{Domain: "a.com"}, {Domain: "b.com"}},
+		Unavailable: []GroupedDomain{{Domain: "A.com"}},
+		Unverified:  []DomainRecord{{Domain: "c.com"}, {Domain: "c.com"}},
+	}
+	got := findGroupedDuplicates(ext)
+	if len(got) != 2 {
+		t.Fatalf("want 2 duplicates, got %+v", got)
+	}
+	if got[0].Domain != "a.com" || strings.Join(got[0].Locations, " ") != "available[0] unavailable[0]" {
+		t.Errorf("unexpected first duplicate: %+v", got[0])
+	}
+	if got[1].Domain != "c.com" || strings.Join(got[1].Locations, " ") != "unverified[0] unverified[1]" {
+		t.Errorf("unexpected second duplicate: %+v", got[1])
+	}
+}
+
+// TestFindArrayDuplicates verifies repeated array entries are reported by index.
+func TestFindArrayDuplicates(t *testing.T) {
+	t.Parallel()
+	got := findArrayDuplicates([]DomainRecord{{Domain: "x.com"}, {Domain: "y.com"}, {Domain: " x.com"}})
+	if len(got) != 1 || strings.Join(got[0].Locations, " ") != "[0] [2]" {
+		t.Errorf("unexpected duplicates: %+v", got)
+	}
+}
+
+// TestRunCLIDedupe runs --dedupe on a grouped file and checks the first
+// occurrence is kept.
+func TestRunCLIDedupe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	in := `{"available":[{"domain":"a.com","reason":"NO_MATCH"}],"unavailable":[{"domain":"a.com","reason":"TAKEN"},{"domain":"b.com","reason":"TAKEN"}]}`
+	if err := os.WriteFile(path, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--dedupe", path}); code != 0 {
+			t.Errorf("exit code %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "a.com (kept available[0], removed unavailable[0])") {
+		t.Errorf("unexpected report: %s", stdout)
+	}
+
+	raw, _ := os.ReadFile(path)
+	var out ExtendedGroupedData
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Available) != 1 || len(out.Unavailable) != 1 || out.Unavailable[0].Domain != "b.com" {
+		t.Errorf("unexpected file after dedupe: %s", raw)
+	}
+}
+
+// TestRunCLIWarnsOnDuplicates verifies a normal check run warns on stderr
+// without modifying the duplicate entries itself.
+func TestRunCLIWarnsOnDuplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"x.com"},{"domain":"x.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := captureOutput(t, func() {
+		_ = RunCLI([]string{"--whois=127.0.0.1:1", "--sleep=0s", path})
+	})
+	if !strings.Contains(stderr, "duplicate domain x.com at [0], [1]") {
+		t.Errorf("missing duplicate warning: %s", stderr)
+	}
+}
@@ -7,7 +7,8 @@ import (
 )
 
 // LoadEnvFile loads environment variables from a .env file.
-// It does not override existing environment variables.
+// It does not override existing environment variables. Loading stops at the
+// first section header such as "[profile.client-x]" (see readProfile).
 func LoadEnvFile(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -18,36 +19,62 @@ func LoadEnvFile(path string) error {
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
+		if _, ok := sectionHeader(line); ok {
+			break
+		}
+		key, value, ok := parseEnvLine(line)
+		if !ok {
 			continue
 		}
 
-		// Split on first =
-		idx := strings.Index(line, "=")
-		if idx == -1 {
-			continue
+		// Don't override existing env vars (including those set to empty string)
+		if err := setenvDefault(key, value); err != nil {
+			return err
 		}
+	}
 
-		key := strings.TrimSpace(line[:idx])
-		value := strings.TrimSpace(line[idx+1:])
+	return scanner.Err()
+}
 
-		// Remove surrounding quotes if present
-		if len(value) >= 2 {
-			if (value[0] == '"' && value[len(value)-1] == '"') ||
-				(value[0] == '\'' && value[len(value)-1] == '\'') {
-				value = value[1 : len(value)-1]
-			}
-		}
+// parseEnvLine splits a trimmed KEY=VALUE line, removing matching quotes
+// around the value. Empty lines, comments, and lines without "=" are not ok.
+func parseEnvLine(line string) (key, value string, ok bool) {
+	// Skip empty lines and comments
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
 
-		// Don't override existing env vars (including those set to empty string)
-		if _, exists := os.LookupEnv(key); !exists {
-			if err := os.Setenv(key, value); err != nil {
-				return err
-			}
+	// Split on first =
+	idx := strings.Index(line, "=")
+	if idx == -1 {
+		return "", "", false
+	}
+
+	key = strings.TrimSpace(line[:idx])
+	value = strings.TrimSpace(line[idx+1:])
+
+	// Remove surrounding quotes if present
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') ||
+			(value[0] == '\'' && value[len(value)-1] == '\'') {
+			value = value[1 : len(value)-1]
 		}
 	}
+	return key, value, true
+}
 
-	return scanner.Err()
+// sectionHeader returns the name of a "[name]" line.
+func sectionHeader(line string) (string, bool) {
+	if len(line) < 2 || line[0] != '[' || line[len(line)-1] != ']' {
+		return "", false
+	}
+	return strings.TrimSpace(line[1 : len(line)-1]), true
+}
+
+// setenvDefault sets key to value unless key is already set, even to "".
+func setenvDefault(key, value string) error {
+	if _, exists := os.LookupEnv(key); exists {
+		return nil
+	}
+	return os.Setenv(key, value)
 }
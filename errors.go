@@ -0,0 +1,84 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Error codes of the JSON error objects printed with --error-format=json.
+// They are part of the CLI's interface: scripts branch on them, so existing
+// codes must not change meaning.
+const (
+	errCodeUsage            = "USAGE_ERROR"        // bad flags or arguments
+	errCodeInputRead        = "INPUT_READ_ERROR"   // input missing, unreadable, or not fetched
+	errCodeInputParse       = "INPUT_PARSE_ERROR"  // input is not a format Talia reads
+	errCodeOutputWrite      = "OUTPUT_WRITE_ERROR" // results could not be written
+	errCodeWhoisUnreachable = "WHOIS_UNREACHABLE"  // every WHOIS check failed
+	errCodeChecksFailed     = "CHECKS_FAILED"      // some checks failed (--fail-on-error)
+	errCodeDelivery         = "DELIVERY_ERROR"     // --upload, --post-results, or --notify failed
+	errCodeSuggest          = "SUGGEST_ERROR"      // AI suggestions could not be generated
+	errCodeStaleResults     = "STALE_RESULTS"      // verdicts older than --max-age
+	errCodeGeneric          = "ERROR"              // anything else
+)
+
+// errorFormatJSON is the --error-format (TALIA_ERROR_FORMAT) value that
+// turns on JSON error objects.
+const errorFormatJSON = "json"
+
+// cliError is a failure as reported in a JSON error object.
+type cliError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"` // the file or URL involved, if any
+	Hint    string `json:"hint,omitempty"` // what to try next, if known
+}
+
+// errorReported records whether the current RunCLI call has printed a JSON
+// error object, so it can add a generic one for failures that didn't.
+var errorReported atomic.Bool
+
+// fail prints the human-readable error (format and args, as before) and a
+// hint to stderr, followed by e as a JSON error object when
+// TALIA_ERROR_FORMAT is "json". e.Message defaults to the first line of the
+// human text without its "Error" prefix. It returns 1, the exit code.
+func fail(e cliError, format string, args ...any) int {
+	text := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	fmt.Fprintln(os.Stderr, text)
+	if e.Hint != "" {
+		fmt.Fprintln(os.Stderr, "Hint:", e.Hint)
+	}
+	if e.Message == "" {
+		e.Message = errorMessage(text)
+	}
+	emitError(e)
+	return 1
+}
+
+// errorMessage turns the first line of a human-readable error such as
+// "Error reading a.json: ..." or "Error: ..." into the bare message.
+func errorMessage(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	if rest, ok := strings.CutPrefix(line, "Error"); ok {
+		line = strings.TrimLeft(rest, ": ")
+	}
+	return line
+}
+
+// emitError writes e as a single-line JSON object, {"error": {...}}, to
+// stderr if JSON errors are on.
+func emitError(e cliError) {
+	if os.Getenv("TALIA_ERROR_FORMAT") != errorFormatJSON {
+		return
+	}
+	errorReported.Store(true)
+	out, err := json.Marshal(struct {
+		Error cliError `json:"error"`
+	}{e})
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(out))
+}
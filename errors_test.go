@@ -0,0 +1,99 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestErrorMessage strips the "Error" prefix and keeps the first line.
+func TestErrorMessage(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{
+		"Error reading a.json: no such file":     "reading a.json: no such file",
+		"Error: --print must be \"available\"":   "--print must be \"available\"",
+		"Error parsing JSON in a.json\n  detail": "parsing JSON in a.json",
+		"Usage: talia [options] <json-file>":     "Usage: talia [options] <json-file>",
+	} {
+		if got := errorMessage(in); got != want {
+			t.Errorf("errorMessage(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// jsonError returns the JSON error object on the last line of stderr.
+func jsonError(t *testing.T, stderr string) cliError {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	var out struct {
+		Error cliError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &out); err != nil {
+		t.Fatalf("last stderr line is not a JSON error: %q", stderr)
+	}
+	return out.Error
+}
+
+// TestRunCLI_ErrorFormatJSON checks the codes of common failures and that
+// the human text is still printed.
+func TestRunCLI_ErrorFormatJSON(t *testing.T) {
+	t.Setenv("TALIA_ERROR_FORMAT", "")
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"available": [}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	one := filepath.Join(dir, "one.json")
+	if err := os.WriteFile(one, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	grouped := filepath.Join(dir, "grouped.json")
+	if err := os.WriteFile(grouped, []byte(`{"available":[{"domain":"a.com"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		code string
+		path string
+	}{
+		{"parse", []string{bad}, errCodeInputParse, bad},
+		{"read", []string{filepath.Join(dir, "missing.json")}, errCodeInputRead, filepath.Join(dir, "missing.json")},
+		{"usage", []string{"--print=all", one}, errCodeUsage, ""},
+		{"unreachable", []string{"--whois=127.0.0.1:1", "--sleep=0s", "--fail-on-error", one}, errCodeWhoisUnreachable, ""},
+		{"whois usage", []string{"whois", "--bogus", "a.com"}, errCodeUsage, ""},
+		{"check usage", []string{"check", "--format=xml", "a.com"}, errCodeUsage, ""},
+		{"check domain", []string{"check", "com"}, errCodeUsage, ""},
+		{"merge usage", []string{"merge", "--bogus", bad}, errCodeUsage, ""},
+		{"merge read", []string{"merge", "-o", filepath.Join(dir, "merged.json"), filepath.Join(dir, "missing.json")}, errCodeInputRead, filepath.Join(dir, "missing.json")},
+		{"merge parse", []string{"merge", "-o", filepath.Join(dir, "merged.json"), bad}, errCodeInputParse, bad},
+		{"merge write", []string{"merge", "-o", dir, grouped}, errCodeOutputWrite, dir},
+		{"subcommand fallback", []string{"report", filepath.Join(dir, "missing.json")}, errCodeGeneric, ""},
+	}
+	for _, tt := range tests {
+		_, stderr := captureOutput(t, func() {
+			if code := RunCLI(append([]string{"--error-format=json"}, tt.args...)); code != 1 {
+				t.Errorf("%s: exit %d, want 1", tt.name, code)
+			}
+		})
+		got := jsonError(t, stderr)
+		if got.Code != tt.code || got.Path != tt.path || got.Message == "" {
+			t.Errorf("%s: error = %+v, want code %s path %q", tt.name, got, tt.code, tt.path)
+		}
+		if !strings.HasPrefix(stderr, "Error") && !strings.Contains(stderr, "\nError") {
+			t.Errorf("%s: human text missing: %q", tt.name, stderr)
+		}
+	}
+
+	t.Setenv("TALIA_ERROR_FORMAT", "")
+	_, stderr := captureOutput(t, func() { RunCLI([]string{bad}) })
+	if strings.Contains(stderr, `{"error"`) {
+		t.Errorf("JSON error without --error-format: %q", stderr)
+	}
+	if !strings.Contains(stderr, "Hint: expected a JSON array") {
+		t.Errorf("hint missing: %q", stderr)
+	}
+}
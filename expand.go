@@ -0,0 +1,69 @@
+package talia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxBraceExpansion caps how many domains one pattern may expand to, so a
+// typo such as a long list of nested groups cannot exhaust memory.
+const maxBraceExpansion = 10000
+
+// expandBraces expands shell-style brace patterns such as
+// "get{app,tool,kit}.{com,io}" into the cross product of their alternatives,
+// in order: getapp.com, getapp.io, gettool.com, ... Groups may be nested.
+// A string without braces expands to itself.
+func expandBraces(pattern string) ([]string, error) {
+	out, err := expandBracesInto(nil, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+	}
+	return out, nil
+}
+
+func expandBracesInto(out []string, pattern string) ([]string, error) {
+	open := strings.IndexByte(pattern, '{')
+	if open < 0 {
+		if strings.IndexByte(pattern, '}') >= 0 {
+			return nil, fmt.Errorf("unmatched '}'")
+		}
+		if len(out) >= maxBraceExpansion {
+			return nil, fmt.Errorf("expands to more than %d domains", maxBraceExpansion)
+		}
+		return append(out, pattern), nil
+	}
+
+	// Find the matching close brace and the top-level commas between them.
+	depth := 0
+	cuts := []int{open}
+	closeAt := -1
+	for i := open; i < len(pattern) && closeAt < 0; i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				closeAt = i
+			}
+		case ',':
+			if depth == 1 {
+				cuts = append(cuts, i)
+			}
+		}
+	}
+	if closeAt < 0 {
+		return nil, fmt.Errorf("unmatched '{'")
+	}
+	cuts = append(cuts, closeAt)
+
+	prefix, suffix := pattern[:open], pattern[closeAt+1:]
+	var err error
+	for i := 0; i+1 < len(cuts); i++ {
+		alt := pattern[cuts[i]+1 : cuts[i+1]]
+		if out, err = expandBracesInto(out, prefix+alt+suffix); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,88 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExpandBraces covers cross products, nesting, and malformed patterns.
+func TestExpandBraces(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"example.com":                "example.com",
+		"get{app,tool,kit}.{com,io}": "getapp.com getapp.io gettool.com gettool.io getkit.com getkit.io",
+		"{a,b{1,2}}.com":             "a.com b1.com b2.com",
+		"x{,y}.com":                  "x.com xy.com",
+		"{solo}.com":                 "solo.com",
+	}
+	for in, want := range cases {
+		got, err := expandBraces(in)
+		if err != nil || strings.Join(got, " ") != want {
+			t.Errorf("expandBraces(%q) = %v, %v; want %s", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"get{app.com", "getapp}.com", strings.Repeat("{a,b,c,d,e,f,g,h,i,j}", 5) + ".com"} {
+		if _, err := expandBraces(bad); err == nil {
+			t.Errorf("expandBraces(%q) succeeded", bad)
+		}
+	}
+}
+
+// TestCleanTextFileExpandsPatterns expands brace patterns in text lists,
+// keeping expansions under any TLD, and drops lines that fail.
+func TestCleanTextFileExpandsPatterns(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "names.txt")
+	if err := os.WriteFile(path, []byte("get{app,kit}.{com,io}\nbad{.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := cleanTextFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := os.ReadFile(path)
+	if string(raw) != "getapp.com\ngetapp.io\ngetkit.com\ngetkit.io\n" {
+		t.Errorf("file = %q", raw)
+	}
+	if strings.Join(removed, " ") != "bad{.com" {
+		t.Errorf("removed = %q", removed)
+	}
+}
+
+// TestTextListRecordsExpandsPatterns expands patterns in text lists read as
+// check or set-operation input and rejects malformed ones.
+func TestTextListRecordsExpandsPatterns(t *testing.T) {
+	t.Parallel()
+	records, err := textListRecords([]byte("# ideas\nGet{App,Kit}.{com,io}\nplain.net\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, rec := range records {
+		got = append(got, rec.Domain)
+	}
+	if strings.Join(got, " ") != "getapp.com getapp.io getkit.com getkit.io plain.net" {
+		t.Errorf("domains = %q", got)
+	}
+	if _, err := textListRecords([]byte("bad{.com\n")); err == nil {
+		t.Error("malformed pattern accepted")
+	}
+}
+
+// TestRunCLICheckSubcommandExpandsPatterns checks every expansion of a
+// pattern argument.
+func TestRunCLICheckSubcommandExpandsPatterns(t *testing.T) {
+	addr := startWhoisServer(t, "No match for domain\n")
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "--whois=" + addr, "--lightspeed=max", "try{a,b}.{com,io}"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	for _, d := range []string{"trya.com", "trya.io", "tryb.com", "tryb.io"} {
+		if !strings.Contains(stdout, d+" ") {
+			t.Errorf("missing %s in %q", d, stdout)
+		}
+	}
+}
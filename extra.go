@@ -0,0 +1,231 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// domainRecordJSON and groupedDomainJSON have the same fields as their
+// namesakes but none of the methods, so they can be (un)marshaled with the
+// default encoding without recursing into the custom methods below.
+type (
+	domainRecordJSON  DomainRecord
+	groupedDomainJSON GroupedDomain
+)
+
+// Keys handled by struct fields; everything else is an extra field.
+var (
+	domainRecordKeys  = jsonKeys(reflect.TypeFor[DomainRecord]())
+	groupedDomainKeys = jsonKeys(reflect.TypeFor[GroupedDomain]())
+)
+
+// looseFields are the hand-edited fields that are decoded leniently: a value
+// of another JSON type, such as "priority": "high", is kept in Extra and the
+// field left zero, rather than failing the whole file.
+var looseFields = map[string]func(json.RawMessage) bool{
+	"priority": func(v json.RawMessage) bool { var n int; return json.Unmarshal(v, &n) == nil },
+	"notes":    func(v json.RawMessage) bool { var s string; return json.Unmarshal(v, &s) == nil },
+}
+
+// UnmarshalJSON decodes the known fields and keeps any others in Extra.
+func (d *DomainRecord) UnmarshalJSON(data []byte) error {
+	var plain domainRecordJSON
+	fields, loose := data, map[string]json.RawMessage(nil)
+	if err := json.Unmarshal(data, &plain); err != nil {
+		var ok bool
+		if fields, loose, ok = cutLooseFields(data); !ok {
+			return newRecordError(err, "DomainRecord", data)
+		}
+		plain = domainRecordJSON{}
+		if json.Unmarshal(fields, &plain) != nil {
+			return newRecordError(err, "DomainRecord", data)
+		}
+	}
+	extra, err := extraFields(fields, domainRecordKeys)
+	if err != nil {
+		return err
+	}
+	*d = DomainRecord(plain)
+	d.Extra = withLooseFields(extra, loose)
+	return nil
+}
+
+// MarshalJSON encodes the known fields followed by the fields in Extra.
+func (d DomainRecord) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(domainRecordJSON(d), d.Extra, domainRecordKeys)
+}
+
+// UnmarshalJSON decodes the known fields and keeps any others in Extra.
+func (g *GroupedDomain) UnmarshalJSON(data []byte) error {
+	var plain groupedDomainJSON
+	fields, loose := data, map[string]json.RawMessage(nil)
+	if err := json.Unmarshal(data, &plain); err != nil {
+		var ok bool
+		if fields, loose, ok = cutLooseFields(data); !ok {
+			return newRecordError(err, "GroupedDomain", data)
+		}
+		plain = groupedDomainJSON{}
+		if json.Unmarshal(fields, &plain) != nil {
+			return newRecordError(err, "GroupedDomain", data)
+		}
+	}
+	extra, err := extraFields(fields, groupedDomainKeys)
+	if err != nil {
+		return err
+	}
+	*g = GroupedDomain(plain)
+	g.Extra = withLooseFields(extra, loose)
+	return nil
+}
+
+// cutLooseFields returns the JSON object in data without the looseFields
+// whose values don't fit, and those fields. ok is false if there are none.
+func cutLooseFields(data []byte) (fields []byte, loose map[string]json.RawMessage, ok bool) {
+	var all map[string]json.RawMessage
+	if json.Unmarshal(data, &all) != nil {
+		return data, nil, false
+	}
+	for k, fits := range looseFields {
+		if v, found := all[k]; found && !fits(v) {
+			if loose == nil {
+				loose = make(map[string]json.RawMessage)
+			}
+			loose[k] = v
+			delete(all, k)
+		}
+	}
+	if loose == nil {
+		return data, nil, false
+	}
+	fields, err := json.Marshal(all)
+	if err != nil {
+		return data, nil, false
+	}
+	return fields, loose, true
+}
+
+// withLooseFields adds the loose fields cut by cutLooseFields to extra.
+func withLooseFields(extra, loose map[string]json.RawMessage) map[string]json.RawMessage {
+	if len(loose) == 0 {
+		return extra
+	}
+	if extra == nil {
+		extra = make(map[string]json.RawMessage, len(loose))
+	}
+	maps.Copy(extra, loose)
+	return extra
+}
+
+// MarshalJSON encodes the known fields followed by the fields in Extra.
+func (g GroupedDomain) MarshalJSON() ([]byte, error) {
+	return marshalWithExtra(groupedDomainJSON(g), g.Extra, groupedDomainKeys)
+}
+
+// recordError wraps a decoding error from one of the record UnmarshalJSON
+// methods. Offsets in the wrapped error are relative to the record, so the
+// record's raw bytes are kept to locate it in the full document.
+type recordError struct {
+	err error
+	raw []byte
+}
+
+func (e *recordError) Error() string { return e.err.Error() }
+func (e *recordError) Unwrap() error { return e.err }
+
+// newRecordError wraps err for the record type name, replacing the internal
+// decoding type in type errors with the public one.
+func newRecordError(err error, name string, raw []byte) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		typeErr.Struct = name
+	}
+	return &recordError{err: err, raw: raw}
+}
+
+// jsonKeys returns the JSON object keys used by the exported fields of t.
+func jsonKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		keys[name] = true
+	}
+	return keys
+}
+
+// extraFields returns the members of the JSON object in data whose keys are
+// not in known, or nil if there are none.
+func extraFields(data []byte, known map[string]bool) (map[string]json.RawMessage, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	for k := range all {
+		if known[k] {
+			delete(all, k)
+		}
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return all, nil
+}
+
+// marshalWithExtra marshals v (a JSON object) and appends the extra fields in
+// key order. Extra keys that collide with known fields are dropped when v
+// has a value for them, so the struct value always wins; otherwise they are
+// loose fields (see looseFields) and kept.
+func marshalWithExtra(v any, extra map[string]json.RawMessage, known map[string]bool) ([]byte, error) {
+	out, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return out, err
+	}
+
+	var encoded map[string]json.RawMessage
+	keys := make([]string, 0, len(extra))
+	for k := range extra {
+		if known[k] {
+			if encoded == nil {
+				if err := json.Unmarshal(out, &encoded); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := encoded[k]; ok {
+				continue
+			}
+		}
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	buf.Write(out[:len(out)-1])
+	for _, k := range keys {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(extra[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,128 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDomainRecordExtraRoundTrip verifies unknown fields survive an
+// unmarshal/marshal cycle next to the known ones.
+func TestDomainRecordExtraRoundTrip(t *testing.T) {
+	t.Parallel()
+	in := `{"domain":"a.com","reason":"TAKEN","owner":"alice","price":1200,"meta":{"tags":["x"]}}`
+	var rec DomainRecord
+	if err := json.Unmarshal([]byte(in), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Domain != "a.com" || rec.Reason != ReasonTaken || len(rec.Extra) != 3 {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+
+	out, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"domain":"a.com","reason":"TAKEN","meta":{"tags":["x"]},"owner":"alice","price":1200}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+}
+
+// TestDomainRecordLooseFields keeps a non-integer priority and non-string
+// notes as they were, with the fields left zero, instead of failing.
+func TestDomainRecordLooseFields(t *testing.T) {
+	t.Parallel()
+	in := `{"domain":"a.com","reason":"TAKEN","notes":["call"],"priority":"high"}`
+	var rec DomainRecord
+	if err := json.Unmarshal([]byte(in), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Domain != "a.com" || rec.Reason != ReasonTaken || rec.Priority != 0 || rec.Notes != "" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	out, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"domain":"a.com","reason":"TAKEN","notes":["call"],"priority":"high"}`; string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+
+	var gd GroupedDomain
+	if err := json.Unmarshal([]byte(in), &gd); err != nil {
+		t.Fatal(err)
+	}
+	gd.Priority = 2
+	if out, err := json.Marshal(gd); err != nil || !strings.Contains(string(out), `"priority":2`) || strings.Contains(string(out), `"high"`) {
+		t.Errorf("set priority: %s, %v", out, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"domain":"a.com","attempts":"two"}`), &rec); err == nil {
+		t.Error("expected an error for a non-integer attempts")
+	}
+}
+
+// TestGroupedDomainExtraKnownFieldWins ensures a stale Extra entry cannot
+// shadow a struct field on output.
+func TestGroupedDomainExtraKnownFieldWins(t *testing.T) {
+	t.Parallel()
+	gd := GroupedDomain{
+		Domain: "a.com",
+		Reason: ReasonNoMatch,
+		Extra:  map[string]json.RawMessage{"reason": json.RawMessage(`"TAKEN"`), "owner": json.RawMessage(`"me"`)},
+	}
+	out, err := json.Marshal(gd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"domain":"a.com","reason":"NO_MATCH","owner":"me"}` {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+// TestRunGroupedInput_PreservesExtraFields checks user metadata on unverified
+// records and on already-checked records is kept after a run.
+func TestRunGroupedInput_PreservesExtraFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	raw := `{
+  "available": [{"domain": "kept.com", "reason": "NO_MATCH", "owner": "alice"}],
+  "unverified": [{"domain": "down.com", "notes": "check weekly"}]
+}`
+	var ext ExtendedGroupedData
+	if err := json.Unmarshal([]byte(raw), &ext); err != nil {
+		t.Fatal(err)
+	}
+	cfg := runConfig{whoisServer: "127.0.0.1:1", groupedOutput: true}
+	_, _ = captureOutput(t, func() {
+		if code := runGroupedInput(cfg, path, ext); code != 0 {
+			t.Errorf("exit code %d", code)
+		}
+	})
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"owner": "alice"`, `"notes": "check weekly"`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("output missing %s:\n%s", want, got)
+		}
+	}
+}
+
+// TestMergeGrouped_KeepsExistingExtra verifies a fresh result without extra
+// fields inherits the ones already stored for that domain.
+func TestMergeGrouped_KeepsExistingExtra(t *testing.T) {
+	t.Parallel()
+	notes := map[string]json.RawMessage{"notes": json.RawMessage(`"x"`)}
+	existing := GroupedData{Unavailable: []GroupedDomain{{Domain: "a.com", Reason: ReasonTaken, Extra: notes}}}
+	newest := GroupedData{Available: []GroupedDomain{{Domain: "a.com", Reason: ReasonNoMatch}}}
+
+	got := mergeGrouped(existing, newest)
+	if len(got.Available) != 1 || string(got.Available[0].Extra["notes"]) != `"x"` {
+		t.Errorf("extra fields lost: %+v", got)
+	}
+}
@@ -0,0 +1,86 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// staleError reports available domains whose verdict is too old to act on.
+type staleError struct {
+	domains []string
+	maxAge  time.Duration
+}
+
+func (e *staleError) Error() string {
+	shown := e.domains
+	more := ""
+	if len(shown) > 5 {
+		shown, more = shown[:5], fmt.Sprintf(" and %d more", len(e.domains)-5)
+	}
+	return fmt.Sprintf("%d available domains were checked more than %s ago or have no check time: %s%s",
+		len(e.domains), e.maxAge, strings.Join(shown, ", "), more)
+}
+
+// isStale reports whether gd's verdict is older than maxAge at now. A record
+// without CheckedAt (written before timestamps, or by hand) is stale, since
+// its age is unknown.
+func isStale(gd GroupedDomain, maxAge time.Duration, now time.Time) bool {
+	return gd.CheckedAt.IsZero() || now.Sub(gd.CheckedAt) > maxAge
+}
+
+// checkFreshness returns a *staleError if any available domain in the
+// grouped file at path was checked more than maxAge before now.
+func checkFreshness(path string, maxAge time.Duration, now time.Time) error {
+	data, err := readGroupedFile(path)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for _, gd := range data.Available {
+		if isStale(gd, maxAge, now) {
+			stale = append(stale, gd.Domain)
+		}
+	}
+	if len(stale) > 0 {
+		return &staleError{domains: stale, maxAge: maxAge}
+	}
+	return nil
+}
+
+// recheckStale moves the available domains in the grouped file at path that
+// were checked more than maxAge before now back to "unverified" and runs the
+// file, so they are checked again and the file is rewritten with fresh
+// verdicts. It returns the process exit code.
+func recheckStale(cfg runConfig, path string, maxAge time.Duration, now time.Time) int {
+	if err := CompactGroupedFile(path); err != nil {
+		return fail(cliError{Code: errCodeInputRead, Path: path}, "Error reading pending results for %s: %v", path, err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fail(cliError{Code: errCodeInputRead, Path: path}, "Error reading %s: %v", path, err)
+	}
+	var ext ExtendedGroupedData
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		return fail(cliError{Code: errCodeInputParse, Path: path}, "Error parsing JSON in %s: %v", path, err)
+	}
+	fresh := ext.Available[:0:0]
+	moved := 0
+	for _, gd := range ext.Available {
+		if isStale(gd, maxAge, now) {
+			ext.Unverified = append(ext.Unverified, gd.record())
+			moved++
+		} else {
+			fresh = append(fresh, gd)
+		}
+	}
+	if moved == 0 {
+		return 0
+	}
+	ext.Available = fresh
+	fmt.Fprintf(cfg.status(), "Rechecking %d available domains older than %s...\n", moved, maxAge)
+	cfg.outputFile = ""
+	return runGroupedInput(cfg, path, ext)
+}
@@ -0,0 +1,93 @@
+package talia
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// writeGroupedFixture writes data as a grouped file and returns its path.
+func writeGroupedFixture(t *testing.T, data GroupedData) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestCheckFreshness flags available domains older than the limit or
+// without a check time.
+func TestCheckFreshness(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	path := writeGroupedFixture(t, GroupedData{
+		Available: []GroupedDomain{
+			{Domain: "fresh.com", CheckedAt: now.Add(-10 * time.Minute)},
+			{Domain: "old.com", CheckedAt: now.Add(-2 * time.Hour)},
+			{Domain: "unknown.com"},
+		},
+		Unavailable: []GroupedDomain{{Domain: "taken.com", CheckedAt: now.Add(-48 * time.Hour)}},
+	})
+
+	var stale *staleError
+	if err := checkFreshness(path, time.Hour, now); !errors.As(err, &stale) {
+		t.Fatalf("checkFreshness = %v, want *staleError", err)
+	}
+	if !slices.Equal(stale.domains, []string{"old.com", "unknown.com"}) {
+		t.Errorf("stale = %v", stale.domains)
+	}
+	if err := checkFreshness(path, 3*time.Hour, now); err == nil || !strings.Contains(err.Error(), "unknown.com") {
+		t.Errorf("undated record not stale: %v", err)
+	}
+}
+
+// TestRunCLI_ExportMaxAge refuses to export stale verdicts, and with
+// --recheck-stale checks them again and exports only the ones still free.
+func TestRunCLI_ExportMaxAge(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("old.com", taliatest.Response{Body: taliatest.Registered("old.com")})
+	now := time.Now()
+	path := writeGroupedFixture(t, GroupedData{Available: []GroupedDomain{
+		{Domain: "fresh.com", Status: StatusAvailable, CheckedAt: now},
+		{Domain: "old.com", Status: StatusAvailable, CheckedAt: now.Add(-2 * time.Hour)},
+		{Domain: "still.com", Status: StatusAvailable, CheckedAt: now.Add(-2 * time.Hour)},
+	}})
+	exported := filepath.Join(t.TempDir(), "available.txt")
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--export-available", exported, "--max-age=1h", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "2 available domains") || !strings.Contains(stderr, "--recheck-stale") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if _, err := os.Stat(exported); !os.IsNotExist(err) {
+		t.Errorf("exported despite stale verdicts: %v", err)
+	}
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--export-available", exported, "--max-age=1h", "--recheck-stale", "--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"old.com", "still.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+	raw, _ := os.ReadFile(exported)
+	if got := strings.Fields(string(raw)); !slices.Equal(got, []string{"fresh.com", "still.com"}) {
+		t.Errorf("exported = %v", got)
+	}
+}
@@ -1,97 +1,385 @@
 package talia
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 )
 
 // mergeGrouped merges new grouped results into existing grouped data, deduplicating by domain.
+// A domain appears in at most one bucket; the newest result decides which.
+//
+// Output order is deterministic so that diffs between runs stay small: a domain
+// that stays in the same bucket keeps its existing position (with the newest
+// value), and domains new to a bucket are appended in the order of newest.
 func mergeGrouped(existing, newest GroupedData) GroupedData {
-	domainsAvail := make(map[string]GroupedDomain)
-	for _, gd := range existing.Available {
-		domainsAvail[gd.Domain] = gd
+	type entry struct {
+		bucket string
+		gd     GroupedDomain
 	}
-	domainsUnavail := make(map[string]GroupedDomain)
-	for _, gd := range existing.Unavailable {
-		domainsUnavail[gd.Domain] = gd
+	var entries []entry
+	add := func(src []GroupedDomain, bucket string) {
+		for _, gd := range src {
+			entries = append(entries, entry{bucket, gd})
+		}
+	}
+	addRecords := func(src []DomainRecord) {
+		for _, rec := range src {
+			entries = append(entries, entry{bucketUnverified, rec.grouped()})
+		}
 	}
+	add(existing.Available, bucketAvailable)
+	add(existing.Unavailable, bucketUnavailable)
+	addRecords(existing.Unverified)
+	numExisting := len(entries)
+	add(newest.Available, bucketAvailable)
+	add(newest.Unavailable, bucketUnavailable)
+	addRecords(newest.Unverified)
 
-	for _, gd := range newest.Available {
-		domainsAvail[gd.Domain] = gd
-		delete(domainsUnavail, gd.Domain)
+	// Fresh results usually carry no extra fields, suggestion metadata, or
+	// curation marks; keep what is already recorded for the domain so it survives a re-check.
+	previous := make(map[string]GroupedDomain)
+	for _, e := range entries[:numExisting] {
+		previous[e.gd.Domain] = e.gd
 	}
-	for _, gd := range newest.Unavailable {
-		domainsUnavail[gd.Domain] = gd
-		delete(domainsAvail, gd.Domain)
+	winner := make(map[string]int)
+	for i, e := range entries {
+		winner[e.gd.Domain] = i
 	}
 
 	out := GroupedData{}
-	for _, rec := range domainsAvail {
-		out.Available = append(out.Available, rec)
-	}
-	for _, rec := range domainsUnavail {
-		out.Unavailable = append(out.Unavailable, rec)
+	emitted := make(map[string]bool)
+	for _, e := range entries {
+		domain := e.gd.Domain
+		w := entries[winner[domain]]
+		if emitted[domain] || e.bucket != w.bucket {
+			continue
+		}
+		emitted[domain] = true
+		gd := w.gd
+		if prev, ok := previous[domain]; ok {
+			if gd.Extra == nil {
+				gd.Extra = prev.Extra
+			}
+			if gd.Rationale == "" && gd.Keywords == nil {
+				gd.Rationale, gd.Keywords = prev.Rationale, prev.Keywords
+			}
+			if !gd.Favorite && !gd.Rejected {
+				gd.Favorite, gd.Rejected = prev.Favorite, prev.Rejected
+			}
+			if gd.Notes == "" {
+				gd.Notes = prev.Notes
+			}
+			if gd.Priority == 0 {
+				gd.Priority = prev.Priority
+			}
+		}
+		switch w.bucket {
+		case bucketAvailable:
+			out.Available = append(out.Available, gd)
+		case bucketUnavailable:
+			out.Unavailable = append(out.Unavailable, gd)
+		default:
+			out.Unverified = append(out.Unverified, gd.record())
+		}
 	}
 	return out
 }
 
+// resultBucket reports which grouped bucket a check result belongs in. With
+// retryErrors set, ERROR results go back to unverified so the next run retries
+// them instead of filing them as unavailable.
+func resultBucket(res checkResult, retryErrors bool) string {
+	switch {
+	case res.Avail:
+		return bucketAvailable
+	case retryErrors && res.Reason == ReasonError:
+		return bucketUnverified
+	default:
+		return bucketUnavailable
+	}
+}
+
+// giveUp marks ERROR results that have used up maxAttempts checks as
+// GIVEN_UP, which files them under unavailable even with --retry-errors. A
+// maxAttempts of 0 means no limit.
+func giveUp(results []checkResult, maxAttempts int) {
+	if maxAttempts <= 0 {
+		return
+	}
+	for i := range results {
+		if results[i].Reason == ReasonError && results[i].Attempts >= maxAttempts {
+			results[i].Reason = ReasonGivenUp
+		}
+	}
+}
+
+// addGroupedResult appends res to the matching bucket of data. from is the
+// input record, whose fields a check doesn't produce (see keepInputFields) are
+// carried over unchanged. A domain left unchecked by --run-timeout or
+// --stop-on-available goes back to unverified as it was.
+func addGroupedResult(data *GroupedData, res checkResult, from DomainRecord, retryErrors bool) {
+	if !res.checked() {
+		data.Unverified = append(data.Unverified, from)
+		return
+	}
+	rec := res.record()
+	rec.keepInputFields(from)
+	gd := rec.grouped()
+	switch resultBucket(res, retryErrors) {
+	case bucketAvailable:
+		data.Available = append(data.Available, gd)
+	case bucketUnverified:
+		data.Unverified = append(data.Unverified, gd.record())
+	default:
+		data.Unavailable = append(data.Unavailable, gd)
+	}
+}
+
 // ConvertArrayToGrouped turns an array of DomainRecord into GroupedData.
+// Records never checked (no reason, status, or availability, such as those
+// a run held back) go to unverified rather than unavailable.
 func ConvertArrayToGrouped(arr []DomainRecord) GroupedData {
 	var gd GroupedData
 	for _, rec := range arr {
-		gDom := GroupedDomain{
-			Domain: rec.Domain,
-			Reason: rec.Reason,
-			Log:    rec.Log,
-		}
-		if rec.Available {
-			gd.Available = append(gd.Available, gDom)
-		} else {
-			gd.Unavailable = append(gd.Unavailable, gDom)
+		switch {
+		case rec.Available:
+			gd.Available = append(gd.Available, rec.grouped())
+		case rec.Reason == "" && rec.Status == "":
+			gd.Unverified = append(gd.Unverified, rec)
+		default:
+			gd.Unavailable = append(gd.Unavailable, rec.grouped())
 		}
 	}
 	return gd
 }
 
-// WriteGroupedFile reads an existing grouped JSON (if any), merges new data, and writes back.
-// If the existing file is an array (plain DomainRecord[]), we convert it to grouped before merging.
-func WriteGroupedFile(path string, newest GroupedData) error {
-	if path == "" {
+// groupedAppendThreshold is the grouped-file size above which WriteGroupedFile
+// stops rewriting the whole file and appends new results to a pending log
+// instead. It is a variable so tests can lower it.
+var groupedAppendThreshold int64 = 64 << 20
+
+// groupedCompactRatio controls when the pending log is folded back into the
+// grouped file: once it grows past 1/groupedCompactRatio of the file's size.
+const groupedCompactRatio = 8
+
+// pendingEntry is one line of a grouped file's pending log.
+type pendingEntry struct {
+	Bucket string        `json:"bucket"`
+	Entry  GroupedDomain `json:"entry"`
+}
+
+// Bucket names used in pending logs.
+const (
+	bucketAvailable   = "available"
+	bucketUnavailable = "unavailable"
+	bucketUnverified  = "unverified"
+)
+
+// pendingLogPath returns the path of the append-only log that holds results
+// not yet merged into the grouped file at path.
+func pendingLogPath(path string) string {
+	return path + ".pending"
+}
+
+// readPendingLog reads the pending log for path and returns its entries as
+// GroupedData. Later lines win over earlier ones for the same domain. A missing
+// log yields empty data.
+func readPendingLog(path string) (GroupedData, error) {
+	f, err := os.Open(pendingLogPath(path))
+	if os.IsNotExist(err) {
+		return GroupedData{}, nil
+	}
+	if err != nil {
+		return GroupedData{}, fmt.Errorf("read pending log: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	latest := make(map[string]pendingEntry)
+	var order []string
+	dec := json.NewDecoder(f)
+	for {
+		var pe pendingEntry
+		if err := dec.Decode(&pe); err == io.EOF {
+			break
+		} else if err != nil {
+			return GroupedData{}, fmt.Errorf("parse pending log: %w", err)
+		}
+		if _, seen := latest[pe.Entry.Domain]; !seen {
+			order = append(order, pe.Entry.Domain)
+		}
+		latest[pe.Entry.Domain] = pe
+	}
+
+	var out GroupedData
+	for _, domain := range order {
+		pe := latest[domain]
+		switch pe.Bucket {
+		case bucketAvailable:
+			out.Available = append(out.Available, pe.Entry)
+		case bucketUnverified:
+			out.Unverified = append(out.Unverified, pe.Entry.record())
+		default:
+			out.Unavailable = append(out.Unavailable, pe.Entry)
+		}
+	}
+	return out, nil
+}
+
+// appendPendingLog appends newest to the pending log for path.
+func appendPendingLog(path string, newest GroupedData) error {
+	f, err := os.OpenFile(pendingLogPath(path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open pending log: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, gd := range newest.Available {
+		if err := enc.Encode(pendingEntry{Bucket: bucketAvailable, Entry: gd}); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("append pending log: %w", err)
+		}
+	}
+	for _, gd := range newest.Unavailable {
+		if err := enc.Encode(pendingEntry{Bucket: bucketUnavailable, Entry: gd}); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("append pending log: %w", err)
+		}
+	}
+	for _, rec := range newest.Unverified {
+		if err := enc.Encode(pendingEntry{Bucket: bucketUnverified, Entry: rec.grouped()}); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("append pending log: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("append pending log: %w", err)
+	}
+	return f.Close()
+}
+
+// applyPendingLog folds any pending results for path into ext, so readers see
+// the same data they would after a compaction.
+func applyPendingLog(path string, ext *ExtendedGroupedData) error {
+	pending, err := readPendingLog(path)
+	if err != nil {
+		return err
+	}
+	if len(pending.Available) == 0 && len(pending.Unavailable) == 0 && len(pending.Unverified) == 0 {
 		return nil
 	}
+	merged := mergeGrouped(GroupedData{Available: ext.Available, Unavailable: ext.Unavailable, Unverified: ext.Unverified}, pending)
+	ext.Available = merged.Available
+	ext.Unavailable = merged.Unavailable
+	ext.Unverified = merged.Unverified
+	return nil
+}
 
+// readGroupedFile reads the grouped file at path, including results still in
+// its pending log. If the file is an array (plain DomainRecord[]), it is
+// converted to grouped form. A missing or empty file yields empty data.
+func readGroupedFile(path string) (GroupedData, error) {
 	existing := GroupedData{}
 
 	info, err := os.Stat(path)
 	if err == nil && info.Size() > 0 {
 		if info.IsDir() {
-			return fmt.Errorf("read grouped file: %s is a directory", path)
+			return existing, fmt.Errorf("read grouped file: %s is a directory", path)
 		}
 		raw, err := os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("read grouped file: %w", err)
+			return existing, fmt.Errorf("read grouped file: %w", err)
 		}
 		if err := json.Unmarshal(raw, &existing); err != nil {
 			var arr []DomainRecord
 			if err2 := json.Unmarshal(raw, &arr); err2 == nil {
 				existing = ConvertArrayToGrouped(arr)
 			} else {
-				return fmt.Errorf("parse grouped file: %w", err)
+				return existing, fmt.Errorf("parse grouped file: %w", err)
 			}
 		}
 	} else if err == nil && info.IsDir() {
-		return fmt.Errorf("read grouped file: %s is a directory", path)
+		return existing, fmt.Errorf("read grouped file: %s is a directory", path)
+	}
+
+	pending, err := readPendingLog(path)
+	if err != nil {
+		return existing, err
+	}
+	if len(pending.Available) > 0 || len(pending.Unavailable) > 0 || len(pending.Unverified) > 0 {
+		existing = mergeGrouped(existing, pending)
+	}
+	return existing, nil
+}
+
+// WriteGroupedFile reads an existing grouped JSON (if any), merges new data, and writes back.
+// If the existing file is an array (plain DomainRecord[]), we convert it to grouped before merging.
+//
+// Grouped files larger than groupedAppendThreshold are not rewritten on every
+// call: new results are appended to a pending log next to the file, and the
+// log is compacted into the file once it grows past a fraction of the file's
+// size. Readers inside Talia apply the pending log transparently; use
+// CompactGroupedFile to fold it in explicitly.
+func WriteGroupedFile(path string, newest GroupedData) error {
+	if path == "" {
+		return nil
 	}
 
-	merged := mergeGrouped(existing, newest)
-	out, err := json.MarshalIndent(merged, "", "  ")
+	if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Size() >= groupedAppendThreshold {
+		if err := appendPendingLog(path, newest); err != nil {
+			return err
+		}
+		pendingInfo, err := os.Stat(pendingLogPath(path))
+		if err != nil {
+			return fmt.Errorf("stat pending log: %w", err)
+		}
+		if pendingInfo.Size()*groupedCompactRatio < info.Size() {
+			return nil
+		}
+		return CompactGroupedFile(path)
+	}
+
+	existing, err := readGroupedFile(path)
+	if err != nil {
+		return err
+	}
+	return writeGrouped(path, mergeGrouped(existing, newest))
+}
+
+// CompactGroupedFile merges the pending log for the grouped file at path into
+// the file itself and removes the log. It is a no-op when there is no log.
+func CompactGroupedFile(path string) error {
+	if _, err := os.Stat(pendingLogPath(path)); os.IsNotExist(err) {
+		return nil
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		return err
+	}
+	return writeGrouped(path, data)
+}
+
+// writeGrouped writes data to path and clears the pending log, whose entries
+// data is expected to already include.
+func writeGrouped(path string, data GroupedData) error {
+	out, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshal grouped data: %w", err)
 	}
-	if err := os.WriteFile(path, out, 0644); err != nil {
+	if err := writeFileAtomic(path, out, 0644); err != nil {
 		return fmt.Errorf("write grouped file: %w", err)
 	}
-	return nil
+	return clearPendingLog(path)
 }
 
+// clearPendingLog removes the pending log for path. Callers use it after
+// rewriting a grouped file from data that already includes the log.
+func clearPendingLog(path string) error {
+	if err := os.Remove(pendingLogPath(path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove pending log: %w", err)
+	}
+	return nil
+}
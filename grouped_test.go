@@ -0,0 +1,344 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withAppendThreshold lowers groupedAppendThreshold for the duration of a test.
+func withAppendThreshold(t *testing.T, n int64) {
+	old := groupedAppendThreshold
+	groupedAppendThreshold = n
+	t.Cleanup(func() { groupedAppendThreshold = old })
+}
+
+// TestWriteGroupedFile_AppendsPendingForLargeFiles verifies large grouped files
+// are not rewritten and that readers still see the appended results.
+func TestWriteGroupedFile_AppendsPendingForLargeFiles(t *testing.T) {
+	withAppendThreshold(t, 1)
+	path := filepath.Join(t.TempDir(), "grouped.json")
+
+	// Large enough that a single appended line stays under the compaction ratio.
+	base := GroupedData{Unavailable: []GroupedDomain{{Domain: "a.com", Reason: ReasonTaken}}}
+	for i := range 30 {
+		base.Unavailable = append(base.Unavailable, GroupedDomain{Domain: fmt.Sprintf("taken%d.com", i), Reason: ReasonTaken})
+	}
+	raw, _ := json.MarshalIndent(base, "", "  ")
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newest := GroupedData{Available: []GroupedDomain{{Domain: "a.com", Reason: ReasonNoMatch}}}
+	if err := WriteGroupedFile(path, newest); err != nil {
+		t.Fatalf("WriteGroupedFile: %v", err)
+	}
+
+	after, _ := os.ReadFile(path)
+	if string(after) != string(raw) {
+		t.Error("grouped file was rewritten; expected append to pending log")
+	}
+	if _, err := os.Stat(pendingLogPath(path)); err != nil {
+		t.Fatalf("pending log missing: %v", err)
+	}
+
+	got, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatalf("readGroupedFile: %v", err)
+	}
+	if len(got.Available) != 1 || got.Available[0].Domain != "a.com" {
+		t.Errorf("pending result not applied: %+v", got.Available)
+	}
+	if len(got.Unavailable) != 30 {
+		t.Errorf("want 30 unavailable after move, got %d", len(got.Unavailable))
+	}
+
+	exported := filepath.Join(t.TempDir(), "avail.txt")
+	if n, err := exportAvailableDomains(path, exported); err != nil || n != 1 {
+		t.Errorf("export saw %d domains (err %v), want 1", n, err)
+	}
+}
+
+// TestWriteGroupedFile_CompactsPendingLog verifies the pending log is folded
+// into the file once it grows large relative to the file.
+func TestWriteGroupedFile_CompactsPendingLog(t *testing.T) {
+	withAppendThreshold(t, 1)
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	if err := os.WriteFile(path, []byte(`{"available":[],"unavailable":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newest := GroupedData{Available: []GroupedDomain{{Domain: "x.com", Reason: ReasonNoMatch}}}
+	if err := WriteGroupedFile(path, newest); err != nil {
+		t.Fatalf("WriteGroupedFile: %v", err)
+	}
+	if _, err := os.Stat(pendingLogPath(path)); !os.IsNotExist(err) {
+		t.Errorf("expected pending log to be compacted away, stat err = %v", err)
+	}
+
+	var got GroupedData
+	raw, _ := os.ReadFile(path)
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Available) != 1 || got.Available[0].Domain != "x.com" {
+		t.Errorf("compacted file missing result: %s", raw)
+	}
+}
+
+// TestCompactGroupedFile covers explicit compaction and the no-log case.
+func TestCompactGroupedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	if err := CompactGroupedFile(path); err != nil {
+		t.Fatalf("compact without log: %v", err)
+	}
+
+	if err := appendPendingLog(path, GroupedData{Unavailable: []GroupedDomain{{Domain: "y.com", Reason: ReasonTaken}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CompactGroupedFile(path); err != nil {
+		t.Fatalf("CompactGroupedFile: %v", err)
+	}
+	got, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Unavailable) != 1 || got.Unavailable[0].Domain != "y.com" {
+		t.Errorf("unexpected data after compaction: %+v", got)
+	}
+	if _, err := os.Stat(pendingLogPath(path)); !os.IsNotExist(err) {
+		t.Error("pending log not removed")
+	}
+}
+
+// TestReadPendingLog_ParseError ensures a corrupt log is reported.
+func TestReadPendingLog_ParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	if err := os.WriteFile(pendingLogPath(path), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readPendingLog(path); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+// TestMergeGrouped_Unverified verifies the newest result decides a domain's
+// bucket, including moves into and out of unverified.
+func TestMergeGrouped_Unverified(t *testing.T) {
+	t.Parallel()
+	existing := GroupedData{
+		Unavailable: []GroupedDomain{{Domain: "flaky.com", Reason: ReasonError}},
+		Unverified:  []DomainRecord{{Domain: "retry.com", Reason: ReasonError}},
+	}
+	newest := GroupedData{
+		Available:  []GroupedDomain{{Domain: "retry.com", Reason: ReasonNoMatch}},
+		Unverified: []DomainRecord{{Domain: "flaky.com", Reason: ReasonError}},
+	}
+	got := mergeGrouped(existing, newest)
+	if len(got.Available) != 1 || got.Available[0].Domain != "retry.com" {
+		t.Errorf("available = %+v", got.Available)
+	}
+	if len(got.Unavailable) != 0 {
+		t.Errorf("unavailable = %+v", got.Unavailable)
+	}
+	if len(got.Unverified) != 1 || got.Unverified[0].Domain != "flaky.com" {
+		t.Errorf("unverified = %+v", got.Unverified)
+	}
+}
+
+// TestMergeGrouped_KeepsSuggestionMetadata keeps a domain's rationale and
+// keywords when a re-check result without them wins.
+func TestMergeGrouped_KeepsSuggestionMetadata(t *testing.T) {
+	t.Parallel()
+	existing := GroupedData{Available: []GroupedDomain{{Domain: "a.com", Rationale: "Short.", Keywords: []string{"a"}}}}
+	newest := GroupedData{Unavailable: []GroupedDomain{{Domain: "a.com", Reason: ReasonTaken}}}
+	got := mergeGrouped(existing, newest)
+	if len(got.Unavailable) != 1 || got.Unavailable[0].Rationale != "Short." || len(got.Unavailable[0].Keywords) != 1 {
+		t.Errorf("unavailable = %+v", got.Unavailable)
+	}
+}
+
+// TestRunGroupedInput_RetryErrors verifies errored checks stay in unverified
+// with --retry-errors and land in unavailable without it.
+func TestRunGroupedInput_RetryErrors(t *testing.T) {
+	for _, retry := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "grouped.json")
+		ext := ExtendedGroupedData{Unverified: []DomainRecord{{Domain: "down.com"}}}
+		cfg := runConfig{whoisServer: "127.0.0.1:1", groupedOutput: true, retryErrors: retry}
+		_, _ = captureOutput(t, func() {
+			if code := runGroupedInput(cfg, path, ext); code != 0 {
+				t.Errorf("exit code %d", code)
+			}
+		})
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out ExtendedGroupedData
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if retry {
+			if len(out.Unverified) != 1 || out.Unverified[0].Reason != ReasonError || len(out.Unavailable) != 0 {
+				t.Errorf("retry-errors: unexpected output %s", raw)
+			}
+		} else if len(out.Unavailable) != 1 || len(out.Unverified) != 0 {
+			t.Errorf("default: unexpected output %s", raw)
+		}
+	}
+}
+
+// TestRunGroupedInput_MaxAttempts counts attempts across runs and gives up
+// on a domain once its check has failed max-attempts times.
+func TestRunGroupedInput_MaxAttempts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	if err := os.WriteFile(path, []byte(`{"unverified":[{"domain":"down.com"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := runConfig{whoisServer: "127.0.0.1:1", groupedOutput: true, retryErrors: true, maxAttempts: 2}
+
+	var out ExtendedGroupedData
+	for run := 1; run <= 2; run++ {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ext ExtendedGroupedData
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = captureOutput(t, func() {
+			if code := runGroupedInput(cfg, path, ext); code != 0 {
+				t.Errorf("run %d: exit code %d", run, code)
+			}
+		})
+		raw, err = os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = ExtendedGroupedData{}
+		if err := json.Unmarshal(raw, &out); err != nil {
+			t.Fatal(err)
+		}
+		if run == 1 && (len(out.Unverified) != 1 || out.Unverified[0].Attempts != 1 || out.Unverified[0].Reason != ReasonError) {
+			t.Fatalf("run 1: unexpected output %s", raw)
+		}
+	}
+	if len(out.Unverified) != 0 || len(out.Unavailable) != 1 {
+		t.Fatalf("run 2: unverified %+v, unavailable %+v", out.Unverified, out.Unavailable)
+	}
+	if gd := out.Unavailable[0]; gd.Reason != ReasonGivenUp || gd.Status != StatusUnknown || gd.Attempts != 2 {
+		t.Errorf("given up record = %+v", gd)
+	}
+}
+
+// TestCleanSuggestionsFileKeepsAttempts keeps the attempt count and last
+// error of retried records through --clean.
+func TestCleanSuggestionsFileKeepsAttempts(t *testing.T) {
+	t.Parallel()
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "Retry.com", Reason: ReasonError, Status: StatusUnknown, Attempts: 2}}})
+	if _, err := cleanSuggestionsFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := data.Unverified[0]; rec.Domain != "retry.com" || rec.Attempts != 2 || rec.Reason != ReasonError || rec.Status != StatusUnknown {
+		t.Errorf("unverified = %+v", data.Unverified)
+	}
+}
+
+// TestMergeFilesKeepsAttempts keeps the attempt count and last error of
+// retried records through a merge, so shard outputs recombine with their
+// --max-attempts progress.
+func TestMergeFilesKeepsAttempts(t *testing.T) {
+	t.Parallel()
+	shard := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "X.com", Reason: ReasonError, Status: StatusUnknown, Attempts: 2}}})
+	output := filepath.Join(t.TempDir(), "merged.json")
+	if _, err := mergeFiles(output, []string{shard}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := readGroupedFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Unverified) != 1 {
+		t.Fatalf("unverified = %+v", data.Unverified)
+	}
+	if rec := data.Unverified[0]; rec.Domain != "x.com" || rec.Attempts != 2 || rec.Reason != ReasonError || rec.Status != StatusUnknown {
+		t.Errorf("unverified = %+v", rec)
+	}
+}
+
+// TestRunCLI_MaxAttemptsRequiresRetryErrors rejects --max-attempts alone.
+func TestRunCLI_MaxAttemptsRequiresRetryErrors(t *testing.T) {
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--max-attempts=3", "domains.json"}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "requires --retry-errors") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestMergeGrouped_DeterministicOrder verifies domains keep their position
+// when they stay in a bucket and new ones are appended in result order.
+func TestMergeGrouped_DeterministicOrder(t *testing.T) {
+	t.Parallel()
+	existing := GroupedData{
+		Available:   []GroupedDomain{{Domain: "a.com"}, {Domain: "b.com"}, {Domain: "c.com"}},
+		Unavailable: []GroupedDomain{{Domain: "x.com"}, {Domain: "y.com"}},
+	}
+	newest := GroupedData{
+		Available:   []GroupedDomain{{Domain: "z.com"}, {Domain: "y.com"}, {Domain: "b.com", Reason: ReasonNoMatch}},
+		Unavailable: []GroupedDomain{{Domain: "a.com"}},
+	}
+
+	for range 20 {
+		got := mergeGrouped(existing, newest)
+		var avail, unavail []string
+		for _, gd := range got.Available {
+			avail = append(avail, gd.Domain)
+		}
+		for _, gd := range got.Unavailable {
+			unavail = append(unavail, gd.Domain)
+		}
+		if fmt.Sprint(avail) != "[b.com c.com z.com y.com]" || fmt.Sprint(unavail) != "[x.com a.com]" {
+			t.Fatalf("available=%v unavailable=%v", avail, unavail)
+		}
+		if got.Available[0].Reason != ReasonNoMatch {
+			t.Errorf("b.com not updated in place: %+v", got.Available[0])
+		}
+	}
+}
+
+// TestRunDomainArray_GroupedOverwriteMerges verifies grouped output without
+// --output-file keeps results already stored in the input file.
+func TestRunDomainArray_GroupedOverwriteMerges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	prior := `{"available":[],"unavailable":[{"domain":"old.com","reason":"TAKEN"}]}`
+	if err := os.WriteFile(path, []byte(prior), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := runConfig{whoisServer: "127.0.0.1:1", groupedOutput: true}
+	_, _ = captureOutput(t, func() {
+		if code := runDomainArray(cfg, path, []DomainRecord{{Domain: "new.com"}}); code != 0 {
+			t.Errorf("exit code %d", code)
+		}
+	})
+
+	got, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Unavailable) != 2 || got.Unavailable[0].Domain != "old.com" || got.Unavailable[1].Domain != "new.com" {
+		t.Errorf("prior results lost: %+v", got)
+	}
+}
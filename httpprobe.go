@@ -0,0 +1,115 @@
+package talia
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultHTTPProbeURL is the address --http-probe requests; "{domain}" is
+// replaced with the domain.
+const defaultHTTPProbeURL = "http://{domain}/"
+
+// httpProbeTimeout bounds each --http-probe request.
+const httpProbeTimeout = 5 * time.Second
+
+// httpProbe is the outcome of a --http-probe request: the status code and
+// redirect target of the first response, or why there was none.
+type httpProbe struct {
+	Status   int
+	Redirect string
+	Error    string
+}
+
+// probeHTTP sends a HEAD request to the address urlTemplate gives for
+// domain, or a GET if the server doesn't allow HEAD. Redirects are recorded,
+// not followed.
+func probeHTTP(client *http.Client, urlTemplate, domain string) httpProbe {
+	u := strings.ReplaceAll(urlTemplate, "{domain}", url.PathEscape(domain))
+	resp, err := client.Head(u)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		_ = resp.Body.Close()
+		resp, err = client.Get(u)
+	}
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return httpProbe{Error: err.Error()}
+	}
+	_ = resp.Body.Close()
+	p := httpProbe{Status: resp.StatusCode}
+	if loc, err := resp.Location(); err == nil {
+		p.Redirect = loc.String()
+	}
+	return p
+}
+
+// addHTTPProbes probes every taken domain and reports how many have a
+// working site on cfg's status output.
+func addHTTPProbes(results []checkResult, cfg runConfig) {
+	client := &http.Client{
+		Timeout: httpProbeTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	probed, live := 0, 0
+	for i, res := range results {
+		if res.Reason != ReasonTaken {
+			continue
+		}
+		results[i].HTTP = probeHTTP(client, cfg.httpProbeURL, res.Domain)
+		probed++
+		if !siteDead(results[i].HTTP.Status, results[i].HTTP.Error) {
+			live++
+		}
+	}
+	if probed > 0 {
+		fmt.Fprintf(cfg.status(), "HTTP probe: %d of %d taken domains have a working site\n", live, probed)
+	}
+}
+
+// siteDead reports whether a probe found no working site: the request
+// failed or the server errored.
+func siteDead(status int, probeErr string) bool {
+	return probeErr != "" || status >= http.StatusInternalServerError
+}
+
+// isDeadSite reports whether rec is a taken domain whose --http-probe found
+// no working site, a registration that may be worth approaching.
+func isDeadSite(rec DomainRecord) bool {
+	probed := rec.HTTPStatus != 0 || rec.HTTPError != ""
+	return probed && !rec.Available && siteDead(rec.HTTPStatus, rec.HTTPError)
+}
+
+// httpProbeFlags are the --http-probe flags of file mode and "talia check".
+type httpProbeFlags struct {
+	enabled bool
+	url     string
+}
+
+// addHTTPProbeFlags registers the HTTP probe flags on fs.
+func addHTTPProbeFlags(fs *flag.FlagSet) *httpProbeFlags {
+	f := &httpProbeFlags{}
+	fs.BoolVar(&f.enabled, "http-probe", false, "Request the web site of taken domains and store the HTTP status and redirect target, or why it failed")
+	fs.StringVar(&f.url, "http-probe-url", defaultHTTPProbeURL, "Address requested by --http-probe; {domain} is replaced with the domain")
+	return f
+}
+
+// apply copies the flags to cfg.
+func (f *httpProbeFlags) apply(cfg *runConfig) error {
+	if !f.enabled {
+		return nil
+	}
+	if !strings.Contains(f.url, "{domain}") {
+		return fmt.Errorf("--http-probe-url must contain {domain}")
+	}
+	cfg.httpProbeURL = f.url
+	return nil
+}
@@ -0,0 +1,90 @@
+package talia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProbeHTTP records the first response without following redirects and
+// falls back to GET when HEAD isn't allowed.
+func TestProbeHTTP(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved.com":
+			http.Redirect(w, r, "https://www.moved.com/", http.StatusMovedPermanently)
+		case "/gethead.com":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/broken.com":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	cases := map[string]httpProbe{
+		"moved.com":   {Status: http.StatusMovedPermanently, Redirect: "https://www.moved.com/"},
+		"gethead.com": {Status: http.StatusOK},
+		"broken.com":  {Status: http.StatusBadGateway},
+	}
+	for domain, want := range cases {
+		if got := probeHTTP(client, srv.URL+"/{domain}", domain); got != want {
+			t.Errorf("probeHTTP(%s) = %+v, want %+v", domain, got, want)
+		}
+	}
+
+	if got := probeHTTP(&http.Client{Timeout: time.Second}, "http://127.0.0.1:1/{domain}", "down.com"); got.Error == "" || got.Status != 0 {
+		t.Errorf("unreachable probe = %+v", got)
+	}
+}
+
+// TestIsDeadSite flags probed taken domains without a working site.
+func TestIsDeadSite(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		rec  DomainRecord
+		want bool
+	}{
+		{DomainRecord{HTTPError: "no such host"}, true},
+		{DomainRecord{HTTPStatus: 503}, true},
+		{DomainRecord{HTTPStatus: 200}, false},
+		{DomainRecord{HTTPStatus: 301, HTTPRedirect: "https://x.com/"}, false},
+		{DomainRecord{}, false},
+	}
+	for _, c := range cases {
+		if got := isDeadSite(c.rec); got != c.want {
+			t.Errorf("isDeadSite(%+v) = %v, want %v", c.rec, got, c.want)
+		}
+	}
+	if row := reportRow(DomainRecord{Domain: "a.com", HTTPError: "timeout"}, time.Now()); row[5] != "dead" {
+		t.Errorf("FLAGS = %q", row[5])
+	}
+}
+
+// TestRunCheckCommandHTTPProbe stores the probe result of taken domains.
+func TestRunCheckCommandHTTPProbe(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: TAKEN.COM\r\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var code int
+	stdout, stderr := captureOutput(t, func() {
+		code = RunCLI([]string{"check", "--whois=" + addr, "--sleep=0s", "--format=json", "--http-probe", "--http-probe-url=" + srv.URL + "/{domain}", "--dead-site", "taken.com"})
+	})
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"httpStatus": 503`) {
+		t.Errorf("httpStatus missing:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "HTTP probe: 0 of 1 taken domains have a working site") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
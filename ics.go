@@ -0,0 +1,95 @@
+package talia
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultICSAlarms are the days before an expiry at which calendar alarms
+// fire, for --ics-alarms.
+const defaultICSAlarms = "30,7,1"
+
+// parseICSAlarms parses a comma-separated list of whole days, e.g. "30,7,1".
+func parseICSAlarms(s string) ([]int, error) {
+	var days []int
+	for _, item := range splitList(s) {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid --ics-alarms entry %q: want whole days, e.g. 30,7,1", item)
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+// writeICS writes an iCalendar (RFC 5545) file with an all-day event on the
+// expiration date of each taken record that has one, and a display alarm
+// the given number of days before each. Records without an expiration date
+// are skipped.
+func writeICS(w io.Writer, records []DomainRecord, alarmDays []int, now time.Time) error {
+	var b strings.Builder
+	line := func(s string) { b.WriteString(foldICSLine(s)) }
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//sustanza//talia//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:Talia domain expiries")
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, rec := range records {
+		if rec.ExpiresAt.IsZero() || rec.Available {
+			continue
+		}
+		day := rec.ExpiresAt.UTC()
+		line("BEGIN:VEVENT")
+		line("UID:" + rec.Domain + "-expiry@talia")
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + day.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeICSText(rec.Domain+" expires"))
+		desc := "Expires " + day.Format(time.RFC3339)
+		if rec.Registrar != "" {
+			desc += "\nRegistrar: " + rec.Registrar
+		}
+		if len(rec.EPPStatus) > 0 {
+			desc += "\nStatus: " + strings.Join(rec.EPPStatus, ", ")
+		}
+		line("DESCRIPTION:" + escapeICSText(desc))
+		for _, days := range alarmDays {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line(fmt.Sprintf("TRIGGER:-P%dD", days))
+			line("DESCRIPTION:" + escapeICSText(fmt.Sprintf("%s expires in %d days", rec.Domain, days)))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeICSText escapes an iCalendar TEXT value.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICSLine terminates s with CRLF, folding it into lines of at most 75
+// octets as RFC 5545 requires, without splitting a UTF-8 sequence.
+func foldICSLine(s string) string {
+	var b strings.Builder
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	b.WriteString(s + "\r\n")
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteICS writes one event per expiring taken domain with its alarms,
+// escaped and folded per RFC 5545.
+func TestWriteICS(t *testing.T) {
+	t.Parallel()
+	records := []DomainRecord{
+		{Domain: "a.com", Status: StatusTaken, ExpiresAt: time.Date(2027, 3, 1, 12, 0, 0, 0, time.UTC), Registrar: "Example, Inc.; " + strings.Repeat("x", 80)},
+		{Domain: "b.com", Status: StatusTaken},                                                     // no expiry
+		{Domain: "c.com", Available: true, ExpiresAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}, // stale
+	}
+	var b strings.Builder
+	if err := writeICS(&b, records, []int{7, 1}, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:a.com-expiry@talia\r\n",
+		"DTSTAMP:20260102T030405Z\r\n",
+		"DTSTART;VALUE=DATE:20270301\r\nDTEND;VALUE=DATE:20270302\r\n",
+		"TRIGGER:-P7D\r\n",
+		"TRIGGER:-P1D\r\n",
+		`Registrar: Example\, Inc.\; x`,
+		"\r\n x",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VEVENT") != 1 {
+		t.Errorf("want one event:\n%s", out)
+	}
+	for line := range strings.SplitSeq(out, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	if _, err := parseICSAlarms("7,x"); err == nil {
+		t.Error("expected error for non-numeric alarm")
+	}
+}
+
+// TestRunReportCommandICS prints a calendar from a grouped file.
+func TestRunReportCommandICS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	data := `{"available":[],"unavailable":[{"domain":"a.com","reason":"TAKEN","status":"taken","expiresAt":"2027-03-01T00:00:00Z"}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"report", "--ics", "--ics-alarms=14", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "SUMMARY:a.com expires") || !strings.Contains(stdout, "TRIGGER:-P14D") {
+		t.Errorf("stdout = %q", stdout)
+	}
+}
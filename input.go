@@ -0,0 +1,68 @@
+package talia
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxRemoteInputBytes caps the size of an input list fetched over HTTP.
+const maxRemoteInputBytes = 256 << 20
+
+// isURL reports whether path is an HTTP(S) URL rather than a file path.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// fetchInput downloads the input list at url, sending auth (if any) as the
+// Authorization header.
+func fetchInput(client httpDoer, url, auth string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteInputBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > maxRemoteInputBytes {
+		return nil, fmt.Errorf("response is larger than %d MiB", maxRemoteInputBytes>>20)
+	}
+	return raw, nil
+}
+
+// textListRecords parses a plain-text list, one domain per line, into
+// records. Blank lines and lines starting with '#' are skipped, and brace
+// patterns such as get{app,kit}.{com,io} are expanded (see expandBraces).
+func textListRecords(raw []byte) ([]DomainRecord, error) {
+	var records []DomainRecord
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains, err := expandBraces(strings.ToLower(line))
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domains {
+			records = append(records, DomainRecord{Domain: d})
+		}
+	}
+	return records, sc.Err()
+}
@@ -0,0 +1,75 @@
+package talia
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunCLIInputURL checks JSON and plain-text lists fetched over HTTP,
+// the Authorization header, and the --output-file requirement.
+func TestRunCLIInputURL(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/list.json":
+			_, _ = io.WriteString(w, `{"unverified":[{"domain":"a.com","owner":"ops"}]}`)
+		case "/list.txt":
+			_, _ = io.WriteString(w, "# watchlist\nA.com\n\nb.com\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	whois := startWhoisServer(t, "No match for domain\n")
+	out := filepath.Join(t.TempDir(), "out.json")
+
+	t.Setenv("TALIA_INPUT_AUTH", "Bearer secret")
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", "--output-file=" + out, srv.URL + "/list.json"}); code != 0 {
+			t.Errorf("json: exit %d", code)
+		}
+	})
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	var ext ExtendedGroupedData
+	raw, _ := os.ReadFile(out)
+	if err := json.Unmarshal(raw, &ext); err != nil || len(ext.Available) != 1 || string(ext.Available[0].Extra["owner"]) != `"ops"` {
+		t.Errorf("json output = %s (%v)", raw, err)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", "--input-auth=Token x", "--output-file=" + out, srv.URL + "/list.txt"}); code != 0 {
+			t.Errorf("text: exit %d", code)
+		}
+	})
+	if gotAuth != "Token x" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	var recs []DomainRecord
+	raw, _ = os.ReadFile(out)
+	if err := json.Unmarshal(raw, &recs); err != nil || len(recs) != 2 || recs[0].Domain != "a.com" || !recs[1].Available {
+		t.Errorf("text output = %s (%v)", raw, err)
+	}
+
+	for _, args := range [][]string{
+		{srv.URL + "/list.json"},
+		{"--output-file=" + out, srv.URL + "/missing"},
+	} {
+		_, stderr := captureOutput(t, func() {
+			if code := RunCLI(append([]string{"--whois=" + whois}, args...)); code != 1 {
+				t.Errorf("%v: exit %d, want 1", args, code)
+			}
+		})
+		if !strings.Contains(stderr, "--output-file") && !strings.Contains(stderr, "404") {
+			t.Errorf("%v: stderr = %q", args, stderr)
+		}
+	}
+}
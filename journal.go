@@ -0,0 +1,268 @@
+package talia
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sync"
+	"time"
+)
+
+// journalMaxAge is how old a journal's run may be for its results to be
+// reused: past that, domains may well have changed hands and are checked
+// again.
+const journalMaxAge = 24 * time.Hour
+
+// journalPath returns the path of the write-ahead journal for an output file.
+func journalPath(path string) string {
+	return path + ".journal"
+}
+
+// journal is an append-only log of completed WHOIS checks for a run that
+// writes its results to one file. Each result is appended and synced as soon
+// as it is known, so a run cut short by a crash or power loss can pick up
+// where it left off instead of checking everything again; the file itself is
+// still rewritten only once, at the end. The first line is a journalHeader.
+type journal struct {
+	path   string
+	header journalHeader
+
+	mu       sync.Mutex
+	f        *os.File
+	replayed map[string]checkResult
+	warnOnce sync.Once
+}
+
+// journalHeader describes the run that started a journal, so its results
+// are only reused by a run that would have made the same queries.
+type journalHeader struct {
+	WhoisServer  string       `json:"whoisServer,omitempty"`
+	QueryFormats queryFormats `json:"queryFormats,omitempty"`
+	Started      time.Time    `json:"started"`
+}
+
+// matches reports whether a run described by h may reuse results journaled
+// under j at now.
+func (j journalHeader) matches(h journalHeader, now time.Time) bool {
+	return !j.Started.IsZero() && now.Sub(j.Started) <= journalMaxAge &&
+		j.WhoisServer == h.WhoisServer && maps.Equal(j.QueryFormats, h.QueryFormats)
+}
+
+// openJournal opens the journal for the run that writes output, described by
+// h. A journal left behind by an interrupted run is replayed if it is newer
+// than output (or output doesn't exist), was started under the same WHOIS
+// server and query formats, and is at most journalMaxAge old: its results
+// are reused for the same domains. Any other journal is discarded; an older
+// one belongs to a run whose results did reach output.
+func openJournal(output string, h journalHeader) (*journal, error) {
+	j := &journal{path: journalPath(output), header: h}
+	replay := false
+	if jinfo, err := os.Stat(j.path); err == nil {
+		oinfo, err := os.Stat(output)
+		replay = err != nil || jinfo.ModTime().After(oinfo.ModTime())
+	}
+
+	flags := os.O_CREATE | os.O_RDWR
+	if !replay {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(j.path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open journal: %w", err)
+	}
+	j.f = f
+	if replay {
+		if err := j.replay(); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	if j.replayed == nil {
+		if err := j.start(); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// start empties the journal and writes j.header as its first line.
+func (j *journal) start() error {
+	if err := j.f.Truncate(0); err != nil {
+		return fmt.Errorf("truncate journal: %w", err)
+	}
+	if _, err := j.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek journal: %w", err)
+	}
+	line, err := json.Marshal(j.header)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+// replay reads the results already in the journal if its header matches
+// j.header, keeping the journal's own header; otherwise it leaves j.replayed
+// nil. A torn final line from an interrupted write, and anything after it,
+// is cut off so appends start on a clean line.
+func (j *journal) replay() error {
+	r := bufio.NewReader(j.f)
+	first, err := r.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("read journal: %w", err)
+	}
+	var h journalHeader
+	if json.Unmarshal(bytes.TrimSpace(first), &h) != nil || !h.matches(j.header, j.header.Started) {
+		return nil
+	}
+	j.header = h
+	j.replayed = make(map[string]checkResult)
+	valid := int64(len(first))
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read journal: %w", err)
+		}
+		var res checkResult
+		if json.Unmarshal(bytes.TrimSpace(line), &res) != nil || res.Domain == "" {
+			break
+		}
+		j.replayed[res.Domain] = res
+		valid += int64(len(line))
+	}
+	if err := j.f.Truncate(valid); err != nil {
+		return fmt.Errorf("truncate journal: %w", err)
+	}
+	if _, err := j.f.Seek(valid, io.SeekStart); err != nil {
+		return fmt.Errorf("seek journal: %w", err)
+	}
+	return nil
+}
+
+// append records res and syncs it to disk.
+func (j *journal) append(res checkResult) error {
+	line, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// reportReuse prints how many of the interrupted run's results were reused
+// and how long ago that run started, if any were.
+func (j *journal) reportReuse(w io.Writer, reused int, now time.Time) {
+	if reused == 0 {
+		return
+	}
+	age := now.Sub(j.header.Started).Round(time.Second)
+	fmt.Fprintf(w, "Journal: reusing %d results from an interrupted run started %s ago (%s)\n", reused, age, j.path)
+}
+
+// reuse returns the result replayed for domain, if any. A nil journal has
+// none.
+func (j *journal) reuse(domain string) (checkResult, bool) {
+	if j == nil {
+		return checkResult{}, false
+	}
+	res, ok := j.replayed[domain]
+	return res, ok
+}
+
+// record appends res, warning once if the journal can't be written. A nil
+// journal is a no-op.
+func (j *journal) record(res checkResult) {
+	if j == nil {
+		return
+	}
+	if err := j.append(res); err != nil {
+		j.warnOnce.Do(func() { fmt.Fprintf(os.Stderr, "Warning: writing journal %s: %v\n", j.path, err) })
+	}
+}
+
+// check runs the WHOIS phase for domains with cfg, reusing replayed results
+// and journaling new ones as they complete. Results are in input order.
+func (j *journal) check(domains []string, cfg runConfig) []checkResult {
+	results := make([]checkResult, len(domains))
+	var todo []string
+	var todoIdx []int
+	for i, domain := range domains {
+		if res, ok := j.reuse(domain); ok {
+			results[i] = res
+			cfg.limits.goal.record(res)
+			continue
+		}
+		todo = append(todo, domain)
+		todoIdx = append(todoIdx, i)
+	}
+	j.reportReuse(cfg.status(), len(domains)-len(todo), cfg.timeSource().Now())
+	if len(todo) == 0 {
+		return results
+	}
+
+	for k, res := range checkDomainsWhoisWith(todo, cfg, j.record) {
+		results[todoIdx[k]] = res
+	}
+	return results
+}
+
+// close closes the journal, keeping it on disk for a later replay. A nil
+// journal is a no-op, as is closing twice.
+func (j *journal) close() {
+	if j != nil {
+		_ = j.f.Close()
+	}
+}
+
+// remove closes and deletes the journal once the run's results are safely in
+// the output file.
+func (j *journal) remove() error {
+	j.close()
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove journal: %w", err)
+	}
+	return nil
+}
+
+// startJournal opens the journal for output into cfg. Journaling is best
+// effort: if it can't be opened the run goes ahead without one. Read-only
+// and --no-journal runs keep none.
+func startJournal(cfg *runConfig, output string) {
+	if cfg.readOnly || cfg.noJournal {
+		return
+	}
+	j, err := openJournal(output, journalHeader{
+		WhoisServer:  cfg.whoisServer,
+		QueryFormats: cfg.queryFormats,
+		Started:      cfg.timeSource().Now().UTC(),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: running without a journal: %v\n", err)
+		return
+	}
+	cfg.journal = j
+}
+
+// finishJournal removes cfg's journal after the results were written.
+func finishJournal(cfg runConfig) {
+	if cfg.journal == nil {
+		return
+	}
+	if err := cfg.journal.remove(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
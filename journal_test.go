@@ -0,0 +1,194 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// writeJournal writes h and results to the journal for output, followed by
+// extra raw bytes, and sets its modification time to mtime.
+func writeJournal(t *testing.T, output string, h journalHeader, mtime time.Time, extra string, results ...checkResult) {
+	t.Helper()
+	var b strings.Builder
+	header, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Write(header)
+	b.WriteByte('\n')
+	for _, res := range results {
+		line, err := json.Marshal(res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	b.WriteString(extra)
+	if err := os.WriteFile(journalPath(output), []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(journalPath(output), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestJournalReplay reuses journaled results, cuts off a torn final line,
+// and journals the new results after the surviving ones.
+func TestJournalReplay(t *testing.T) {
+	srv := newWhoisServer(t)
+	output := filepath.Join(t.TempDir(), "out.json")
+	started := time.Now().Add(-time.Hour)
+	writeJournal(t, output, journalHeader{WhoisServer: srv.Addr, Started: started}, time.Now(), `{"Domain":"tor`,
+		checkResult{Domain: "a.com", Reason: ReasonTaken, Registrar: "Example"})
+
+	j, err := openJournal(output, journalHeader{WhoisServer: srv.Addr, Started: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []checkResult
+	stdout, _ := captureOutput(t, func() {
+		results = j.check([]string{"a.com", "b.com"}, runConfig{whoisServer: srv.Addr, workers: 1})
+	})
+	j.close()
+
+	if results[0].Registrar != "Example" || !results[1].Avail {
+		t.Errorf("results = %+v", results)
+	}
+	if got := srv.Queries(); !slices.Equal(got, []string{"b.com"}) {
+		t.Errorf("queries = %v, want only b.com", got)
+	}
+	if !strings.Contains(stdout, "reusing 1 results from an interrupted run started 1h0m") {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	raw, err := os.ReadFile(journalPath(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"started"`) || !strings.Contains(lines[1], `"a.com"`) || !strings.Contains(lines[2], `"b.com"`) {
+		t.Errorf("journal = %q", raw)
+	}
+}
+
+// TestJournalStale discards a journal older than the output file.
+func TestJournalStale(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.json")
+	if err := os.WriteFile(output, []byte("[]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := journalHeader{Started: time.Now()}
+	writeJournal(t, output, h, time.Now().Add(-time.Hour), "", checkResult{Domain: "a.com"})
+
+	j, err := openJournal(output, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.close()
+	if len(j.replayed) != 0 {
+		t.Errorf("replayed stale journal: %v", j.replayed)
+	}
+	raw, err := os.ReadFile(journalPath(output))
+	if err != nil || strings.Contains(string(raw), "a.com") {
+		t.Errorf("stale journal not truncated: %q, %v", raw, err)
+	}
+}
+
+// TestJournalMismatch discards a journal started under another WHOIS server
+// or query format, or longer ago than journalMaxAge.
+func TestJournalMismatch(t *testing.T) {
+	now := time.Now()
+	run := journalHeader{WhoisServer: "whois.example:43", QueryFormats: queryFormats{"": "domain %s"}, Started: now}
+	tests := []struct {
+		name string
+		h    journalHeader
+	}{
+		{"server", journalHeader{WhoisServer: "other.example:43", QueryFormats: run.QueryFormats, Started: now}},
+		{"query format", journalHeader{WhoisServer: run.WhoisServer, Started: now}},
+		{"age", journalHeader{WhoisServer: run.WhoisServer, QueryFormats: run.QueryFormats, Started: now.Add(-journalMaxAge - time.Minute)}},
+		{"no header", journalHeader{}},
+	}
+	for _, tt := range tests {
+		output := filepath.Join(t.TempDir(), "out.json")
+		writeJournal(t, output, tt.h, now, "", checkResult{Domain: "a.com", Avail: true})
+		j, err := openJournal(output, run)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.close()
+		if _, ok := j.reuse("a.com"); ok {
+			t.Errorf("%s: replayed a mismatched journal", tt.name)
+		}
+	}
+}
+
+// TestRunCLI_JournalResume resumes an interrupted array run and removes the
+// journal once the file is written.
+func TestRunCLI_JournalResume(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("b.com", taliatest.Response{Body: taliatest.Registered("b.com")})
+	path := filepath.Join(t.TempDir(), "domains.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"},{"domain":"b.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	writeJournal(t, path, journalHeader{WhoisServer: srv.Addr, Started: time.Now()}, time.Now(), "", checkResult{Domain: "a.com", Avail: true, Reason: ReasonNoMatch})
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"b.com"}) {
+		t.Errorf("queries = %v, want only b.com", got)
+	}
+	var recs []DomainRecord
+	raw, _ := os.ReadFile(path)
+	if err := json.Unmarshal(raw, &recs); err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || !recs[0].Available || recs[1].Status != StatusTaken {
+		t.Errorf("records = %+v", recs)
+	}
+	if _, err := os.Stat(journalPath(path)); !os.IsNotExist(err) {
+		t.Errorf("journal left behind: %v", err)
+	}
+}
+
+// TestRunCLI_NoJournal neither replays a leftover journal nor keeps one.
+func TestRunCLI_NoJournal(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := filepath.Join(t.TempDir(), "domains.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	writeJournal(t, path, journalHeader{WhoisServer: srv.Addr, Started: time.Now()}, time.Now(), "", checkResult{Domain: "a.com", Reason: ReasonTaken})
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--no-journal", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"a.com"}) {
+		t.Errorf("queries = %v, want a.com", got)
+	}
+	raw, err := os.ReadFile(journalPath(path))
+	if err != nil || strings.Count(string(raw), "\n") != 2 {
+		t.Errorf("leftover journal changed: %q, %v", raw, err)
+	}
+}
@@ -0,0 +1,59 @@
+package talia
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// isJSONLines reports whether path (a file or an HTTP(S) URL) names a JSON
+// Lines file, one DomainRecord per line, by its .jsonl or .ndjson extension.
+func isJSONLines(p string) bool {
+	if isURL(p) {
+		if u, err := url.Parse(p); err == nil {
+			p = u.Path
+		}
+	}
+	ext := strings.ToLower(path.Ext(p))
+	return ext == ".jsonl" || ext == ".ndjson"
+}
+
+// parseJSONLines decodes one DomainRecord per line. Blank lines are skipped;
+// errors name the offending line.
+func parseJSONLines(raw []byte) ([]DomainRecord, error) {
+	var records []DomainRecord
+	r := bufio.NewReader(bytes.NewReader(raw))
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rec DomainRecord
+			if uerr := json.Unmarshal(line, &rec); uerr != nil {
+				return nil, fmt.Errorf("line %d: %w", n, uerr)
+			}
+			records = append(records, rec)
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// marshalJSONLines encodes records one per line.
+func marshalJSONLines(records []DomainRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,57 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseJSONLines skips blank lines and names the line of a bad record.
+func TestParseJSONLines(t *testing.T) {
+	t.Parallel()
+	recs, err := parseJSONLines([]byte("{\"domain\":\"a.com\"}\n\n{\"domain\":\"b.com\",\"src\":1}"))
+	if err != nil || len(recs) != 2 || recs[1].Domain != "b.com" || string(recs[1].Extra["src"]) != "1" {
+		t.Errorf("records = %+v, err = %v", recs, err)
+	}
+	if _, err := parseJSONLines([]byte("{\"domain\":\"a.com\"}\n{bad}\n")); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("err = %v", err)
+	}
+	if !isJSONLines("https://x.test/feed.NDJSON?token=1") || isJSONLines("a.json") {
+		t.Error("isJSONLines")
+	}
+}
+
+// TestRunCLIJSONLines updates a .jsonl file in place, one record per line,
+// and rejects grouped output into it.
+func TestRunCLIJSONLines(t *testing.T) {
+	whois := startWhoisServer(t, "No match for domain\n")
+	path := filepath.Join(t.TempDir(), "scraped.jsonl")
+	if err := os.WriteFile(path, []byte("{\"domain\":\"a.com\",\"source\":\"crawler\"}\n{\"domain\":\"b.com\"}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	raw, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"status":"available"`) || !strings.HasSuffix(lines[0], `"source":"crawler"}`) {
+		t.Errorf("file = %s", raw)
+	}
+
+	records, err := readRecords(path)
+	if err != nil || len(records) != 2 || !records[1].Available {
+		t.Errorf("readRecords = %+v, %v", records, err)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + whois, "--grouped-output", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "--output-file") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,59 @@
+package talia
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// languageTag matches the --suggest-language values Talia accepts: a
+// two- or three-letter language code with an optional region, like "es" or
+// "pt-BR".
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z]{2})?$`)
+
+// languageNames spells out the common codes so the prompt reads naturally;
+// other valid codes are passed to the model as written.
+var languageNames = map[string]string{
+	"ar": "Arabic", "da": "Danish", "de": "German", "en": "English", "es": "Spanish",
+	"fi": "Finnish", "fr": "French", "hi": "Hindi", "it": "Italian", "ja": "Japanese",
+	"ko": "Korean", "nl": "Dutch", "no": "Norwegian", "pl": "Polish", "pt": "Portuguese",
+	"ru": "Russian", "sv": "Swedish", "tr": "Turkish", "uk": "Ukrainian", "zh": "Chinese",
+}
+
+// languagePrompt appends to prompt the instruction to suggest names in the
+// language tag, or returns prompt unchanged for tag "".
+func languagePrompt(tag, prompt string) (string, error) {
+	if tag == "" {
+		return prompt, nil
+	}
+	if !languageTag.MatchString(tag) {
+		return "", fmt.Errorf("invalid --suggest-language %q: want a language code like es or pt-BR", tag)
+	}
+	code, region, _ := strings.Cut(tag, "-")
+	name := languageNames[strings.ToLower(code)]
+	if name == "" {
+		name = fmt.Sprintf("the language with code %q", strings.ToLower(code))
+	}
+	market := name + "-speaking customers"
+	if region != "" {
+		market += " in " + strings.ToUpper(region)
+	}
+	hint := fmt.Sprintf("Suggest names in %s that appeal to %s. Write them in that language's own letters, "+
+		"including accented or non-Latin characters; do not transliterate.", name, market)
+	if prompt == "" {
+		return hint, nil
+	}
+	return prompt + " " + hint, nil
+}
+
+// idnSuggestions converts the suggested domains to their ASCII form, for
+// --suggest-language runs where the model may return internationalized
+// names. Names that can't be converted are left for normalizeDomain to drop.
+func idnSuggestions(list []DomainRecord) []DomainRecord {
+	for i := range list {
+		if d, err := idnToASCII(list[i].Domain); err == nil {
+			list[i].Domain = d
+		}
+	}
+	return list
+}
@@ -0,0 +1,73 @@
+package talia
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLanguagePrompt names the language and market and rejects malformed
+// tags.
+func TestLanguagePrompt(t *testing.T) {
+	t.Parallel()
+	if got, err := languagePrompt("", "coffee"); err != nil || got != "coffee" {
+		t.Errorf("no language: %q, %v", got, err)
+	}
+	got, err := languagePrompt("pt-br", "coffee")
+	if err != nil || !strings.HasPrefix(got, "coffee Suggest names in Portuguese that appeal to Portuguese-speaking customers in BR.") {
+		t.Errorf("pt-br: %q, %v", got, err)
+	}
+	if got, _ := languagePrompt("eu", ""); !strings.HasPrefix(got, `Suggest names in the language with code "eu"`) {
+		t.Errorf("unknown code: %q", got)
+	}
+	if _, err := languagePrompt("spanish", "x"); err == nil {
+		t.Error("expected an error for a language name instead of a code")
+	}
+}
+
+// TestRunCLISuggestLanguage keeps internationalized suggestions, stored in
+// punycode, when a language is set.
+func TestRunCLISuggestLanguage(t *testing.T) {
+	var request string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		request = string(raw)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"tool_calls":[{"function":{"name":"suggest_domains","arguments":"{\"unverified\":[{\"domain\":\"café.com\"},{\"domain\":\"épicerie.com\"}]}"}}]}}]}`)
+	}))
+	defer srv.Close()
+	testHTTPClient = fakeHTTPClient{srv}
+	testBaseURL = srv.URL
+	t.Cleanup(func() {
+		testHTTPClient = nil
+		testBaseURL = ""
+	})
+	t.Setenv("OPENAI_API_KEY", "key")
+	t.Setenv("TALIA_SUGGEST_LANGUAGE", "")
+
+	path := filepath.Join(t.TempDir(), "sugg.json")
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=2", "--suggest-language=fr", "--prompt=coffee", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(request, "Suggest names in French") {
+		t.Errorf("request = %s", request)
+	}
+	raw, _ := os.ReadFile(path)
+	if !strings.Contains(string(raw), `"xn--caf-dma.com"`) || !strings.Contains(string(raw), `"xn--picerie-9xa.com"`) {
+		t.Errorf("file = %s", raw)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=1", "--suggest-language=french!", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "invalid --suggest-language") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,64 @@
+package talia
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// truncateLog shortens log to at most maxBytes (plus a marker line) by
+// keeping its head and tail, which hold the domain details and the end of the
+// response; the repeated registry disclaimers in between are dropped.
+// maxBytes <= 0 disables truncation.
+func truncateLog(log string, maxBytes int) string {
+	if maxBytes <= 0 || len(log) <= maxBytes {
+		return log
+	}
+	head := maxBytes / 2
+	tail := maxBytes - head
+	// Avoid splitting a multi-byte UTF-8 sequence on either side.
+	for head > 0 && !utf8.RuneStart(log[head]) {
+		head--
+	}
+	for tail > 0 && !utf8.RuneStart(log[len(log)-tail]) {
+		tail--
+	}
+	dropped := len(log) - head - tail
+	return log[:head] + fmt.Sprintf("\n...[%d bytes truncated]...\n", dropped) + log[len(log)-tail:]
+}
+
+// truncateResultLogs applies truncateLog to every result.
+func truncateResultLogs(results []checkResult, maxBytes int) {
+	if maxBytes <= 0 {
+		return
+	}
+	for i := range results {
+		results[i].Log = truncateLog(results[i].Log, maxBytes)
+	}
+}
+
+// stripLogsFile removes the "log" field from every record in the array or
+// grouped file at path and returns how many records had one.
+func stripLogsFile(path string) (int, error) {
+	return rewriteRecordFile(path,
+		func(records []DomainRecord) int {
+			return clearLogs(records, func(r *DomainRecord) *string { return &r.Log })
+		},
+		func(ext *ExtendedGroupedData) int {
+			logOf := func(g *GroupedDomain) *string { return &g.Log }
+			return clearLogs(ext.Available, logOf) +
+				clearLogs(ext.Unavailable, logOf) +
+				clearLogs(ext.Unverified, func(r *DomainRecord) *string { return &r.Log })
+		})
+}
+
+// clearLogs empties the log field of each item and counts the non-empty ones.
+func clearLogs[T any](items []T, logOf func(*T) *string) int {
+	n := 0
+	for i := range items {
+		if l := logOf(&items[i]); *l != "" {
+			*l = ""
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,72 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestTruncateLog verifies head and tail are kept within the byte budget.
+func TestTruncateLog(t *testing.T) {
+	t.Parallel()
+	log := "HEAD" + strings.Repeat("x", 1000) + "TAIL"
+	got := truncateLog(log, 20)
+	if !strings.HasPrefix(got, "HEAD") || !strings.HasSuffix(got, "TAIL") {
+		t.Errorf("head/tail lost: %q", got)
+	}
+	if !strings.Contains(got, "[988 bytes truncated]") {
+		t.Errorf("missing marker: %q", got)
+	}
+	if truncateLog("short", 20) != "short" || truncateLog(log, 0) != log {
+		t.Error("logs within the limit must be unchanged")
+	}
+}
+
+// TestTruncateLogUTF8 ensures multi-byte characters are never split.
+func TestTruncateLogUTF8(t *testing.T) {
+	t.Parallel()
+	got := truncateLog(strings.Repeat("é", 100), 11)
+	if !utf8.ValidString(got) {
+		t.Errorf("invalid UTF-8 after truncation: %q", got)
+	}
+}
+
+// TestRunCLIStripLogs removes logs from every bucket of a grouped file.
+func TestRunCLIStripLogs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	in := `{"available":[{"domain":"a.com","reason":"NO_MATCH","log":"big"}],"unavailable":[{"domain":"b.com","reason":"TAKEN"}],"unverified":[{"domain":"c.com","log":"err"}]}`
+	if err := os.WriteFile(path, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--strip-logs", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Removed logs from 2 records") {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+	raw, _ := os.ReadFile(path)
+	var out ExtendedGroupedData
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), `"log"`) || len(out.Available)+len(out.Unavailable)+len(out.Unverified) != 3 {
+		t.Errorf("unexpected file: %s", raw)
+	}
+}
+
+// TestCheckDomains_MaxLogBytes verifies cfg.maxLogBytes caps stored logs.
+func TestCheckDomains_MaxLogBytes(t *testing.T) {
+	cfg := runConfig{whoisServer: "127.0.0.1:1", maxLogBytes: 10}
+	var results []checkResult
+	_, _ = captureOutput(t, func() {
+		results = checkDomains([]string{"a.com"}, cfg)
+	})
+	if !strings.Contains(results[0].Log, "bytes truncated") {
+		t.Errorf("log not truncated: %q", results[0].Log)
+	}
+}
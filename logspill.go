@@ -0,0 +1,77 @@
+package talia
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// logSpill keeps the WHOIS logs of a run within a memory budget, for
+// --log-budget: logs are held in the results until the run has held budget
+// bytes of them, and every later log is written to a file in dir instead
+// and referenced by the record's logFile. A nil *logSpill holds every log.
+type logSpill struct {
+	dir    string
+	budget int
+
+	mu      sync.Mutex
+	held    int // bytes of log held in results
+	spilled int // logs written to dir
+	err     error
+}
+
+// newLogSpill returns a logSpill writing to dir once budget bytes of log
+// are held. The directory is created on the first spill.
+func newLogSpill(dir string, budget int) *logSpill {
+	return &logSpill{dir: dir, budget: budget}
+}
+
+// defaultLogDir is the sidecar directory of --log-budget for the results
+// file at path: "results.json" spills to "results.logs".
+func defaultLogDir(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".logs"
+}
+
+// keep returns the log to store on domain's result and, if it was spilled,
+// the file holding it instead. A log that can't be written is held anyway,
+// since losing it would be worse than going over the budget.
+func (s *logSpill) keep(domain, log string) (string, string) {
+	if s == nil || log == "" {
+		return log, ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held+len(log) <= s.budget {
+		s.held += len(log)
+		return log, ""
+	}
+	path := filepath.Join(s.dir, strings.ReplaceAll(domain, string(filepath.Separator), "_")+".log")
+	err := os.MkdirAll(s.dir, 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(log), 0644)
+	}
+	if err != nil {
+		s.err = err
+		s.held += len(log)
+		return log, ""
+	}
+	s.spilled++
+	return "", path
+}
+
+// report prints how many logs were spilled, and the first write error.
+func (s *logSpill) report(cfg runConfig) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.spilled > 0 {
+		fmt.Fprintf(cfg.status(), "Log budget of %d bytes reached: wrote %d logs to %s\n", s.budget, s.spilled, s.dir)
+	}
+	if s.err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: could not spill logs, kept them in memory:", s.err)
+	}
+}
@@ -0,0 +1,62 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLogSpillKeep holds logs up to the budget and writes the rest to files.
+func TestLogSpillKeep(t *testing.T) {
+	t.Parallel()
+	dir := filepath.Join(t.TempDir(), "logs")
+	s := newLogSpill(dir, 10)
+	if log, file := s.keep("a.com", "123456"); log != "123456" || file != "" {
+		t.Errorf("within budget: %q, %q", log, file)
+	}
+	log, file := s.keep("b.com", "123456")
+	if log != "" || file != filepath.Join(dir, "b.com.log") {
+		t.Errorf("over budget: %q, %q", log, file)
+	}
+	if raw, err := os.ReadFile(file); err != nil || string(raw) != "123456" {
+		t.Errorf("spilled log = %q, %v", raw, err)
+	}
+	if log, file := (*logSpill)(nil).keep("c.com", "x"); log != "x" || file != "" {
+		t.Errorf("nil spill: %q, %q", log, file)
+	}
+	if got := defaultLogDir("out/results.json"); got != "out/results.logs" {
+		t.Errorf("defaultLogDir = %q", got)
+	}
+}
+
+// TestRunCLILogBudget spills verbose logs past the budget next to the file.
+func TestRunCLILogBudget(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: TAKEN.COM\r\nRegistrar: Example\r\n")
+	path := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"},{"domain":"b.com"},{"domain":"c.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + addr, "--sleep=0s", "--verbose", "--log-budget=60", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "wrote 2 logs to") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	records, err := readRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0].Log == "" || records[0].LogFile != "" {
+		t.Errorf("first record: %+v", records[0])
+	}
+	want := filepath.Join(strings.TrimSuffix(path, ".json")+".logs", "c.com.log")
+	if records[2].Log != "" || records[2].LogFile != want {
+		t.Errorf("last record: %+v", records[2])
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Error(err)
+	}
+}
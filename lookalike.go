@@ -0,0 +1,224 @@
+package talia
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of lookalike, in the order they are generated.
+const (
+	lookalikeOmission      = "omission"      // exmple.com
+	lookalikeRepetition    = "repetition"    // exxample.com
+	lookalikeTransposition = "transposition" // exmaple.com
+	lookalikeReplacement   = "replacement"   // exsmple.com (adjacent key)
+	lookalikeHomoglyph     = "homoglyph"     // examp1e.com
+	lookalikeHyphenation   = "hyphenation"   // ex-ample.com
+)
+
+var lookalikeKinds = []string{
+	lookalikeOmission,
+	lookalikeRepetition,
+	lookalikeTransposition,
+	lookalikeReplacement,
+	lookalikeHomoglyph,
+	lookalikeHyphenation,
+}
+
+// qwertyNeighbors lists the keys next to each key on a QWERTY keyboard.
+var qwertyNeighbors = map[byte]string{
+	'1': "2q", '2': "13wq", '3': "24ew", '4': "35re", '5': "46tr",
+	'6': "57yt", '7': "68uy", '8': "79iu", '9': "80oi", '0': "9po",
+	'q': "12wa", 'w': "qe23sa", 'e': "wr34ds", 'r': "et45fd", 't': "ry56gf",
+	'y': "tu67hg", 'u': "yi78jh", 'i': "uo89kj", 'o': "ip90lk", 'p': "o0l",
+	'a': "qwsz", 's': "awedxz", 'd': "serfcx", 'f': "drtgvc", 'g': "ftyhbv",
+	'h': "gyujnb", 'j': "huikmn", 'k': "jiolm", 'l': "kop",
+	'z': "asx", 'x': "zsdc", 'c': "xdfv", 'v': "cfgb", 'b': "vghn",
+	'n': "bhjm", 'm': "njk",
+}
+
+// homoglyphs maps a letter sequence to ASCII sequences that look like it in
+// common fonts. Internationalized (IDN) homoglyphs are out of scope.
+var homoglyphs = []struct{ from, to string }{
+	{"o", "0"}, {"0", "o"},
+	{"l", "1"}, {"l", "i"}, {"i", "1"}, {"i", "l"}, {"1", "l"},
+	{"m", "rn"}, {"rn", "m"},
+	{"w", "vv"}, {"vv", "w"},
+	{"d", "cl"}, {"cl", "d"},
+	{"g", "q"}, {"q", "g"},
+	{"u", "v"}, {"v", "u"},
+	{"e", "c"}, {"a", "o"},
+}
+
+// lookalike is a generated variant of a domain.
+type lookalike struct {
+	Domain string
+	Kind   string
+}
+
+// generateLookalikes returns typo and homoglyph variants of domain's first
+// label, keeping the rest of the domain. Only the given kinds are generated.
+// Variants that are not valid labels, equal the original, or repeat an
+// earlier variant are skipped, so each domain appears once under the first
+// kind that produced it.
+func generateLookalikes(domain string, kinds []string) []lookalike {
+	label, rest, _ := strings.Cut(domain, ".")
+	if rest != "" {
+		rest = "." + rest
+	}
+	var out []lookalike
+	seen := map[string]bool{label: true}
+	add := func(kind, v string) {
+		if !seen[v] && validDomainLabel.MatchString(v) {
+			seen[v] = true
+			out = append(out, lookalike{Domain: v + rest, Kind: kind})
+		}
+	}
+
+	for _, kind := range kinds {
+		switch kind {
+		case lookalikeOmission:
+			for i := range len(label) {
+				add(kind, label[:i]+label[i+1:])
+			}
+		case lookalikeRepetition:
+			for i := range len(label) {
+				add(kind, label[:i+1]+label[i:])
+			}
+		case lookalikeTransposition:
+			for i := 0; i+1 < len(label); i++ {
+				add(kind, label[:i]+string(label[i+1])+string(label[i])+label[i+2:])
+			}
+		case lookalikeReplacement:
+			for i := range len(label) {
+				for _, k := range []byte(qwertyNeighbors[label[i]]) {
+					add(kind, label[:i]+string(k)+label[i+1:])
+				}
+			}
+		case lookalikeHomoglyph:
+			for _, h := range homoglyphs {
+				for i := 0; ; i++ {
+					j := strings.Index(label[i:], h.from)
+					if j < 0 {
+						break
+					}
+					i += j
+					add(kind, label[:i]+h.to+label[i+len(h.from):])
+				}
+			}
+		case lookalikeHyphenation:
+			for i := 1; i < len(label); i++ {
+				add(kind, label[:i]+"-"+label[i:])
+			}
+		}
+	}
+	return out
+}
+
+// lookalikeColumns are the columns of table and CSV output.
+var lookalikeColumns = append([]string{"KIND"}, reportColumns...)
+
+// runLookalikesCommand implements "talia lookalikes <domain>": typo and
+// homoglyph variants of a brand's domain are generated and checked. Results
+// are grouped so the unregistered ones (candidates for defensive
+// registration) come first, followed by the registered ones (possible
+// phishing or typosquatting), then those whose check failed.
+func runLookalikesCommand(args []string) int {
+	fs := flag.NewFlagSet("talia lookalikes", flag.ContinueOnError)
+	checks := addCheckFlags(fs)
+	format := fs.String("format", formatTable, "Output format: 'table', 'csv', or 'json'")
+	kindsFlag := fs.String("kinds", strings.Join(lookalikeKinds, ","), "Comma-separated kinds of variant to generate")
+	list := fs.Bool("list", false, "Print the variants without checking them")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: talia lookalikes [--kinds=omission,homoglyph,...] [--list] [--format=table|csv|json] <domain>")
+		return 1
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Error: --format must be %q, %q, or %q\n", formatTable, formatCSV, formatJSON)
+		return 1
+	}
+	kinds := splitList(*kindsFlag)
+	for _, k := range kinds {
+		if !slices.Contains(lookalikeKinds, k) {
+			fmt.Fprintf(os.Stderr, "Error: unknown lookalike kind %q (want one of %s)\n", k, strings.Join(lookalikeKinds, ", "))
+			return 1
+		}
+	}
+	domain := strings.ToLower(strings.TrimSpace(args[0]))
+	if label, _, ok := strings.Cut(domain, "."); !ok || !validDomainLabel.MatchString(label) {
+		fmt.Fprintf(os.Stderr, "Error: invalid domain %q\n", args[0])
+		return 1
+	}
+
+	variants := generateLookalikes(domain, kinds)
+	if *list {
+		for _, v := range variants {
+			fmt.Printf("%s\t%s\n", v.Domain, v.Kind)
+		}
+		return 0
+	}
+	if len(variants) == 0 {
+		fmt.Fprintln(os.Stderr, "No lookalikes generated.")
+		return 0
+	}
+
+	domains := make([]string, len(variants))
+	for i, v := range variants {
+		domains[i] = v.Domain
+	}
+	cfg, err := checks.config()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	records := resultRecords(checkedResults(checkDomains(domains, cfg)))
+	kindOf := make(map[string]string, len(variants))
+	for _, v := range variants {
+		kindOf[v.Domain] = v.Kind
+	}
+	order := map[AvailabilityStatus]int{StatusAvailable: 0, StatusTaken: 1, StatusUnknown: 2}
+	slices.SortStableFunc(records, func(a, b DomainRecord) int {
+		return order[a.Status] - order[b.Status]
+	})
+
+	counts := map[AvailabilityStatus]int{}
+	for _, rec := range records {
+		counts[rec.Status]++
+	}
+	fmt.Fprintf(os.Stderr, "%d unregistered lookalikes (consider registering defensively), %d registered (review for phishing), %d unknown\n",
+		counts[StatusAvailable], counts[StatusTaken], counts[StatusUnknown])
+
+	if err := writeLookalikes(*format, records, kindOf); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing results: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeLookalikes writes checked lookalikes to stdout. JSON records carry
+// their kind in a "lookalike" field; table and CSV output add a KIND column.
+func writeLookalikes(format string, records []DomainRecord, kindOf map[string]string) error {
+	if format == formatJSON {
+		for i := range records {
+			kind, _ := json.Marshal(kindOf[records[i].Domain])
+			records[i].Extra = map[string]json.RawMessage{"lookalike": kind}
+		}
+		return writeRecords(os.Stdout, format, records)
+	}
+
+	now := time.Now()
+	rows := make([][]string, len(records))
+	for i, rec := range records {
+		rows[i] = append([]string{kindOf[rec.Domain]}, reportRow(rec, now)...)
+	}
+	return writeRows(os.Stdout, format, lookalikeColumns, rows)
+}
@@ -0,0 +1,79 @@
+package talia
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestGenerateLookalikes checks each kind and that duplicates keep their
+// first kind.
+func TestGenerateLookalikes(t *testing.T) {
+	t.Parallel()
+	got := func(domain string, kinds ...string) string {
+		var parts []string
+		for _, v := range generateLookalikes(domain, kinds) {
+			parts = append(parts, v.Domain+"/"+v.Kind)
+		}
+		return strings.Join(parts, " ")
+	}
+
+	cases := []struct {
+		domain, kind, want string
+	}{
+		{"abc.com", lookalikeOmission, "bc.com/omission ac.com/omission ab.com/omission"},
+		{"ab.io", lookalikeRepetition, "aab.io/repetition abb.io/repetition"},
+		{"abc.com", lookalikeTransposition, "bac.com/transposition acb.com/transposition"},
+		{"pl.com", lookalikeReplacement, "ol.com/replacement 0l.com/replacement ll.com/replacement pk.com/replacement po.com/replacement pp.com/replacement"},
+		{"mod.com", lookalikeHomoglyph, "m0d.com/homoglyph rnod.com/homoglyph mocl.com/homoglyph"},
+		{"abc.co.uk", lookalikeHyphenation, "a-bc.co.uk/hyphenation ab-c.co.uk/hyphenation"},
+	}
+	for _, c := range cases {
+		if g := got(c.domain, c.kind); g != c.want {
+			t.Errorf("%s %s = %q, want %q", c.domain, c.kind, g, c.want)
+		}
+	}
+
+	// "aa" omits to "a" twice and transposes to itself.
+	if g := got("aa.com", lookalikeOmission, lookalikeTransposition, lookalikeRepetition); g != "a.com/omission aaa.com/repetition" {
+		t.Errorf("dedup = %q", g)
+	}
+}
+
+// TestRunCLILookalikesSubcommand checks listing, checking, and JSON output.
+func TestRunCLILookalikesSubcommand(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"lookalikes", "--list", "--kinds=omission", "ab.com"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "b.com\tomission\na.com\tomission\n" {
+		t.Errorf("list = %q", stdout)
+	}
+
+	addr := startWhoisServer(t, "No match for domain\n")
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"lookalikes", "--whois=" + addr, "--lightspeed=max", "--kinds=omission", "--format=json", "ab.com"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stderr, "2 unregistered lookalikes") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	var recs []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &recs); err != nil {
+		t.Fatalf("stdout %q: %v", stdout, err)
+	}
+	if len(recs) != 2 || recs[0]["lookalike"] != "omission" || recs[0]["status"] != "available" {
+		t.Errorf("records = %v", recs)
+	}
+
+	_, stderr = captureOutput(t, func() {
+		if code := RunCLI([]string{"lookalikes", "--kinds=bogus", "ab.com"}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, `unknown lookalike kind "bogus"`) {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
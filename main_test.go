@@ -13,6 +13,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/sustanza/talia/taliatest"
 )
 
 // TestMain sets up the test environment to prevent tests from hitting real APIs.
@@ -88,47 +91,33 @@ func captureOutput(t *testing.T, fn func()) (string, string) {
 func TestCheckDomainAvailability(t *testing.T) {
 	cases := []struct {
 		name          string
-		serverHandler func(net.Conn)
+		resp          taliatest.Response
 		wantAvailable bool
 		wantReason    AvailabilityReason
 		wantErr       bool
 	}{
 		{
-			name: "No match => available=TRUE, reason=NO_MATCH",
-			serverHandler: func(c net.Conn) {
-				_, _ = io.Copy(io.Discard, c)
-				_, _ = io.WriteString(c, "No match for example.com\n")
-				helperClose(nil, c, "conn close")
-			},
+			name:          "No match => available=TRUE, reason=NO_MATCH",
+			resp:          taliatest.Response{Body: "No match for example.com\n"},
 			wantAvailable: true,
 			wantReason:    ReasonNoMatch,
 		},
 		{
-			name: "Domain found => available=FALSE, reason=TAKEN",
-			serverHandler: func(c net.Conn) {
-				_, _ = io.Copy(io.Discard, c)
-				_, _ = io.WriteString(c, "Domain Name: something.com\n")
-				helperClose(nil, c, "conn close")
-			},
+			name:          "Domain found => available=FALSE, reason=TAKEN",
+			resp:          taliatest.Response{Body: "Domain Name: something.com\n"},
 			wantAvailable: false,
 			wantReason:    ReasonTaken,
 		},
 		{
-			name: "Immediate close => reason=ERROR",
-			serverHandler: func(c net.Conn) {
-				helperClose(nil, c, "conn close")
-			},
+			name:          "Connection reset => reason=ERROR",
+			resp:          taliatest.Response{Fault: taliatest.Reset},
 			wantAvailable: false,
 			wantReason:    ReasonError,
 			wantErr:       true,
 		},
 		{
-			name: "Empty response => reason=ERROR",
-			serverHandler: func(c net.Conn) {
-				// Send no data
-				_, _ = io.Copy(io.Discard, c)
-				helperClose(nil, c, "conn close")
-			},
+			name:          "Empty response => reason=ERROR",
+			resp:          taliatest.Response{Fault: taliatest.Drop},
 			wantAvailable: false,
 			wantReason:    ReasonError,
 			wantErr:       true,
@@ -137,21 +126,10 @@ func TestCheckDomainAvailability(t *testing.T) {
 
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
-			ln, err := net.Listen("tcp", "127.0.0.1:0")
-			if err != nil {
-				t.Fatalf("failed to listen: %v", err)
-			}
-			defer helperClose(t, ln, "listener close")
+			srv := newWhoisServer(t)
+			srv.Handle("example.com", tt.resp)
 
-			go func() {
-				conn, err := ln.Accept()
-				if err != nil {
-					return
-				}
-				tt.serverHandler(conn)
-			}()
-
-			avail, reason, _, err := CheckDomainAvailability("example.com", ln.Addr().String())
+			avail, reason, _, err := CheckDomainAvailability("example.com", srv.Addr)
 			if tt.wantErr && err == nil {
 				t.Errorf("expected an error but got none")
 			}
@@ -183,17 +161,17 @@ func TestArgParsing(t *testing.T) {
 		t.Errorf("Expected usage help, got: %s", stderr)
 	}
 
-	// Arg but no --whois
+	// Arg but no --whois: servers are routed by TLD, so the file is read
 	flag.CommandLine = flag.NewFlagSet("TestArgParsingNoWhois", flag.ContinueOnError)
 	tmpPath := filepath.Join(t.TempDir(), "somefile.json")
 	_, stderr = captureOutput(t, func() {
 		code := RunCLI([]string{tmpPath})
 		if code == 0 {
-			t.Error("Expected non-zero code if whois is missing")
+			t.Error("Expected non-zero code for a missing file")
 		}
 	})
-	if !strings.Contains(stderr, "Error: --whois=<server:port> is required") {
-		t.Errorf("Expected missing whois error, got: %s", stderr)
+	if !strings.Contains(stderr, "Error reading") {
+		t.Errorf("Expected read error, got: %s", stderr)
 	}
 }
 
@@ -266,26 +244,11 @@ func TestMainNonGrouped(t *testing.T) {
 	helperClose(t, tmp, "tmp file close for non-grouped test")
 
 	// WHOIS => always 'No match for' => available
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("failed to listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-	go func() {
-		for {
-			c, err := ln.Accept()
-			if err != nil {
-				return
-			}
-			_, _ = io.Copy(io.Discard, c)
-			_, _ = io.WriteString(c, "No match for domain\n")
-			helperClose(nil, c, "conn close")
-		}
-	}()
+	addr := startWhoisServer(t, "No match for domain\n")
 
 	_, _ = captureOutput(t, func() {
 		code := RunCLI([]string{
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + addr,
 			"--sleep=0s",
 			tmp.Name(),
 		})
@@ -342,26 +305,11 @@ func TestFileWriteError(t *testing.T) {
 		t.Fatalf("chmod temp file: %v", err)
 	}
 
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-	go func() {
-		for {
-			c, err := ln.Accept()
-			if err != nil {
-				return
-			}
-			_, _ = io.Copy(io.Discard, c)
-			_, _ = io.WriteString(c, "No match for domain\n")
-			helperClose(nil, c, "conn close")
-		}
-	}()
+	addr := startWhoisServer(t, "No match for domain\n")
 
 	_, stderr := captureOutput(t, func() {
 		code := RunCLI([]string{
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + addr,
 			"--sleep=0s",
 			tmp.Name(),
 		})
@@ -392,27 +340,12 @@ func TestMainVerbose(t *testing.T) {
 	helperClose(t, tmp, "tmp file close for verbose test")
 
 	// WHOIS => "No match for"
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-	go func() {
-		for {
-			c, err := ln.Accept()
-			if err != nil {
-				return
-			}
-			_, _ = io.Copy(io.Discard, c)
-			_, _ = io.WriteString(c, "No match for domain\n")
-			helperClose(nil, c, "conn close")
-		}
-	}()
+	addr := startWhoisServer(t, "No match for domain\n")
 
 	_, _ = captureOutput(t, func() {
 		code := RunCLI([]string{
 			"--verbose",
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + addr,
 			"--sleep=0s",
 			tmp.Name(),
 		})
@@ -457,36 +390,13 @@ func TestMainErrorCase(t *testing.T) {
 	}
 	helperClose(t, tmp, "tmp file close for error case test")
 
-	// First conn => immediate close => error, second => "No match for"
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-
-	go func() {
-		counter := 0
-		for {
-			c, err := ln.Accept()
-			if err != nil {
-				return
-			}
-			func(conn net.Conn) {
-				defer helperClose(nil, conn, "conn close")
-				_, _ = io.Copy(io.Discard, conn)
-				if counter == 0 {
-					// Immediate close => error
-				} else {
-					_, _ = io.WriteString(conn, "No match for domain\n")
-				}
-				counter++
-			}(c)
-		}
-	}()
+	// error1.com => dropped connection => error, ok2.com => no match => available
+	srv := newWhoisServer(t)
+	srv.Handle("error1.com", taliatest.Response{Fault: taliatest.Drop})
 
 	stdout, _ := captureOutput(t, func() {
 		code := RunCLI([]string{
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + srv.Addr,
 			"--sleep=0s",
 			tmp.Name(),
 		})
@@ -549,36 +459,14 @@ func TestMainGroupedNoFile(t *testing.T) {
 	}
 	helperClose(t, tmp, "tmp file close for grouped no file test")
 
-	// WHOIS => first => no match => available, second => domain => taken
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-	go func() {
-		i := 0
-		for {
-			c, err := ln.Accept()
-			if err != nil {
-				return
-			}
-			func(conn net.Conn) {
-				defer helperClose(nil, conn, "conn close")
-				_, _ = io.Copy(io.Discard, conn)
-				if i == 0 {
-					_, _ = io.WriteString(conn, "No match for domain\n")
-				} else {
-					_, _ = io.WriteString(conn, "Domain Name: something.com\n")
-				}
-				i++
-			}(c)
-		}
-	}()
+	// WHOIS => g1.com => no match => available, g2.com => registered => taken
+	srv := newWhoisServer(t)
+	srv.Handle("g2.com", taliatest.Response{Body: taliatest.Registered("g2.com")})
 
 	_, _ = captureOutput(t, func() {
 		code := RunCLI([]string{
 			"--grouped-output",
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + srv.Addr,
 			"--sleep=0s",
 			tmp.Name(),
 		})
@@ -592,8 +480,8 @@ func TestMainGroupedNoFile(t *testing.T) {
 		t.Fatalf("reading grouped: %v", err)
 	}
 	var grouped struct {
-		Available   []map[string]string `json:"available"`
-		Unavailable []map[string]string `json:"unavailable"`
+		Available   []map[string]any `json:"available"`
+		Unavailable []map[string]any `json:"unavailable"`
 	}
 	if err := json.Unmarshal(groupedBytes, &grouped); err != nil {
 		t.Fatalf("unmarshal grouped: %v", err)
@@ -642,37 +530,15 @@ func TestMainGroupedWithFile(t *testing.T) {
 	}
 	helperClose(t, groupedFile, "groupedFile close for grouped with file test")
 
-	// WHOIS => first => no match => available, second => found => unavailable
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-	go func() {
-		i := 0
-		for {
-			conn, err := ln.Accept()
-			if err != nil {
-				return
-			}
-			func(c net.Conn) {
-				defer helperClose(nil, c, "conn close")
-				_, _ = io.Copy(io.Discard, c)
-				if i == 0 {
-					_, _ = io.WriteString(c, "No match for domain\n")
-				} else {
-					_, _ = io.WriteString(c, "Domain Name: found-something\n")
-				}
-				i++
-			}(conn)
-		}
-	}()
+	// WHOIS => merge-test1.com => available, merge-test2.com => registered => unavailable
+	srv := newWhoisServer(t)
+	srv.Handle("merge-test2.com", taliatest.Response{Body: taliatest.Registered("merge-test2.com")})
 
 	_, _ = captureOutput(t, func() {
 		code := RunCLI([]string{
 			"--grouped-output",
 			"--output-file=" + groupedFile.Name(),
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + srv.Addr,
 			"--sleep=0s",
 			inputFile.Name(),
 		})
@@ -742,23 +608,13 @@ func TestMainGroupedFileEmptyExisting(t *testing.T) {
 	defer helperRemove(t, groupedFile.Name())
 
 	// WHOIS => no match => available
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatalf("listen: %v", err)
-	}
-	defer helperClose(t, ln, "listener close")
-	go func() {
-		c, _ := ln.Accept()
-		_, _ = io.Copy(io.Discard, c)
-		_, _ = io.WriteString(c, "No match for domain\n")
-		helperClose(nil, c, "conn close")
-	}()
+	addr := startWhoisServer(t, "No match for domain\n")
 
 	_, stderr := captureOutput(t, func() {
 		code := RunCLI([]string{
 			"--grouped-output",
 			"--output-file=" + groupedFile.Name(),
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + addr,
 			"--sleep=0s",
 			inputFile.Name(),
 		})
@@ -853,38 +709,15 @@ func TestMainGroupedFileRepeatedAppend(t *testing.T) {
 	helperClose(t, groupedFile, "groupedFile close for repeated append test")
 
 	// 3. Start a mock WHOIS server
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer helperClose(t, ln, "listener close")
-
-	go func() {
-		// We'll alternate: first => no match => available
-		// second => domain found => taken
-		i := 0
-		for {
-			c, e := ln.Accept()
-			if e != nil {
-				return
-			}
-			_, _ = io.Copy(io.Discard, c)
-			if i%2 == 0 {
-				_, _ = io.WriteString(c, "No match for domain\n")
-			} else {
-				_, _ = io.WriteString(c, "Domain Name: found-it\n")
-			}
-			helperClose(nil, c, "conn close")
-			i++
-		}
-	}()
+	srv := newWhoisServer(t)
+	srv.Handle("append-2.com", taliatest.Response{Body: taliatest.Registered("append-2.com")})
 
 	// 4. Run Talia with --grouped-output and the same groupedFile a first time
 	_, _ = captureOutput(t, func() {
 		exitCodeFirst := RunCLI([]string{
 			"--grouped-output",
 			"--output-file=" + groupedFile.Name(),
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + srv.Addr,
 			"--sleep=0s",
 			inputFile.Name(),
 		})
@@ -898,7 +731,7 @@ func TestMainGroupedFileRepeatedAppend(t *testing.T) {
 		exitCodeSecond := RunCLI([]string{
 			"--grouped-output",
 			"--output-file=" + groupedFile.Name(),
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + srv.Addr,
 			"--sleep=0s",
 			inputFile.Name(),
 		})
@@ -962,36 +795,15 @@ func TestMainGroupedFileWithUnverifiedInput(t *testing.T) {
 	}
 	helperClose(t, inputFile, "inputFile close for unverified input test")
 
-	// Start WHOIS server that returns "No match for" for one domain
-	// and "Domain Name:" for the other.
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer helperClose(t, ln, "listener close")
-
-	go func() {
-		i := 0
-		for {
-			c, e := ln.Accept()
-			if e != nil {
-				return
-			}
-			_, _ = io.Copy(io.Discard, c)
-			if i%2 == 0 {
-				_, _ = io.WriteString(c, "No match for domain\n")
-			} else {
-				_, _ = io.WriteString(c, "Domain Name: found-something\n")
-			}
-			helperClose(nil, c, "conn close")
-			i++
-		}
-	}()
+	// Start a WHOIS server that finds check-me-1.com available and
+	// check-me-2.com registered.
+	srv := newWhoisServer(t)
+	srv.Handle("check-me-2.com", taliatest.Response{Body: taliatest.Registered("check-me-2.com")})
 
 	_, _ = captureOutput(t, func() {
 		exitCode := RunCLI([]string{
 			"--grouped-output",
-			"--whois=" + ln.Addr().String(),
+			"--whois=" + srv.Addr,
 			"--sleep=0s",
 			inputFile.Name(),
 		})
@@ -1651,7 +1463,7 @@ func TestRunCLIDomainArray_ErrorHandling(t *testing.T) {
 	if err := json.Unmarshal(data, &out); err != nil {
 		t.Fatalf("unmarshal: %v", err)
 	}
-	if out[0].Reason != ReasonError || out[0].Available {
+	if out[0].Reason != ReasonError || out[0].Available || out[0].Status != StatusUnknown {
 		t.Errorf("unexpected record: %+v", out[0])
 	}
 	if out[0].Log == "" {
@@ -2437,3 +2249,148 @@ func TestMergeFiles_WriteError(t *testing.T) {
 		t.Error("expected write error")
 	}
 }
+
+// TestRunCLI_ParallelKeepsOrder makes earlier domains finish last and checks
+// that array output and grouped output both follow input order.
+func TestRunCLI_ParallelKeepsOrder(t *testing.T) {
+	srv := newWhoisServer(t)
+	for i := range 5 {
+		// d0 waits longest, d4 answers immediately.
+		d := fmt.Sprintf("d%d.com", i)
+		srv.Handle(d, taliatest.Response{Body: taliatest.Available(d), Delay: time.Duration(4-i) * 20 * time.Millisecond})
+	}
+
+	dir := t.TempDir()
+	arrayFile := filepath.Join(dir, "list.json")
+	groupedFile := filepath.Join(dir, "grouped.json")
+	input := `[{"domain":"d0.com"},{"domain":"d1.com"},{"domain":"d2.com"},{"domain":"d3.com"},{"domain":"d4.com"}]`
+	if err := os.WriteFile(arrayFile, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := "[d0.com d1.com d2.com d3.com d4.com]"
+
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--lightspeed=max", "--grouped-output", "--output-file=" + groupedFile, arrayFile}); code != 0 {
+			t.Errorf("grouped run exit %d", code)
+		}
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--lightspeed=max", arrayFile}); code != 0 {
+			t.Errorf("array run exit %d", code)
+		}
+	})
+
+	var arr []DomainRecord
+	raw, _ := os.ReadFile(arrayFile)
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range arr {
+		got = append(got, r.Domain)
+	}
+	if fmt.Sprint(got) != want {
+		t.Errorf("array order = %v", got)
+	}
+
+	var grouped GroupedData
+	raw, _ = os.ReadFile(groupedFile)
+	if err := json.Unmarshal(raw, &grouped); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, gd := range grouped.Available {
+		got = append(got, gd.Domain)
+	}
+	if fmt.Sprint(got) != want {
+		t.Errorf("grouped order = %v", got)
+	}
+}
+
+// TestRunCLI_ArrayOutputFile writes array-mode results to --output-file and
+// leaves the input list untouched.
+func TestRunCLI_ArrayOutputFile(t *testing.T) {
+	addr := startWhoisServer(t, "No match for domain\n")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.json")
+	output := filepath.Join(dir, "output.json")
+	original := `[{"domain":"free.com","available":false}]`
+	if err := os.WriteFile(input, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + addr, "--sleep=0", "--output-file=" + output, input}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+
+	if raw, _ := os.ReadFile(input); string(raw) != original {
+		t.Errorf("input modified: %s", raw)
+	}
+	var arr []DomainRecord
+	raw, _ := os.ReadFile(output)
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != 1 || !arr[0].Available {
+		t.Errorf("output = %s", raw)
+	}
+}
+
+// TestRunCLI_FailOnError exits 1 when a check errored, after still writing
+// the results, and only when --fail-on-error is set.
+func TestRunCLI_FailOnError(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "input.json")
+	for _, tc := range []struct {
+		args []string
+		want int
+	}{
+		{nil, 0},
+		{[]string{"--fail-on-error"}, 1},
+	} {
+		if err := os.WriteFile(input, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		args := append([]string{"--whois=127.0.0.1:1", "--sleep=0"}, tc.args...)
+		_, stderr := captureOutput(t, func() {
+			if code := RunCLI(append(args, input)); code != tc.want {
+				t.Errorf("%v: exit %d, want %d", tc.args, code, tc.want)
+			}
+		})
+		if tc.want == 1 && !strings.Contains(stderr, "1 of 1 checks failed") {
+			t.Errorf("stderr = %q", stderr)
+		}
+		var arr []DomainRecord
+		raw, _ := os.ReadFile(input)
+		if err := json.Unmarshal(raw, &arr); err != nil || arr[0].Reason != ReasonError {
+			t.Errorf("%v: results not written: %s", tc.args, raw)
+		}
+	}
+}
+
+// TestRunCLI_PrintAvailable prints only the available domain names on stdout
+// while still updating the file.
+func TestRunCLI_PrintAvailable(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("taken.com", taliatest.Response{Body: taliatest.Registered("taken.com")})
+
+	input := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(input, []byte(`[{"domain":"a.com"},{"domain":"taken.com"},{"domain":"b.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--lightspeed=2", "--print=available", input}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "a.com\nb.com\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if !strings.Contains(stderr, "Processing complete") {
+		t.Errorf("status output missing from stderr: %q", stderr)
+	}
+	var arr []DomainRecord
+	raw, _ := os.ReadFile(input)
+	if err := json.Unmarshal(raw, &arr); err != nil || len(arr) != 3 || !arr[0].Available {
+		t.Errorf("file not updated: %s", raw)
+	}
+}
@@ -0,0 +1,148 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// rewriteRecordFile loads the array or grouped file at path, lets the matching
+// edit function modify it in place, and writes it back if any records
+// changed. Each edit function returns the number of records it changed.
+func rewriteRecordFile(path string, editArray func([]DomainRecord) int, editGrouped func(*ExtendedGroupedData) int) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int
+	var out any
+	var records []DomainRecord
+	if err := json.Unmarshal(raw, &records); err == nil {
+		updated = editArray(records)
+		out = records
+	} else {
+		var ext ExtendedGroupedData
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return 0, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if err := applyPendingLog(path, &ext); err != nil {
+			return 0, err
+		}
+		updated = editGrouped(&ext)
+		out = ext
+	}
+
+	if updated == 0 {
+		return 0, nil
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	err = auditWrite("rewrite", "", path, func() error {
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(path)
+	})
+	return updated, err
+}
+
+// parseCheckedAt parses the --checked-at date of --migrate-checked-at: a
+// day (2024-05-01, taken as midnight UTC) or an RFC 3339 time.
+func parseCheckedAt(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--checked-at must be a date (2024-05-01) or an RFC 3339 time")
+	}
+	return t.UTC(), nil
+}
+
+// migrateCheckedAtFile stamps the checked records without "checkedAt" in
+// the array or grouped file at path with at, or with the file's
+// modification time if at is zero, and returns how many records were
+// updated. The modification time is the latest the check could have
+// happened, so --max-age and --recheck-due treat the records as no fresher
+// than they are. Unverified records are left without a time.
+func migrateCheckedAtFile(path string, at time.Time) (int, error) {
+	if at.IsZero() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		at = info.ModTime().UTC().Truncate(time.Second)
+	}
+	stamp := func(checkedAt *time.Time) int {
+		if !checkedAt.IsZero() {
+			return 0
+		}
+		*checkedAt = at
+		return 1
+	}
+	return rewriteRecordFile(path,
+		func(records []DomainRecord) int {
+			updated := 0
+			for i := range records {
+				if records[i].Status != "" || records[i].Reason != "" || records[i].Available {
+					updated += stamp(&records[i].CheckedAt)
+				}
+			}
+			return updated
+		},
+		func(ext *ExtendedGroupedData) int {
+			updated := 0
+			for i := range ext.Available {
+				updated += stamp(&ext.Available[i].CheckedAt)
+			}
+			for i := range ext.Unavailable {
+				updated += stamp(&ext.Unavailable[i].CheckedAt)
+			}
+			return updated
+		})
+}
+
+// migrateStatusFile fills in the "status" field for checked records in the
+// array or grouped file at path that predate it, and returns how many records
+// were updated. Array records derive their status from "available" and
+// "reason"; grouped records from their bucket, with ERROR entries in
+// unavailable becoming "unknown". Unverified records are left without a status.
+func migrateStatusFile(path string) (int, error) {
+	return rewriteRecordFile(path,
+		func(records []DomainRecord) int {
+			updated := 0
+			for i := range records {
+				if records[i].Status != "" {
+					continue
+				}
+				if s := statusFor(records[i].Available, records[i].Reason); s != "" {
+					records[i].Status = s
+					updated++
+				}
+			}
+			return updated
+		},
+		func(ext *ExtendedGroupedData) int {
+			updated := 0
+			for i := range ext.Available {
+				if ext.Available[i].Status == "" {
+					ext.Available[i].Status = StatusAvailable
+					updated++
+				}
+			}
+			for i := range ext.Unavailable {
+				if ext.Unavailable[i].Status == "" {
+					ext.Unavailable[i].Status = StatusTaken
+					if ext.Unavailable[i].Reason == ReasonError {
+						ext.Unavailable[i].Status = StatusUnknown
+					}
+					updated++
+				}
+			}
+			return updated
+		})
+}
@@ -0,0 +1,124 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStatusFor covers the mapping from check outcome to status.
+func TestStatusFor(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		avail  bool
+		reason AvailabilityReason
+		want   AvailabilityStatus
+	}{
+		{true, ReasonNoMatch, StatusAvailable},
+		{false, ReasonTaken, StatusTaken},
+		{false, ReasonError, StatusUnknown},
+		{false, "", ""},
+	}
+	for _, tt := range tests {
+		if got := statusFor(tt.avail, tt.reason); got != tt.want {
+			t.Errorf("statusFor(%v, %q) = %q, want %q", tt.avail, tt.reason, got, tt.want)
+		}
+	}
+}
+
+// TestRunCLIMigrateStatus migrates an old array file and an old grouped file.
+func TestRunCLIMigrateStatus(t *testing.T) {
+	dir := t.TempDir()
+	arrayPath := filepath.Join(dir, "list.json")
+	groupedPath := filepath.Join(dir, "grouped.json")
+	if err := os.WriteFile(arrayPath, []byte(`[{"domain":"a.com","available":true,"reason":"NO_MATCH"},{"domain":"b.com","reason":"ERROR"},{"domain":"c.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(groupedPath, []byte(`{"available":[{"domain":"a.com","reason":"NO_MATCH"}],"unavailable":[{"domain":"b.com","reason":"TAKEN"},{"domain":"c.com","reason":"ERROR"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		for _, p := range []string{arrayPath, groupedPath} {
+			if code := RunCLI([]string{"--migrate-status", p}); code != 0 {
+				t.Errorf("%s: exit %d", p, code)
+			}
+		}
+	})
+	if !strings.Contains(stdout, "Added status to 2 records") || !strings.Contains(stdout, "Added status to 3 records") {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+
+	var arr []DomainRecord
+	raw, _ := os.ReadFile(arrayPath)
+	if err := json.Unmarshal(raw, &arr); err != nil {
+		t.Fatal(err)
+	}
+	if arr[0].Status != StatusAvailable || arr[1].Status != StatusUnknown || arr[2].Status != "" {
+		t.Errorf("unexpected array statuses: %s", raw)
+	}
+
+	var grouped GroupedData
+	raw, _ = os.ReadFile(groupedPath)
+	if err := json.Unmarshal(raw, &grouped); err != nil {
+		t.Fatal(err)
+	}
+	if grouped.Available[0].Status != StatusAvailable || grouped.Unavailable[0].Status != StatusTaken || grouped.Unavailable[1].Status != StatusUnknown {
+		t.Errorf("unexpected grouped statuses: %s", raw)
+	}
+}
+
+// TestRunCLIMigrateCheckedAt stamps checked records with the file's
+// modification time or --checked-at, keeping existing times.
+func TestRunCLIMigrateCheckedAt(t *testing.T) {
+	dir := t.TempDir()
+	arrayPath := filepath.Join(dir, "list.json")
+	groupedPath := filepath.Join(dir, "grouped.json")
+	if err := os.WriteFile(arrayPath, []byte(`[{"domain":"a.com","available":true,"reason":"NO_MATCH"},{"domain":"b.com","reason":"TAKEN","checkedAt":"2025-01-02T00:00:00Z"},{"domain":"c.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(groupedPath, []byte(`{"available":[{"domain":"a.com","reason":"NO_MATCH"}],"unavailable":[{"domain":"b.com","reason":"TAKEN"}],"unverified":[{"domain":"c.com"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := os.Chtimes(arrayPath, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--migrate-checked-at", arrayPath}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+		if code := RunCLI([]string{"--migrate-checked-at", "--checked-at=2024-05-01", groupedPath}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Added checkedAt to 1 records") || !strings.Contains(stdout, "Added checkedAt to 2 records") {
+		t.Errorf("unexpected output: %s", stdout)
+	}
+
+	arr, err := readRecords(arrayPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !arr[0].CheckedAt.Equal(mtime) || arr[1].CheckedAt.Year() != 2025 || !arr[2].CheckedAt.IsZero() {
+		t.Errorf("array times = %v, %v, %v", arr[0].CheckedAt, arr[1].CheckedAt, arr[2].CheckedAt)
+	}
+	data, err := readGroupedFile(groupedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if !data.Available[0].CheckedAt.Equal(want) || !data.Unavailable[0].CheckedAt.Equal(want) || !data.Unverified[0].CheckedAt.IsZero() {
+		t.Errorf("grouped = %+v", data)
+	}
+
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--migrate-checked-at", "--checked-at=yesterday", groupedPath}); code == 0 {
+			t.Error("invalid --checked-at accepted")
+		}
+	})
+}
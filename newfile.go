@@ -0,0 +1,128 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// defaultNewTemplate is the record "talia new" appends without --template.
+const defaultNewTemplate = `{"domain":""}`
+
+// parseRecordTemplate parses the --template of "talia new": one JSON object,
+// decoded as a domain record with any unknown fields (tags, owner, ...)
+// kept.
+func parseRecordTemplate(s string) (DomainRecord, error) {
+	var rec DomainRecord
+	trimmed := bytes.TrimSpace([]byte(s))
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return rec, fmt.Errorf("--template must be a JSON object, e.g. %s", defaultNewTemplate)
+	}
+	if err := json.Unmarshal(trimmed, &rec); err != nil {
+		return rec, fmt.Errorf("--template: %w", err)
+	}
+	return rec, nil
+}
+
+// appendTemplateRecords appends count copies of tmpl to the file at path:
+// to the end of an array or JSON Lines file, or to the "unverified" list of
+// a grouped file. A missing file is created, as JSON Lines for .jsonl and
+// .ndjson and as a grouped file otherwise. It reports whether the file was
+// created.
+func appendTemplateRecords(path string, tmpl DomainRecord, count int) (bool, error) {
+	added := make([]DomainRecord, count)
+	for i := range added {
+		added[i] = tmpl
+	}
+
+	raw, err := os.ReadFile(path)
+	created := errors.Is(err, os.ErrNotExist)
+	if err != nil && !created {
+		return false, err
+	}
+	if !created && count == 0 {
+		return false, nil
+	}
+
+	var out []byte
+	var records []DomainRecord
+	switch {
+	case isJSONLines(path):
+		if !created {
+			if records, err = parseJSONLines(raw); err != nil {
+				return false, fmt.Errorf("parsing %s: %w", path, err)
+			}
+		}
+		out, err = marshalJSONLines(append(records, added...))
+	case !created && json.Unmarshal(raw, &records) == nil:
+		out, err = json.MarshalIndent(append(records, added...), "", "  ")
+	default:
+		if created {
+			// A new file shows its buckets, so it reads as a grouped file.
+			out, err = json.MarshalIndent(GroupedData{Available: []GroupedDomain{}, Unavailable: []GroupedDomain{}, Unverified: added}, "", "  ")
+			break
+		}
+		var ext ExtendedGroupedData
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return false, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if err := applyPendingLog(path, &ext); err != nil {
+			return false, err
+		}
+		ext.Unverified = append(ext.Unverified, added...)
+		out, err = json.MarshalIndent(ext, "", "  ")
+	}
+	if err != nil {
+		return false, err
+	}
+	return created, auditWrite("new", "", path, func() error {
+		if err := writeFileAtomic(path, out, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(path)
+	})
+}
+
+// runNewCommand implements "talia new [--count=N] [--template=json] <file>":
+// it scaffolds a domain file and appends records made from the template,
+// ready to be filled in by hand. Check runs skip records whose domain is
+// still empty (see isPlaceholder).
+func runNewCommand(args []string) int {
+	fs := flag.NewFlagSet("talia new", flag.ContinueOnError)
+	count := fs.Int("count", 0, "Number of records to append")
+	template := fs.String("template", defaultNewTemplate, `Record to append, as a JSON object, e.g. '{"domain":"","tags":["q3"]}'`)
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(files) != 1 || *count < 0 {
+		fmt.Fprintln(os.Stderr, "Usage: talia new [--count=N] [--template='{\"domain\":\"\"}'] <file>")
+		return 1
+	}
+	tmpl, err := parseRecordTemplate(*template)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if !isPlaceholder(tmpl) && *count > 1 {
+		fmt.Fprintf(os.Stderr, "Error: the template's domain %q would be repeated %d times; leave it empty or use --count=1\n", tmpl.Domain, *count)
+		return 1
+	}
+
+	path := files[0]
+	created, err := appendTemplateRecords(path, tmpl, *count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+		return 1
+	}
+	if created {
+		fmt.Printf("Created %s with %d records\n", path, *count)
+	} else {
+		fmt.Printf("Added %d records to %s\n", *count, path)
+	}
+	return 0
+}
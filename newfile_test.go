@@ -0,0 +1,119 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestRunNewCommandCreatesGrouped scaffolds a grouped file of tagged
+// placeholders, then appends to it.
+func TestRunNewCommandCreatesGrouped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"new", "--count=3", `--template={"domain":"","tags":["q3"]}`, path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+		if code := RunCLI([]string{"new", path}); code != 0 {
+			t.Errorf("second exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Created "+path+" with 3 records") || !strings.Contains(stdout, "Added 0 records to "+path) {
+		t.Errorf("stdout = %q", stdout)
+	}
+	raw, _ := os.ReadFile(path)
+	var data ExtendedGroupedData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Unverified) != 3 || !strings.Contains(string(raw), `"available": []`) {
+		t.Fatalf("file = %s", raw)
+	}
+	var tags []string
+	if err := json.Unmarshal(data.Unverified[2].Extra["tags"], &tags); err != nil || len(tags) != 1 || tags[0] != "q3" {
+		t.Errorf("tags = %q (%v)", tags, err)
+	}
+}
+
+// TestAppendTemplateRecordsKeepsFormat appends to the end of array and JSON
+// Lines files.
+func TestAppendTemplateRecordsKeepsFormat(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := parseRecordTemplate(`{"domain":"","favorite":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, initial := range map[string]string{
+		"list.json":  `[{"domain":"a.com"}]`,
+		"list.jsonl": "{\"domain\":\"a.com\"}\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(initial), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if created, err := appendTemplateRecords(path, tmpl, 2); err != nil || created {
+			t.Fatalf("%s: created %v, err %v", name, created, err)
+		}
+		records, err := readRecords(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 3 || records[0].Domain != "a.com" || !records[2].Favorite {
+			t.Errorf("%s: records = %+v", name, records)
+		}
+	}
+}
+
+// TestRunNewCommandRejects refuses templates that aren't objects and
+// repeated domains.
+func TestRunNewCommandRejects(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch.json")
+	for _, args := range [][]string{
+		{"--template=[]", path},
+		{"--count=2", `--template={"domain":"a.com"}`, path},
+	} {
+		captureOutput(t, func() {
+			if code := runNewCommand(args); code == 0 {
+				t.Errorf("%v: exit 0", args)
+			}
+		})
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file created: %v", err)
+	}
+}
+
+// TestRunCLI_SkipsPlaceholders leaves records without a domain unchecked and
+// in place.
+func TestRunCLI_SkipsPlaceholders(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("free.com", taliatest.Response{Body: taliatest.Available("free.com")})
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{
+		{Domain: ""},
+		{Domain: "free.com"},
+	}})
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Skipping 1 records without a domain") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if got := srv.Queries(); len(got) != 1 {
+		t.Errorf("queries = %q", got)
+	}
+	raw, _ := os.ReadFile(path)
+	var ext ExtendedGroupedData
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		t.Fatal(err)
+	}
+	if len(ext.Unverified) != 1 || ext.Unverified[0].Domain != "" || len(ext.Available) != 1 {
+		t.Errorf("file = %s", raw)
+	}
+}
@@ -0,0 +1,348 @@
+package talia
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Event describes a finished check run. It is what Notifiers are told.
+type Event struct {
+	RunID     string    `json:"runId,omitempty"`
+	File      string    `json:"file,omitempty"` // where the results were written
+	Time      time.Time `json:"time"`
+	Checked   int       `json:"checked"`
+	Errors    int       `json:"errors"`
+	Available []string  `json:"available"`
+
+	// Changes lists the registrar and name server changes found on taken
+	// domains re-checked by the run.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Summary returns a one-line description of the run, e.g.
+// "talia run 20250102T030405Z-1a2b3c4d: 2 of 10 domains available: a.com, b.io".
+func (e Event) Summary() string {
+	var b strings.Builder
+	b.WriteString("talia run")
+	if e.RunID != "" {
+		b.WriteString(" " + e.RunID)
+	}
+	fmt.Fprintf(&b, ": %d of %d domains available", len(e.Available), e.Checked)
+	if e.Errors > 0 {
+		fmt.Fprintf(&b, " (%d errors)", e.Errors)
+	}
+	if len(e.Available) > 0 {
+		b.WriteString(": " + strings.Join(e.Available, ", "))
+	}
+	if len(e.Changes) > 0 {
+		fmt.Fprintf(&b, "; %d registrar/name server changes", len(e.Changes))
+	}
+	return b.String()
+}
+
+// runEvent builds the Event for a run whose results were written to path.
+func runEvent(cfg runConfig, path string, results []checkResult) Event {
+	e := Event{
+		RunID:     cfg.runID,
+		File:      path,
+		Time:      cfg.timeSource().Now().UTC(),
+		Checked:   len(results),
+		Available: []string{},
+	}
+	for _, res := range results {
+		switch {
+		case res.Avail:
+			e.Available = append(e.Available, res.Domain)
+		case res.Reason == ReasonError || res.Reason == ReasonGivenUp:
+			e.Errors++
+		}
+		e.Changes = append(e.Changes, res.Changes...)
+	}
+	return e
+}
+
+// Notifier is told about every finished check run. Talia ships
+// WebhookNotifier, SlackNotifier, and EmailNotifier (see --notify); programs
+// embedding Talia can add their own with RegisterNotifier.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+var (
+	notifiersMu sync.Mutex
+	notifiers   []Notifier
+)
+
+// RegisterNotifier adds n to the notifiers told about every run, in addition
+// to those given with --notify. It is safe for concurrent use.
+func RegisterNotifier(n Notifier) {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	notifiers = append(notifiers, n)
+}
+
+// registeredNotifiers returns a copy of the notifiers added by
+// RegisterNotifier.
+func registeredNotifiers() []Notifier {
+	notifiersMu.Lock()
+	defer notifiersMu.Unlock()
+	return append([]Notifier(nil), notifiers...)
+}
+
+// notifyTimeout bounds each notification so a hung endpoint can't stall the
+// end of a run.
+const notifyTimeout = 30 * time.Second
+
+// notifyRun tells cfg.notifiers and the registered notifiers about the run.
+// Every notifier is tried; it returns the process exit code.
+func notifyRun(cfg runConfig, path string, results []checkResult) int {
+	all := append(append([]Notifier(nil), cfg.notifiers...), registeredNotifiers()...)
+	if len(all) == 0 {
+		return 0
+	}
+	e := runEvent(cfg, path, results)
+	code := 0
+	for _, n := range all {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		err := n.Notify(ctx, e)
+		cancel()
+		if err != nil {
+			code = fail(cliError{Code: errCodeDelivery}, "Error sending notification: %v", err)
+		}
+	}
+	return code
+}
+
+// postJSON posts v as JSON to url with client (nil means
+// http.DefaultClient), adding header if its value is non-empty.
+func postJSON(ctx context.Context, client *http.Client, url string, v any, header http.Header) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return postBody(ctx, client, url, payload, header)
+}
+
+// postBody posts payload to url as application/json; see postJSON.
+func postBody(ctx context.Context, client *http.Client, url string, payload []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, vs := range header {
+		if len(vs) > 0 && vs[0] != "" {
+			req.Header[k] = vs
+		}
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// WebhookNotifier posts the Event as JSON to URL, with the run ID in the
+// X-Talia-Run-ID header. With Template set, the body is the template
+// executed over the Event instead (see ParseNotifyTemplate).
+type WebhookNotifier struct {
+	URL      string
+	Client   *http.Client // nil means http.DefaultClient
+	Template *template.Template
+}
+
+// Notify implements Notifier.
+func (n WebhookNotifier) Notify(ctx context.Context, e Event) error {
+	header := http.Header{runIDHeader: {e.RunID}}
+	var err error
+	if n.Template != nil {
+		var body bytes.Buffer
+		if err = n.Template.Execute(&body, e); err == nil {
+			err = postBody(ctx, n.Client, n.URL, body.Bytes(), header)
+		}
+	} else {
+		err = postJSON(ctx, n.Client, n.URL, e, header)
+	}
+	if err != nil {
+		return fmt.Errorf("webhook %s: %w", n.URL, err)
+	}
+	return nil
+}
+
+// notifyTemplateFuncs are available in webhook templates besides the
+// text/template builtins: json encodes a value as JSON (so a domain list or
+// summary can be embedded in a JSON body safely), and join is strings.Join.
+var notifyTemplateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"join": strings.Join,
+}
+
+// ParseNotifyTemplate parses text as a webhook body template over Event,
+// e.g. `{"summary": {{json .Summary}}, "count": {{len .Available}}}`. It
+// executes the template once on an empty Event so references to fields
+// that don't exist fail here rather than at the end of a run.
+func ParseNotifyTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("notify").Funcs(notifyTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(io.Discard, Event{Available: []string{}}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// SlackNotifier posts the Event's summary to a Slack incoming webhook.
+type SlackNotifier struct {
+	WebhookURL string
+	Client     *http.Client // nil means http.DefaultClient
+}
+
+// Notify implements Notifier.
+func (n SlackNotifier) Notify(ctx context.Context, e Event) error {
+	if err := postJSON(ctx, n.Client, n.WebhookURL, map[string]string{"text": e.Summary()}, nil); err != nil {
+		return fmt.Errorf("slack: %w", err)
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail; tests replace it.
+var sendMail = smtp.SendMail
+
+// EmailNotifier mails the Event's summary through the SMTP server at Addr
+// (host:port). Auth may be nil for servers that don't require it.
+type EmailNotifier struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+// Notify implements Notifier. ctx is not consulted: net/smtp has no context
+// support.
+func (n EmailNotifier) Notify(_ context.Context, e Event) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", e.Summary())
+	fmt.Fprintf(&msg, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%d domains checked, %d available, %d errors.\r\n", e.Checked, len(e.Available), e.Errors)
+	if e.File != "" {
+		fmt.Fprintf(&msg, "Results: %s\r\n", e.File)
+	}
+	if len(e.Available) > 0 {
+		msg.WriteString("\r\nAvailable:\r\n")
+		for _, d := range e.Available {
+			fmt.Fprintf(&msg, "  %s\r\n", d)
+		}
+	}
+	if len(e.Changes) > 0 {
+		msg.WriteString("\r\nChanged:\r\n")
+		for _, c := range e.Changes {
+			fmt.Fprintf(&msg, "  %s\r\n", c)
+		}
+	}
+	if err := sendMail(n.Addr, n.Auth, n.From, n.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("email to %s: %w", strings.Join(n.To, ", "), err)
+	}
+	return nil
+}
+
+// parseNotifier builds the Notifier for one --notify target:
+//
+//	mailto:ops@example.com                  EmailNotifier (TALIA_SMTP_* env)
+//	https://hooks.slack.com/services/...    SlackNotifier
+//	https://example.com/hook                WebhookNotifier
+func parseNotifier(target string) (Notifier, error) {
+	if addr, ok := strings.CutPrefix(target, "mailto:"); ok {
+		return emailNotifierFromEnv(addr)
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid --notify %q: want an http(s) URL or mailto:address", target)
+	}
+	if u.Host == "hooks.slack.com" {
+		return SlackNotifier{WebhookURL: target}, nil
+	}
+	return WebhookNotifier{URL: target}, nil
+}
+
+// emailNotifierFromEnv builds an EmailNotifier for to from TALIA_SMTP_ADDR,
+// TALIA_SMTP_FROM, and optionally TALIA_SMTP_USER and TALIA_SMTP_PASSWORD.
+func emailNotifierFromEnv(to string) (Notifier, error) {
+	if _, err := mail.ParseAddress(to); err != nil {
+		return nil, fmt.Errorf("invalid --notify mailto:%s: %w", to, err)
+	}
+	addr := os.Getenv("TALIA_SMTP_ADDR")
+	from := os.Getenv("TALIA_SMTP_FROM")
+	if addr == "" || from == "" {
+		return nil, fmt.Errorf("--notify mailto: requires TALIA_SMTP_ADDR and TALIA_SMTP_FROM")
+	}
+	n := EmailNotifier{Addr: addr, From: from, To: []string{to}}
+	if user := os.Getenv("TALIA_SMTP_USER"); user != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		n.Auth = smtp.PlainAuth("", user, os.Getenv("TALIA_SMTP_PASSWORD"), host)
+	}
+	return n, nil
+}
+
+// applyNotify parses the comma-separated --notify targets into cfg. Unlike
+// splitList it keeps case, which webhook URLs depend on. templatePath, if
+// set, is a --notify-template file used for the webhook targets' bodies.
+func applyNotify(cfg *runConfig, targets, templatePath string) error {
+	var tmpl *template.Template
+	if templatePath != "" {
+		raw, err := os.ReadFile(templatePath)
+		if err != nil {
+			return fmt.Errorf("reading --notify-template: %w", err)
+		}
+		if tmpl, err = ParseNotifyTemplate(string(raw)); err != nil {
+			return fmt.Errorf("invalid --notify-template %s: %w", templatePath, err)
+		}
+	}
+	webhooks := 0
+	for t := range strings.SplitSeq(targets, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		n, err := parseNotifier(t)
+		if err != nil {
+			return err
+		}
+		if w, ok := n.(WebhookNotifier); ok {
+			w.Template = tmpl
+			n = w
+			webhooks++
+		}
+		cfg.notifiers = append(cfg.notifiers, n)
+	}
+	if tmpl != nil && webhooks == 0 {
+		return fmt.Errorf("--notify-template requires a webhook --notify target")
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package talia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEventSummary covers the one-line summary used by Slack and email.
+func TestEventSummary(t *testing.T) {
+	t.Parallel()
+	e := Event{RunID: "r1", Checked: 3, Errors: 1, Available: []string{"a.com", "b.io"}}
+	if got, want := e.Summary(), "talia run r1: 2 of 3 domains available (1 errors): a.com, b.io"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+	if got, want := (Event{Checked: 1}).Summary(), "talia run: 0 of 1 domains available"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+// TestParseNotifier maps --notify targets to notifier types.
+func TestParseNotifier(t *testing.T) {
+	t.Setenv("TALIA_SMTP_ADDR", "smtp.example.com:587")
+	t.Setenv("TALIA_SMTP_FROM", "talia@example.com")
+	t.Setenv("TALIA_SMTP_USER", "")
+
+	n, err := parseNotifier("https://hooks.slack.com/services/T0/B0/XyZ")
+	if s, ok := n.(SlackNotifier); err != nil || !ok || s.WebhookURL != "https://hooks.slack.com/services/T0/B0/XyZ" {
+		t.Errorf("slack: %#v, %v", n, err)
+	}
+	if n, err := parseNotifier("http://example.com/hook"); err != nil || n != (WebhookNotifier{URL: "http://example.com/hook"}) {
+		t.Errorf("webhook: %#v, %v", n, err)
+	}
+	n, err = parseNotifier("mailto:ops@example.com")
+	if m, ok := n.(EmailNotifier); err != nil || !ok || m.Addr != "smtp.example.com:587" || m.To[0] != "ops@example.com" || m.Auth != nil {
+		t.Errorf("email: %#v, %v", n, err)
+	}
+	for _, bad := range []string{"ftp://example.com", "example.com", "mailto:not an address"} {
+		if _, err := parseNotifier(bad); err == nil {
+			t.Errorf("parseNotifier(%q): expected error", bad)
+		}
+	}
+
+	t.Setenv("TALIA_SMTP_ADDR", "")
+	if _, err := parseNotifier("mailto:ops@example.com"); err == nil || !strings.Contains(err.Error(), "TALIA_SMTP_ADDR") {
+		t.Errorf("missing SMTP env: %v", err)
+	}
+}
+
+// TestWebhookAndSlackNotifiers checks the bodies and headers posted.
+func TestWebhookAndSlackNotifiers(t *testing.T) {
+	t.Parallel()
+	var (
+		body   map[string]any
+		header http.Header
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+	e := Event{RunID: "r1", Checked: 1, Available: []string{"a.com"}}
+
+	if err := (WebhookNotifier{URL: srv.URL}).Notify(context.Background(), e); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	if body["runId"] != "r1" || body["checked"] != 1.0 || header.Get(runIDHeader) != "r1" {
+		t.Errorf("webhook body %v, header %v", body, header)
+	}
+
+	if err := (SlackNotifier{WebhookURL: srv.URL}).Notify(context.Background(), e); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+	if body["text"] != e.Summary() || header.Get(runIDHeader) != "" {
+		t.Errorf("slack body %v, header %v", body, header)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := (SlackNotifier{WebhookURL: failing.URL}).Notify(context.Background(), e); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected 403 error, got %v", err)
+	}
+}
+
+// TestWebhookNotifierTemplate posts the templated body and rejects templates
+// that reference fields Event doesn't have.
+func TestWebhookNotifierTemplate(t *testing.T) {
+	t.Parallel()
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer srv.Close()
+
+	tmpl, err := ParseNotifyTemplate(`{"event_action": "trigger", "payload": {"summary": {{json .Summary}}, "custom_details": {"domains": {{json (join .Available " ")}}, "count": {{len .Available}}}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := Event{RunID: "r1", Checked: 2, Available: []string{"a.com", `q"uote.com`}}
+	if err := (WebhookNotifier{URL: srv.URL, Template: tmpl}).Notify(context.Background(), e); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	payload, _ := body["payload"].(map[string]any)
+	details, _ := payload["custom_details"].(map[string]any)
+	if body["event_action"] != "trigger" || payload["summary"] != e.Summary() || details["domains"] != `a.com q"uote.com` || details["count"] != 2.0 {
+		t.Errorf("templated body = %v", body)
+	}
+
+	for _, bad := range []string{"{{.Missing}}", "{{"} {
+		if _, err := ParseNotifyTemplate(bad); err == nil {
+			t.Errorf("ParseNotifyTemplate(%q): expected error", bad)
+		}
+	}
+}
+
+// TestApplyNotifyTemplate applies --notify-template to webhook targets only
+// and requires one.
+func TestApplyNotifyTemplate(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "body.tmpl")
+	if err := os.WriteFile(path, []byte(`{"text": {{json .Summary}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var cfg runConfig
+	if err := applyNotify(&cfg, "https://example.com/hook,https://hooks.slack.com/services/x", path); err != nil {
+		t.Fatal(err)
+	}
+	if w, ok := cfg.notifiers[0].(WebhookNotifier); !ok || w.Template == nil {
+		t.Errorf("webhook notifier = %#v", cfg.notifiers[0])
+	}
+	if err := applyNotify(&runConfig{}, "https://hooks.slack.com/services/x", path); err == nil {
+		t.Error("expected an error without a webhook target")
+	}
+	if err := applyNotify(&runConfig{}, "https://example.com/hook", filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing template file")
+	}
+}
+
+// TestEmailNotifier checks the message handed to the SMTP client.
+func TestEmailNotifier(t *testing.T) {
+	var (
+		gotAddr string
+		gotTo   []string
+		gotMsg  string
+	)
+	orig := sendMail
+	t.Cleanup(func() { sendMail = orig })
+	sendMail = func(addr string, _ smtp.Auth, _ string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+
+	n := EmailNotifier{Addr: "smtp.example.com:25", From: "talia@example.com", To: []string{"ops@example.com"}}
+	e := Event{RunID: "r1", File: "out.json", Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), Checked: 2, Available: []string{"a.com"}}
+	if err := n.Notify(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	if gotAddr != "smtp.example.com:25" || len(gotTo) != 1 {
+		t.Errorf("addr %q, to %v", gotAddr, gotTo)
+	}
+	for _, want := range []string{"Subject: " + e.Summary() + "\r\n", "To: ops@example.com\r\n", "Results: out.json", "  a.com\r\n"} {
+		if !strings.Contains(gotMsg, want) {
+			t.Errorf("message missing %q:\n%s", want, gotMsg)
+		}
+	}
+
+	sendMail = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("refused") }
+	if err := n.Notify(context.Background(), e); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("expected send error, got %v", err)
+	}
+}
+
+// notifierFunc adapts a function to Notifier.
+type notifierFunc func(context.Context, Event) error
+
+func (f notifierFunc) Notify(ctx context.Context, e Event) error { return f(ctx, e) }
+
+// TestRunCLI_Notify runs --notify and a registered notifier after a check
+// run, and fails the run when a notifier does.
+func TestRunCLI_Notify(t *testing.T) {
+	orig := registeredNotifiers()
+	t.Cleanup(func() {
+		notifiersMu.Lock()
+		notifiers = orig
+		notifiersMu.Unlock()
+	})
+	var registered []Event
+	RegisterNotifier(notifierFunc(func(_ context.Context, e Event) error {
+		registered = append(registered, e)
+		return nil
+	}))
+
+	whois := startWhoisServer(t, "No match for domain\n")
+	var hooked Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&hooked)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "domains.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", "--run-id=r1", "--notify=" + srv.URL, path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if hooked.RunID != "r1" || hooked.File != path || strings.Join(hooked.Available, " ") != "a.com" {
+		t.Errorf("webhook event = %+v (stderr %q)", hooked, stderr)
+	}
+	if len(registered) != 1 || registered[0].Checked != 1 {
+		t.Errorf("registered notifier got %+v", registered)
+	}
+
+	RegisterNotifier(notifierFunc(func(context.Context, Event) error { return errors.New("boom") }))
+	_, stderr = captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "Error sending notification: boom") || len(registered) != 2 {
+		t.Errorf("stderr = %q, registered %d", stderr, len(registered))
+	}
+
+	_, stderr = captureOutput(t, func() {
+		if code := RunCLI([]string{"--notify=ftp://x", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "invalid --notify") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
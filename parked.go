@@ -0,0 +1,153 @@
+package talia
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// parkedNameServers are name server fragments of domain parking and
+// marketplace services. A taken domain delegated to one is usually parked
+// or listed for sale, and so often acquirable.
+var parkedNameServers = []string{"sedoparking", "parkingcrew", "bodis", "above.com", "dan.com", "afternic", "uniregistrymarket", "parklogic", "domainnamesales", "huge-domains", "hugedomains"}
+
+// parkedRegistrars are registrar name fragments of domain investors and
+// marketplaces that hold their inventory with their own registrar.
+var parkedRegistrars = []string{"hugedomains", "turncommerce", "namebright", "above.com", "dropcatch", "buydomains"}
+
+// parkedWhois reports whether the WHOIS name servers or registrar of a
+// taken domain point to a parking service or marketplace.
+func parkedWhois(nameServers []string, registrar string) bool {
+	for _, fragment := range parkedNameServers {
+		if nameServerContains(nameServers, fragment) {
+			return true
+		}
+	}
+	registrar = strings.ToLower(registrar)
+	for _, fragment := range parkedRegistrars {
+		if strings.Contains(registrar, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isParked reports whether rec is taken and likely parked or for sale: it
+// was tagged during the check, or (for records checked before the tag
+// existed) its stored name servers or registrar say so.
+func isParked(rec DomainRecord) bool {
+	if rec.Available {
+		return false
+	}
+	return rec.Parked || parkedWhois(rec.NameServers, rec.Registrar)
+}
+
+// defaultParkedProbeURL is the page fetched by --probe-parked; "{domain}"
+// is replaced with the domain.
+const defaultParkedProbeURL = "http://{domain}/"
+
+// parkedProbeTimeout bounds each --probe-parked page fetch, redirects
+// included.
+const parkedProbeTimeout = 5 * time.Second
+
+// parkedProbeBytes is how much of a page --probe-parked reads.
+const parkedProbeBytes = 64 << 10
+
+// parkedPageMarkers are phrases, lowercased, of parking and for-sale
+// landing pages.
+var parkedPageMarkers = []string{
+	"domain is for sale", "domain may be for sale", "buy this domain",
+	"make an offer", "this domain is parked", "parked free", "domain parking",
+	"inquire about this domain", "related searches",
+}
+
+// parkedHosts are marketplace hosts a for-sale domain redirects to.
+var parkedHosts = []string{"sedo.com", "dan.com", "afternic.com", "hugedomains.com", "godaddy.com/forsale", "atom.com", "squadhelp.com", "brandbucket.com"}
+
+// probeParked fetches the page at urlTemplate for domain and reports
+// whether it is a parking or for-sale page: it lands on a marketplace or
+// contains one of parkedPageMarkers.
+func probeParked(client httpDoer, urlTemplate, domain string) (bool, error) {
+	u := strings.ReplaceAll(urlTemplate, "{domain}", url.PathEscape(domain))
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if final := resp.Request; final != nil {
+		landed := strings.ToLower(final.URL.Host + final.URL.Path)
+		for _, host := range parkedHosts {
+			if strings.Contains(landed, host) {
+				return true, nil
+			}
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, parkedProbeBytes))
+	if err != nil {
+		return false, err
+	}
+	page := strings.ToLower(string(body))
+	for _, marker := range parkedPageMarkers {
+		if strings.Contains(page, marker) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addParkedProbes probes the taken domains not already tagged as parked by
+// WHOIS. Unreachable sites are not parked pages, so fetch errors are only
+// counted.
+func addParkedProbes(results []checkResult, cfg runConfig) {
+	client := &http.Client{Timeout: parkedProbeTimeout}
+	probed, failed := 0, 0
+	for i, res := range results {
+		if res.Reason != ReasonTaken || res.Parked {
+			continue
+		}
+		parked, err := probeParked(client, cfg.parkedProbeURL, res.Domain)
+		probed++
+		if err != nil {
+			failed++
+			continue
+		}
+		results[i].Parked = parked
+	}
+	if probed > 0 {
+		fmt.Fprintf(cfg.status(), "Probed %d taken domains for parking pages (%d unreachable)\n", probed, failed)
+	}
+}
+
+// parkedFlags are the --probe-parked flags of file mode and "talia check".
+type parkedFlags struct {
+	enabled bool
+	url     string
+}
+
+// addParkedFlags registers the parked probe flags on fs.
+func addParkedFlags(fs *flag.FlagSet) *parkedFlags {
+	f := &parkedFlags{}
+	fs.BoolVar(&f.enabled, "probe-parked", false, "Fetch the web page of taken domains not parked by WHOIS and tag parking and for-sale pages as parked")
+	fs.StringVar(&f.url, "probe-parked-url", defaultParkedProbeURL, "Page fetched by --probe-parked; {domain} is replaced with the domain")
+	return f
+}
+
+// apply copies the flags to cfg.
+func (f *parkedFlags) apply(cfg *runConfig) error {
+	if !f.enabled {
+		return nil
+	}
+	if !strings.Contains(f.url, "{domain}") {
+		return fmt.Errorf("--probe-parked-url must contain {domain}")
+	}
+	cfg.parkedProbeURL = f.url
+	return nil
+}
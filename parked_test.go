@@ -0,0 +1,78 @@
+package talia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestParkedWhois tags parking name servers and marketplace registrars.
+func TestParkedWhois(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		ns        []string
+		registrar string
+		want      bool
+	}{
+		{[]string{"ns1.sedoparking.com"}, "Key-Systems GmbH", true},
+		{[]string{"ns1.example-dns.net"}, "TurnCommerce, Inc. DBA NameBright.com", true},
+		{[]string{"ns1.cloudflare.com"}, "GoDaddy.com, LLC", false},
+		{nil, "", false},
+	}
+	for _, c := range cases {
+		if got := parkedWhois(c.ns, c.registrar); got != c.want {
+			t.Errorf("parkedWhois(%v, %q) = %v, want %v", c.ns, c.registrar, got, c.want)
+		}
+	}
+	if !isParked(DomainRecord{Parked: true}) || isParked(DomainRecord{Available: true, Parked: true}) {
+		t.Error("isParked ignores the stored tag or availability")
+	}
+}
+
+// TestProbeParked recognizes for-sale pages and marketplace redirects.
+func TestProbeParked(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forsale.com":
+			_, _ = w.Write([]byte("<h1>This Domain Is For Sale</h1>"))
+		case "/redirect.com":
+			http.Redirect(w, r, "/lp/dan.com/redirect.com", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("<h1>Welcome to our shop</h1>"))
+		}
+	}))
+	defer srv.Close()
+
+	for domain, want := range map[string]bool{"forsale.com": true, "redirect.com": true, "shop.com": false} {
+		got, err := probeParked(srv.Client(), srv.URL+"/{domain}", domain)
+		if err != nil || got != want {
+			t.Errorf("probeParked(%s) = %v, %v; want %v", domain, got, err, want)
+		}
+	}
+}
+
+// TestRunCheckCommandProbeParked probes taken domains and tags for-sale
+// pages in the output.
+func TestRunCheckCommandProbeParked(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: FORSALE.COM\r\nName Server: NS1.EXAMPLE-DNS.NET\r\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Buy this domain today"))
+	}))
+	defer srv.Close()
+
+	var code int
+	stdout, stderr := captureOutput(t, func() {
+		code = RunCLI([]string{"check", "--whois=" + addr, "--sleep=0s", "--format=json", "--probe-parked", "--probe-parked-url=" + srv.URL + "/{domain}", "forsale.com"})
+	})
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"parked": true`) {
+		t.Errorf("parked missing:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "Probed 1 taken domains") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,113 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// snippetWidth is how many bytes of context are shown on each side of a
+// parse error position.
+const snippetWidth = 40
+
+// parseAttempt records one input format that was tried and why it failed.
+type parseAttempt struct {
+	Format string
+	Err    error
+}
+
+// errorOffset returns the byte offset in data of the input a JSON decoding
+// error points at, if the error carries a position.
+func errorOffset(data []byte, err error) (int64, bool) {
+	var recErr *recordError
+	if errors.As(err, &recErr) {
+		base := bytes.Index(data, recErr.raw)
+		off, ok := errorOffset(recErr.raw, recErr.err)
+		if base < 0 || !ok {
+			return 0, false
+		}
+		return int64(base) + off, true
+	}
+
+	// Both error types report the offset just past the offending input.
+	var off int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		off = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		off = typeErr.Offset
+	default:
+		return 0, false
+	}
+	return max(off-1, 0), true
+}
+
+// lineCol converts a byte offset in data to 1-based line and column numbers.
+func lineCol(data []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(data)))
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+	return line, col
+}
+
+// snippet returns the line around offset, clipped to snippetWidth bytes on
+// each side, and a second line with a caret under the offset.
+func snippet(data []byte, offset int64) string {
+	offset = min(max(offset, 0), int64(len(data)))
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	end := len(data)
+	if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+		end = int(offset) + i
+	}
+	prefix, suffix := "", ""
+	if int(offset)-start > snippetWidth {
+		start = int(offset) - snippetWidth
+		prefix = "..."
+	}
+	if end-int(offset) > snippetWidth {
+		end = int(offset) + snippetWidth
+		suffix = "..."
+	}
+	text := strings.ReplaceAll(string(data[start:end]), "\t", " ")
+	caret := int(offset) - start + len(prefix)
+	return prefix + text + suffix + "\n" + strings.Repeat(" ", caret) + "^"
+}
+
+// parseDiagnostic describes why data could not be parsed in any of the
+// attempted formats: each format with its error and line/column, followed by
+// a snippet of the input at each distinct error position.
+func parseDiagnostic(path string, data []byte, attempts []parseAttempt) string {
+	var b strings.Builder
+	formats := make([]string, len(attempts))
+	for i, a := range attempts {
+		formats[i] = a.Format
+	}
+	fmt.Fprintf(&b, "Error parsing JSON in %s (tried: %s)\n", path, strings.Join(formats, ", "))
+
+	var offsets []int64
+	for _, a := range attempts {
+		off, ok := errorOffset(data, a.Err)
+		if !ok {
+			fmt.Fprintf(&b, "  %s: %v\n", a.Format, a.Err)
+			continue
+		}
+		line, col := lineCol(data, off)
+		fmt.Fprintf(&b, "  %s: %v (line %d, column %d)\n", a.Format, a.Err, line, col)
+		if !slices.Contains(offsets, off) {
+			offsets = append(offsets, off)
+		}
+	}
+	for _, off := range offsets {
+		line, _ := lineCol(data, off)
+		gutter := fmt.Sprintf("  %d | ", line)
+		text, caret, _ := strings.Cut(snippet(data, off), "\n")
+		fmt.Fprintf(&b, "%s%s\n%s%s\n", gutter, text, strings.Repeat(" ", len(gutter)), caret)
+	}
+	return b.String()
+}
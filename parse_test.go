@@ -0,0 +1,53 @@
+package talia
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestParseDiagnostic_SyntaxError checks the line, column, and caret for a
+// trailing comma deep in a multi-line file.
+func TestParseDiagnostic_SyntaxError(t *testing.T) {
+	t.Parallel()
+	data := []byte("[\n  {\"domain\": \"a.com\"},\n  {\"domain\": \"b.com\",}\n]\n")
+	var arr []DomainRecord
+	err := json.Unmarshal(data, &arr)
+
+	got := parseDiagnostic("list.json", data, []parseAttempt{{Format: "array of domain records", Err: err}})
+	if !strings.Contains(got, "(line 3, column 22)") {
+		t.Errorf("missing position:\n%s", got)
+	}
+	want := "  3 |   {\"domain\": \"b.com\",}\n" + strings.Repeat(" ", 6+21) + "^\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("unexpected snippet:\n%s", got)
+	}
+}
+
+// TestParseDiagnostic_NestedTypeError verifies errors raised inside a record's
+// UnmarshalJSON are located in the full document, not relative to the record.
+func TestParseDiagnostic_NestedTypeError(t *testing.T) {
+	t.Parallel()
+	data := []byte("{\n\"available\": [{\"domain\": \"x.com\"}, {\"domain\": 5}]}")
+	var ext ExtendedGroupedData
+	err := json.Unmarshal(data, &ext)
+
+	got := parseDiagnostic("g.json", data, []parseAttempt{{Format: "grouped object", Err: err}})
+	if !strings.Contains(got, "(line 2, column 47)") || !strings.Contains(got, "GroupedDomain.domain") {
+		t.Errorf("unexpected diagnostic:\n%s", got)
+	}
+}
+
+// TestSnippetClipsLongLines ensures long lines are trimmed around the offset.
+func TestSnippetClipsLongLines(t *testing.T) {
+	t.Parallel()
+	data := []byte(strings.Repeat("a", 100) + "X" + strings.Repeat("b", 100))
+	got := snippet(data, 100)
+	line, caret, _ := strings.Cut(got, "\n")
+	if !strings.HasPrefix(line, "...") || !strings.HasSuffix(line, "...") {
+		t.Errorf("line not clipped: %q", line)
+	}
+	if line[len(caret)-1] != 'X' {
+		t.Errorf("caret not under offending byte:\n%s", got)
+	}
+}
@@ -0,0 +1,112 @@
+package talia
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// embeddedNotFoundPhrases maps TLDs to the phrases their registries answer
+// with when a domain is not registered. Users extend it with a file of the
+// same name in the config directory.
+//
+//go:embed not-found-phrases.json
+var embeddedNotFoundPhrases []byte
+
+// phraseDB maps TLDs and second-level suffixes (lowercase, without the
+// leading dot) to their "not found" phrases.
+type phraseDB map[string][]string
+
+// phrasesFileName is the user's phrase file in the config directory.
+const phrasesFileName = "not-found-phrases.json"
+
+// defaultNotFoundPhrases are recognized for every domain: the
+// Verisign-style phrase, which many registries and test servers share
+// whatever the TLD.
+var defaultNotFoundPhrases = []string{"No match for"}
+
+// loadPhraseDB returns the embedded phrases plus those in the config
+// directory's phrase file, if there is one. A TLD in both gets both lists.
+func loadPhraseDB() (phraseDB, error) {
+	db := phraseDB{}
+	if err := json.Unmarshal(embeddedNotFoundPhrases, &db); err != nil {
+		return nil, fmt.Errorf("embedded phrase database: %w", err)
+	}
+	dir, err := configDir()
+	if err != nil {
+		return db, nil
+	}
+	path := filepath.Join(dir, phrasesFileName)
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var user phraseDB
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for tld, phrases := range user {
+		tld = strings.ToLower(strings.TrimPrefix(tld, "."))
+		db[tld] = append(db[tld], phrases...)
+	}
+	return db, nil
+}
+
+// The phrase database is loaded once per process on first use;
+// resetPhraseDB forces a reload in tests.
+var (
+	phraseDBMu     sync.Mutex
+	phraseDBLoaded phraseDB
+	phraseDBErr    error
+)
+
+func currentPhraseDB() (phraseDB, error) {
+	phraseDBMu.Lock()
+	defer phraseDBMu.Unlock()
+	if phraseDBLoaded == nil && phraseDBErr == nil {
+		phraseDBLoaded, phraseDBErr = loadPhraseDB()
+	}
+	return phraseDBLoaded, phraseDBErr
+}
+
+func resetPhraseDB() {
+	phraseDBMu.Lock()
+	defer phraseDBMu.Unlock()
+	phraseDBLoaded, phraseDBErr = nil, nil
+}
+
+// phrasesFor returns the "not found" phrases for domain from db: those of
+// its second-level suffix (co.uk) if it has an entry, else its TLD's, plus
+// the defaults.
+func (db phraseDB) phrasesFor(domain string) []string {
+	p, ok := db[publicSuffix(domain)]
+	if !ok {
+		p = db[tldOf(domain)]
+	}
+	return append(p[:len(p):len(p)], defaultNotFoundPhrases...)
+}
+
+// normalizePhrase lowercases s and collapses runs of whitespace, so
+// phrases match however a registry aligns its columns ("Status:   free").
+func normalizePhrase(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// notFound reports whether resp says domain is not registered, by the
+// phrases of its registry.
+func (db phraseDB) notFound(domain, resp string) bool {
+	norm := normalizePhrase(resp)
+	for _, p := range db.phrasesFor(domain) {
+		if p = normalizePhrase(p); p != "" && strings.Contains(norm, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPhraseDBNotFound classifies registry-specific answers by the
+// domain's TLD and keeps the Verisign phrase for every TLD.
+func TestPhraseDBNotFound(t *testing.T) {
+	t.Setenv("TALIA_CONFIG_DIR", t.TempDir())
+	resetPhraseDB()
+	t.Cleanup(resetPhraseDB)
+	db, err := currentPhraseDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		domain, resp string
+		want         bool
+	}{
+		{"frei.de", "Domain: frei.de\nStatus: free\n", true},
+		{"belegt.de", "Domain: belegt.de\nStatus: connect\n", false},
+		{"libero.it", "Domain:             libero.it\nStatus:             AVAILABLE\n", true},
+		{"nothing.io", "NOT FOUND\n", true},
+		{"kong.cn", "No matching record.\n", true},
+		{"free.co.uk", "    This domain name has not been registered.\n", true},
+		{"free.de", "No match for \"FREE.DE\".\n", true},
+		{"taken.com", "Domain Name: TAKEN.COM\nStatus: free\n", false},
+	} {
+		if got := db.notFound(tc.domain, tc.resp); got != tc.want {
+			t.Errorf("notFound(%s, %q) = %v, want %v", tc.domain, tc.resp, got, tc.want)
+		}
+	}
+}
+
+// TestPhraseDBUserFile extends the embedded phrases from the config
+// directory.
+func TestPhraseDBUserFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TALIA_CONFIG_DIR", dir)
+	if err := os.WriteFile(filepath.Join(dir, phrasesFileName), []byte(`{".de": ["Nicht vergeben"], "zz": ["Libre"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resetPhraseDB()
+	t.Cleanup(resetPhraseDB)
+
+	for _, domain := range []string{"a.de", "a.zz"} {
+		resp := map[string]string{"a.de": "Status: free", "a.zz": "Domaine libre"}[domain]
+		avail, reason, _, err := CheckDomainAvailabilityWithClient(domain, fakeWhoisClient{resp: resp})
+		if err != nil || !avail || reason != ReasonNoMatch {
+			t.Errorf("%s: %v %s %v", domain, avail, reason, err)
+		}
+	}
+	db, _ := currentPhraseDB()
+	if !db.notFound("a.de", "nicht   vergeben") {
+		t.Error("user phrase for .de not recognized")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, phrasesFileName), []byte(`{`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resetPhraseDB()
+	if _, _, _, err := CheckDomainAvailabilityWithClient("a.de", fakeWhoisClient{resp: "Status: free"}); err == nil {
+		t.Error("broken phrase file accepted")
+	}
+}
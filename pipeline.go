@@ -0,0 +1,186 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// suggestPipeline verifies suggestions while later suggestion requests are
+// still in flight, so AI latency and WHOIS latency overlap instead of adding
+// up. Batches are fed in with Add as each request returns; Finish waits for the
+// remaining checks.
+//
+// Checks follow cfg like a file run's: cfg.journal supplies and records
+// results, cfg.limits can stop the run early (the rest stay unchecked), and
+// Finish adds what enrichResults adds.
+type suggestPipeline struct {
+	cfg   runConfig
+	queue chan string
+	prog  *progress
+	stats *checkStats
+	wg    sync.WaitGroup
+
+	mu        sync.Mutex
+	seen      map[string]bool
+	order     []string
+	results   map[string]checkResult
+	suggested map[string]DomainRecord // the suggestion behind each result
+	reused    int                     // results taken from the journal
+}
+
+// newSuggestPipeline starts the WHOIS checkers. Domains in existing are never
+// re-checked. cfg.workers selects the checker pool size as in checkDomains:
+// 0 checks sequentially with cfg.sleepFor between checks, -1 checks every domain
+// concurrently.
+func newSuggestPipeline(cfg runConfig, existing []string) *suggestPipeline {
+	p := &suggestPipeline{
+		cfg:       cfg,
+		queue:     make(chan string, 64),
+		prog:      newProgress(cfg.status(), 0),
+		stats:     newCheckStats(cfg.status()),
+		seen:      make(map[string]bool),
+		results:   make(map[string]checkResult),
+		suggested: make(map[string]DomainRecord),
+	}
+	for _, d := range existing {
+		p.seen[strings.ToLower(d)] = true
+	}
+
+	switch {
+	case cfg.workers < 0:
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			var each sync.WaitGroup
+			for domain := range p.queue {
+				each.Add(1)
+				go func() {
+					defer each.Done()
+					p.check(domain)
+				}()
+			}
+			each.Wait()
+		}()
+	default:
+		for range max(cfg.workers, 1) {
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				for domain := range p.queue {
+					if queried := p.check(domain); queried && cfg.workers == 0 {
+						cfg.timeSource().Sleep(cfg.sleepFor(domain))
+					}
+				}
+			}()
+		}
+	}
+	return p
+}
+
+// Add normalizes a batch of suggestions and queues the new, valid ones for
+// checking. It is safe to call from multiple goroutines.
+func (p *suggestPipeline) Add(list []DomainRecord) {
+	var fresh []string
+	p.mu.Lock()
+	for _, rec := range list {
+		domain := normalizeDomain(rec.Domain)
+		if domain == "" || p.seen[domain] {
+			continue
+		}
+		p.seen[domain] = true
+		p.suggested[domain] = rec
+		p.order = append(p.order, domain)
+		fresh = append(fresh, domain)
+	}
+	p.mu.Unlock()
+
+	p.prog.AddTotal(len(fresh))
+	for _, domain := range fresh {
+		p.queue <- domain
+	}
+}
+
+// check finds the result for domain and reports whether it took a WHOIS
+// query: a journaled result is reused, and once cfg.limits has stopped the
+// run the domain is left unchecked.
+func (p *suggestPipeline) check(domain string) bool {
+	res, reused := p.cfg.journal.reuse(domain)
+	queried := false
+	switch {
+	case reused:
+	case p.cfg.limits.stopped(p.cfg.timeSource().Now()):
+		res = uncheckedResult(domain)
+	default:
+		res = checkOne(domain, p.cfg)
+		p.cfg.journal.record(res)
+		queried = true
+	}
+	p.cfg.limits.goal.record(res)
+	if res.checked() {
+		p.prog.IncrementAndPrint(domain, res.Avail, res.Reason)
+		p.stats.Record(res.Avail, res.Reason)
+	}
+	p.mu.Lock()
+	p.results[domain] = res
+	if reused {
+		p.reused++
+	}
+	p.mu.Unlock()
+	return queried
+}
+
+// Finish stops accepting batches, waits for outstanding checks, and returns
+// the results in the order the domains were first suggested, including
+// those left unchecked (see checkResult.checked).
+func (p *suggestPipeline) Finish() []checkResult {
+	close(p.queue)
+	p.wg.Wait()
+	p.prog.Finish()
+	p.stats.PrintSummary()
+	p.cfg.journal.reportReuse(p.cfg.status(), p.reused, p.cfg.timeSource().Now())
+
+	results := make([]checkResult, 0, len(p.order))
+	for _, domain := range p.order {
+		results = append(results, p.results[domain])
+	}
+	reportUnchecked(p.cfg, results)
+	p.cfg.whoisCache.reportHits(p.cfg)
+	p.cfg.logSpill.report(p.cfg)
+	enrichResults(results, p.cfg)
+	return results
+}
+
+// writeCheckedSuggestions adds pipeline results to the grouped file at path,
+// placing each domain directly in available or unavailable (or back in
+// unverified for errors when retryErrors is set). suggested holds the
+// suggestion records by domain, whose metadata is kept. runID names the run
+// in the audit log.
+func writeCheckedSuggestions(path, runID string, results []checkResult, suggested map[string]DomainRecord, retryErrors bool) (ExtendedGroupedData, error) {
+	var ext ExtendedGroupedData
+	if raw, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return ext, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	if err := applyPendingLog(path, &ext); err != nil {
+		return ext, err
+	}
+
+	for _, res := range results {
+		addGroupedResult((*GroupedData)(&ext), res, suggested[res.Domain], retryErrors)
+	}
+
+	out, err := json.MarshalIndent(ext, "", "  ")
+	if err != nil {
+		return ext, err
+	}
+	return ext, auditWrite("suggest", runID, path, func() error {
+		if err := writeFileAtomic(path, out, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(path)
+	})
+}
@@ -0,0 +1,125 @@
+package talia
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestSuggestPipeline verifies queued suggestions are normalized, deduplicated
+// against existing domains, checked, and returned in suggestion order.
+func TestSuggestPipeline(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("taken.com", taliatest.Response{Body: taliatest.Registered("taken.com")})
+
+	cfg := runConfig{whoisServer: srv.Addr, workers: 2}
+	var results []checkResult
+	_, _ = captureOutput(t, func() {
+		p := newSuggestPipeline(cfg, []string{"old.com"})
+		p.Add([]DomainRecord{{Domain: "Free.com"}, {Domain: "old.com"}, {Domain: "bad"}})
+		p.Add([]DomainRecord{{Domain: "taken.com"}, {Domain: "free.com"}})
+		results = p.Finish()
+	})
+
+	if len(results) != 2 {
+		t.Fatalf("want 2 results, got %+v", results)
+	}
+	if results[0].Domain != "free.com" || !results[0].Avail {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[1].Domain != "taken.com" || results[1].Reason != ReasonTaken {
+		t.Errorf("unexpected second result: %+v", results[1])
+	}
+}
+
+// TestRunCLISuggestPipeline runs --suggest with --pipeline end to end and
+// expects checked domains to land directly in the grouped buckets.
+func TestRunCLISuggestPipeline(t *testing.T) {
+	// Integration test: cannot be parallel due to test hooks and env vars
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"tool_calls":[{"function":{"name":"suggest_domains","arguments":"{\"unverified\":[{\"domain\":\"p1.com\"},{\"domain\":\"p2.com\"}]}"}}]}}]}`)
+	}))
+	defer srv.Close()
+	testHTTPClient = fakeHTTPClient{srv}
+	testBaseURL = srv.URL
+	t.Cleanup(func() {
+		testHTTPClient = nil
+		testBaseURL = ""
+	})
+	t.Setenv("OPENAI_API_KEY", "key")
+
+	whois := startWhoisServer(t, "No match for domain\n")
+
+	path := filepath.Join(t.TempDir(), "sugg.json")
+	stdout, _ := captureOutput(t, func() {
+		code := RunCLI([]string{"--suggest=2", "--suggest-parallel=2", "--pipeline", "--whois=" + whois, path})
+		if code != 0 {
+			t.Errorf("expected exit 0, got %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "checked 2 new domains") {
+		t.Errorf("missing pipeline summary: %s", stdout)
+	}
+
+	raw, _ := os.ReadFile(path)
+	var out ExtendedGroupedData
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	if len(out.Available) != 2 || len(out.Unverified) != 0 {
+		t.Errorf("unexpected file contents: %s", raw)
+	}
+}
+
+// TestRunCLISuggestPipelineStopOnAvailable stops checking suggestions once
+// one is available and keeps the rest in unverified.
+func TestRunCLISuggestPipelineStopOnAvailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"tool_calls":[{"function":{"name":"suggest_domains","arguments":"{\"unverified\":[{\"domain\":\"p1.com\"},{\"domain\":\"p2.com\"},{\"domain\":\"p3.com\"}]}"}}]}}]}`)
+	}))
+	defer srv.Close()
+	testHTTPClient = fakeHTTPClient{srv}
+	testBaseURL = srv.URL
+	t.Cleanup(func() {
+		testHTTPClient = nil
+		testBaseURL = ""
+	})
+	t.Setenv("OPENAI_API_KEY", "key")
+
+	whois := newWhoisServer(t)
+	for _, d := range []string{"p1.com", "p2.com", "p3.com"} {
+		whois.Handle(d, taliatest.Response{Body: taliatest.Available(d)})
+	}
+
+	path := filepath.Join(t.TempDir(), "sugg.json")
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=3", "--pipeline", "--stop-on-available=1", "--whois=" + whois.Addr, path}); code != 0 {
+			t.Errorf("expected exit 0, got %d", code)
+		}
+	})
+	if got := whois.Queries(); len(got) != 1 {
+		t.Errorf("queries = %v, want one", got)
+	}
+	if !strings.Contains(stdout, "Stop on available: found 1 available domains; 2 domains left unchecked") {
+		t.Errorf("stdout = %s", stdout)
+	}
+
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Available) != 1 || len(data.Unverified) != 2 || data.Available[0].Attempts != 1 {
+		t.Errorf("file = %+v", data)
+	}
+}
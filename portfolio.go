@@ -0,0 +1,110 @@
+package talia
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// portfolio describes the domains we own, for --portfolio: the domains
+// themselves and the WHOIS patterns that identify our registrations.
+type portfolio struct {
+	domains     map[string]bool // keyed by dupKey
+	registrants []string        // lowercased substrings of the registrant
+	nameServers []string        // lowercased glob patterns, e.g. "*.acme-dns.net"
+}
+
+// loadPortfolio reads a portfolio file: one owned domain per line, plus
+// "registrant: <text>" and "nameserver: <pattern>" lines that match our
+// registrations by WHOIS. Blank lines and '#' comments are skipped.
+func loadPortfolio(file string) (*portfolio, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	p := &portfolio{domains: make(map[string]bool)}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		value = strings.ToLower(strings.TrimSpace(value))
+		switch key = strings.ToLower(strings.TrimSpace(key)); {
+		case !ok:
+			p.domains[dupKey(line)] = true
+		case key == "registrant" && value != "":
+			p.registrants = append(p.registrants, value)
+		case key == "nameserver" && value != "":
+			if _, err := path.Match(value, ""); err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid nameserver pattern %q", file, n, value)
+			}
+			p.nameServers = append(p.nameServers, strings.TrimSuffix(value, "."))
+		default:
+			return nil, fmt.Errorf("%s:%d: want a domain, \"registrant: <text>\", or \"nameserver: <pattern>\"", file, n)
+		}
+	}
+	return p, scanner.Err()
+}
+
+// owns reports whether the taken domain of res is ours: listed in the
+// portfolio, or registered to a matching registrant or delegated to a
+// matching name server.
+func (p *portfolio) owns(res checkResult) bool {
+	if res.Reason != ReasonTaken {
+		return false
+	}
+	if p.domains[dupKey(res.Domain)] {
+		return true
+	}
+	registrant := strings.ToLower(res.Registrant)
+	for _, r := range p.registrants {
+		if registrant != "" && strings.Contains(registrant, r) {
+			return true
+		}
+	}
+	for _, pattern := range p.nameServers {
+		for _, ns := range res.NameServers {
+			if ok, _ := path.Match(pattern, ns); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markOwned sets OwnedByUs on the results p owns and reports how many on
+// cfg's status output.
+func markOwned(results []checkResult, cfg runConfig) {
+	owned := 0
+	for i := range results {
+		if cfg.portfolio.owns(results[i]) {
+			results[i].OwnedByUs = true
+			owned++
+		}
+	}
+	if owned > 0 {
+		fmt.Fprintf(cfg.status(), "Portfolio: %d taken domains are ours\n", owned)
+	}
+}
+
+// applyPortfolio loads the --portfolio file (or TALIA_PORTFOLIO) into cfg.
+func applyPortfolio(cfg *runConfig, file string) error {
+	if file == "" {
+		file = os.Getenv("TALIA_PORTFOLIO")
+	}
+	if file == "" {
+		return nil
+	}
+	p, err := loadPortfolio(file)
+	if err != nil {
+		return fmt.Errorf("--portfolio: %w", err)
+	}
+	cfg.portfolio = p
+	return nil
+}
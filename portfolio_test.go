@@ -0,0 +1,73 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePortfolio(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "portfolio.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestPortfolioOwns matches taken domains by name, registrant, or name
+// server, and never available ones.
+func TestPortfolioOwns(t *testing.T) {
+	p, err := loadPortfolio(writePortfolio(t, "# ours\nAcme.com\nregistrant: ACME Corp\nnameserver: *.acme-dns.net\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		res  checkResult
+		want bool
+	}{
+		{checkResult{Domain: "acme.com", Reason: ReasonTaken}, true},
+		{checkResult{Domain: "acme.com", Reason: ReasonNoMatch}, false},
+		{checkResult{Domain: "acme.io", Reason: ReasonTaken, Registrant: "Acme Corp Holdings"}, true},
+		{checkResult{Domain: "acme.net", Reason: ReasonTaken, NameServers: []string{"ns1.acme-dns.net"}}, true},
+		{checkResult{Domain: "rival.com", Reason: ReasonTaken, Registrant: "Rival Inc", NameServers: []string{"ns1.rival.net"}}, false},
+	}
+	for _, c := range cases {
+		if got := p.owns(c.res); got != c.want {
+			t.Errorf("owns(%+v) = %v, want %v", c.res, got, c.want)
+		}
+	}
+}
+
+// TestLoadPortfolioErrors names the file and line of a bad entry.
+func TestLoadPortfolioErrors(t *testing.T) {
+	for _, content := range []string{"registrar: Acme\n", "nameserver: [\n"} {
+		_, err := loadPortfolio(writePortfolio(t, "acme.com\n"+content))
+		if err == nil || !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("loadPortfolio(%q) error = %v", content, err)
+		}
+	}
+	if _, err := loadPortfolio(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing file accepted")
+	}
+}
+
+// TestRunCheckCommandPortfolio marks a taken domain of ours in the output.
+func TestRunCheckCommandPortfolio(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: ACME.COM\r\nName Server: NS1.ACME-DNS.NET\r\n")
+	portfolio := writePortfolio(t, "nameserver: *.acme-dns.net\n")
+	var code int
+	stdout, stderr := captureOutput(t, func() {
+		code = RunCLI([]string{"check", "--whois=" + addr, "--sleep=0s", "--format=json", "--portfolio=" + portfolio, "acme.com"})
+	})
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, `"ownedByUs": true`) {
+		t.Errorf("ownedByUs missing:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "Portfolio: 1 taken domains are ours") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
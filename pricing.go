@@ -0,0 +1,116 @@
+package talia
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// defaultPricingURL is GoDaddy's availability endpoint, which quotes the
+// first-year price. "{domain}" is replaced with the domain being priced.
+const defaultPricingURL = "https://api.godaddy.com/v1/domains/available?domain={domain}&checkType=FULL"
+
+// defaultPremiumOver is the first-year price (in the quoted currency) above
+// which a domain counts as premium when the API does not say so itself.
+const defaultPremiumOver = 100
+
+// priceQuote is a registrar's first-year price for a domain.
+type priceQuote struct {
+	Price    float64
+	Currency string
+	Premium  bool
+}
+
+// pricingResponse covers the availability/pricing APIs Talia understands.
+// GoDaddy quotes "price" in millionths of the currency unit (converted in
+// quoteDomain); other APIs are assumed to quote whole units.
+type pricingResponse struct {
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
+	Premium  *bool   `json:"premium"`
+}
+
+// quoteDomain asks the pricing API at urlTemplate for domain's first-year
+// price. A domain is premium if the API says so, or otherwise if the price
+// is above premiumOver.
+func quoteDomain(client httpDoer, urlTemplate, auth, domain string, premiumOver float64) (priceQuote, error) {
+	var body pricingResponse
+	u := strings.ReplaceAll(urlTemplate, "{domain}", url.QueryEscape(domain))
+	if err := getJSON(client, u, auth, &body); err != nil {
+		return priceQuote{}, fmt.Errorf("price of %s: %w", domain, err)
+	}
+	if body.Price <= 0 {
+		return priceQuote{}, fmt.Errorf("price of %s: no price in response", domain)
+	}
+	q := priceQuote{Price: body.Price, Currency: body.Currency}
+	if strings.Contains(urlTemplate, "api.godaddy.com") {
+		q.Price /= 1e6
+	}
+	if q.Currency == "" {
+		q.Currency = "USD"
+	}
+	if body.Premium != nil {
+		q.Premium = *body.Premium
+	} else {
+		q.Premium = q.Price > premiumOver
+	}
+	return q, nil
+}
+
+// addPrices stores the first-year price of each available domain. Failed
+// lookups are reported as warnings and leave the price unset.
+func addPrices(results []checkResult, cfg runConfig) {
+	n, premium := 0, 0
+	for i, res := range results {
+		if !res.Avail {
+			continue
+		}
+		q, err := quoteDomain(http.DefaultClient, cfg.pricingURL, cfg.pricingAuth, res.Domain, cfg.premiumOver)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+			continue
+		}
+		results[i].Price = q
+		n++
+		if q.Premium {
+			premium++
+		}
+	}
+	if n > 0 {
+		fmt.Fprintf(cfg.status(), "Priced %d available domains (%d premium)\n", n, premium)
+	}
+}
+
+// pricingFlags are the --pricing flags of file mode and "talia check".
+type pricingFlags struct {
+	enabled     bool
+	url         string
+	premiumOver float64
+}
+
+// addPricingFlags registers the pricing flags on fs.
+func addPricingFlags(fs *flag.FlagSet) *pricingFlags {
+	f := &pricingFlags{}
+	fs.BoolVar(&f.enabled, "pricing", false, "Store the first-year price of available domains and flag premium ones (GoDaddy by default; needs GODADDY_API_KEY and GODADDY_API_SECRET)")
+	fs.StringVar(&f.url, "pricing-url", defaultPricingURL, "Pricing API URL; {domain} is replaced with the domain")
+	fs.Float64Var(&f.premiumOver, "premium-over", defaultPremiumOver, "First-year price above which a domain counts as premium, if the API does not say")
+	return f
+}
+
+// apply copies the flags to cfg. The GoDaddy credentials are required only
+// for the GoDaddy endpoint.
+func (f *pricingFlags) apply(cfg *runConfig) error {
+	if !f.enabled {
+		return nil
+	}
+	cfg.pricingURL = f.url
+	cfg.pricingAuth = godaddyAuth()
+	cfg.premiumOver = f.premiumOver
+	if cfg.pricingAuth == "" && strings.Contains(f.url, "api.godaddy.com") {
+		return fmt.Errorf("--pricing requires GODADDY_API_KEY and GODADDY_API_SECRET")
+	}
+	return nil
+}
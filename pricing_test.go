@@ -0,0 +1,85 @@
+package talia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAddPrices prices available domains only, takes "premium" from the API
+// when present, and falls back to the threshold otherwise.
+func TestAddPrices(t *testing.T) {
+	var asked []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain := r.URL.Query().Get("domain")
+		asked = append(asked, domain)
+		switch domain {
+		case "cheap.com":
+			_, _ = w.Write([]byte(`{"available":true,"price":12.99,"currency":"USD"}`))
+		case "pricey.com":
+			_, _ = w.Write([]byte(`{"price":3000,"currency":"EUR"}`))
+		case "flagged.com":
+			_, _ = w.Write([]byte(`{"price":45,"premium":true}`))
+		default:
+			_, _ = w.Write([]byte(`{"available":true}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	var cfg runConfig
+	f := pricingFlags{enabled: true, url: srv.URL + "/check?domain={domain}", premiumOver: 100}
+	if err := f.apply(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	results := []checkResult{
+		{Domain: "cheap.com", Avail: true, Reason: ReasonNoMatch},
+		{Domain: "taken.com", Reason: ReasonTaken},
+		{Domain: "pricey.com", Avail: true, Reason: ReasonNoMatch},
+		{Domain: "flagged.com", Avail: true, Reason: ReasonNoMatch},
+		{Domain: "noprice.com", Avail: true, Reason: ReasonNoMatch},
+	}
+	_, stderr := captureOutput(t, func() { addPrices(results, cfg) })
+
+	if strings.Join(asked, " ") != "cheap.com pricey.com flagged.com noprice.com" {
+		t.Errorf("asked = %v", asked)
+	}
+	want := []priceQuote{
+		{Price: 12.99, Currency: "USD"},
+		{},
+		{Price: 3000, Currency: "EUR", Premium: true},
+		{Price: 45, Currency: "USD", Premium: true},
+		{},
+	}
+	for i, w := range want {
+		if results[i].Price != w {
+			t.Errorf("%s: quote = %+v, want %+v", results[i].Domain, results[i].Price, w)
+		}
+	}
+	if !strings.Contains(stderr, "price of noprice.com: no price in response") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	rec := results[2].record().grouped().record()
+	row := reportRow(rec, time.Now())
+	if rec.FirstYearPrice != 3000 || row[5] != "premium" || row[7] != "3000.00 EUR" {
+		t.Errorf("record = %+v, row = %q", rec, row)
+	}
+}
+
+// TestQuoteDomainGoDaddyMicros converts GoDaddy's micro-unit prices.
+func TestQuoteDomainGoDaddyMicros(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"available":true,"price":11990000,"currency":"USD","period":1}`))
+	}))
+	t.Cleanup(srv.Close)
+	q, err := quoteDomain(fakeHTTPClient{srv}, defaultPricingURL, "sso-key k:s", "a.com", defaultPremiumOver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Price != 11.99 || q.Premium {
+		t.Errorf("quote = %+v", q)
+	}
+}
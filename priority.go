@@ -0,0 +1,57 @@
+package talia
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Orders for --order: the file's, or highest priority first.
+const (
+	orderFile     = "file"
+	orderPriority = "priority"
+)
+
+// parseOrder validates an --order value.
+func parseOrder(s string) (string, error) {
+	switch s {
+	case "", orderFile:
+		return orderFile, nil
+	case orderPriority:
+		return orderPriority, nil
+	}
+	return "", fmt.Errorf("--order must be %q or %q", orderFile, orderPriority)
+}
+
+// checkOrder returns the indexes of records in the order a run checks them:
+// as they are for orderFile, or by descending priority for orderPriority.
+// The sort is stable, so records of equal priority keep the file's order.
+func checkOrder(order string, records []DomainRecord) []int {
+	idx := make([]int, len(records))
+	for i := range idx {
+		idx[i] = i
+	}
+	if order == orderPriority {
+		slices.SortStableFunc(idx, func(a, b int) int {
+			return records[b].Priority - records[a].Priority
+		})
+	}
+	return idx
+}
+
+// checkRecords checks the domains of records in cfg's --order and returns
+// the results in the records' order, so they are written back in place.
+// With --run-timeout or --stop-on-available, the domains left unchecked are
+// the last in that order.
+func checkRecords(records []DomainRecord, cfg runConfig) []checkResult {
+	order := checkOrder(cfg.order, records)
+	names := make([]string, len(order))
+	for i, j := range order {
+		names[i] = records[j].Domain
+	}
+	checked := checkDomains(names, cfg)
+	results := make([]checkResult, len(checked))
+	for i, j := range order {
+		results[j] = checked[i]
+	}
+	return results
+}
@@ -0,0 +1,59 @@
+package talia
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestCheckOrder sorts by descending priority, keeping the file's order for
+// ties, and leaves the file's order alone otherwise.
+func TestCheckOrder(t *testing.T) {
+	t.Parallel()
+	records := []DomainRecord{{Domain: "a"}, {Domain: "b", Priority: 2}, {Domain: "c", Priority: -1}, {Domain: "d", Priority: 2}}
+	if got := checkOrder(orderPriority, records); !slices.Equal(got, []int{1, 3, 0, 2}) {
+		t.Errorf("priority order = %v", got)
+	}
+	if got := checkOrder(orderFile, records); !slices.Equal(got, []int{0, 1, 2, 3}) {
+		t.Errorf("file order = %v", got)
+	}
+}
+
+// TestRunCLI_OrderPriority queries the highest priority first and writes the
+// results back in the file's order with their priorities.
+func TestRunCLI_OrderPriority(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{
+		{Domain: "low.com"},
+		{Domain: "top.com", Priority: 5},
+		{Domain: "mid.com", Priority: 1},
+	}})
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--order=priority", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"top.com", "mid.com", "low.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Available) != 3 || data.Available[0].Domain != "low.com" || data.Available[1].Priority != 5 {
+		t.Errorf("available = %+v", data.Available)
+	}
+}
+
+// TestRunCLI_OrderInvalid rejects unknown orders.
+func TestRunCLI_OrderInvalid(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "a.com"}}})
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--order=random", path}); code == 0 {
+			t.Error("exit 0, want a usage error")
+		}
+	})
+	if !strings.Contains(stderr, `--order must be "file" or "priority"`) {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,124 @@
+package talia
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// profileSectionPrefix starts the section headers of named profiles in
+// config.env: "[profile.com-drops]".
+const profileSectionPrefix = "profile."
+
+// profile is one named profile from config.env. Lowercase keys are flag
+// defaults, named like the flags without dashes ("whois", "sleep",
+// "output-file", "notify"); UPPER_CASE keys are environment variables.
+type profile struct {
+	name  string
+	flags map[string]string
+	env   map[string]string
+}
+
+// activeProfile is the profile selected for the current RunCLI call, if
+// any (see loadProfile).
+var activeProfile profile
+
+// readProfile reads the [profile.<name>] section of the config file at path.
+func readProfile(path, name string) (profile, error) {
+	p := profile{name: name, flags: map[string]string{}, env: map[string]string{}}
+	file, err := os.Open(path)
+	if err != nil {
+		return p, err
+	}
+	defer func() { _ = file.Close() }()
+
+	found, in := false, false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if section, ok := sectionHeader(line); ok {
+			in = section == profileSectionPrefix+name
+			found = found || in
+			continue
+		}
+		key, value, ok := parseEnvLine(line)
+		if !in || !ok {
+			continue
+		}
+		if key == strings.ToLower(key) {
+			p.flags[strings.TrimLeft(key, "-")] = value
+		} else {
+			p.env[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return p, err
+	}
+	if !found {
+		return p, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	return p, nil
+}
+
+// loadProfile selects the profile named by TALIA_PROFILE (--profile, or set
+// in a config file) from config.env in the config directory and exports its
+// environment variables. They override values loaded from .env and
+// config.env, but not the shell's: shell holds the variables that were set
+// before any file was loaded. With no profile named it clears the active
+// one.
+func loadProfile(shell map[string]bool) error {
+	activeProfile = profile{}
+	name := os.Getenv("TALIA_PROFILE")
+	if name == "" {
+		return nil
+	}
+	dir, err := configDir()
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+	p, err := readProfile(filepath.Join(dir, configFileName), name)
+	if err != nil {
+		return err
+	}
+	for key, value := range p.env {
+		if shell[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	activeProfile = p
+	return nil
+}
+
+// environKeys returns the names of the variables currently set.
+func environKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, kv := range os.Environ() {
+		k, _, _ := strings.Cut(kv, "=")
+		keys[k] = true
+	}
+	return keys
+}
+
+// applyProfile sets the active profile's flag defaults on fs before it
+// parses the command line, so explicit flags still win. Keys that fs has no
+// flag for are skipped: one profile can serve check runs and "talia import"
+// alike.
+func applyProfile(fs *flag.FlagSet) error {
+	for _, k := range slices.Sorted(maps.Keys(activeProfile.flags)) {
+		if fs.Lookup(k) == nil {
+			continue
+		}
+		if err := fs.Set(k, activeProfile.flags[k]); err != nil {
+			return fmt.Errorf("profile %s: invalid %s: %w", activeProfile.name, k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `OPENAI_MODEL=base
+
+[profile.com-drops]
+whois = %s
+sleep = 0s
+--output-file = "%s"
+TALIA_RUN_ID = drops-run
+
+[profile.client-x]
+sleep = soon
+`
+
+// writeProfiles writes config.env with testProfiles into a temp config
+// directory and points TALIA_CONFIG_DIR at it.
+func writeProfiles(t *testing.T, whois, output string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("TALIA_CONFIG_DIR", dir)
+	path := filepath.Join(dir, configFileName)
+	content := strings.Replace(strings.Replace(testProfiles, "%s", whois, 1), "%s", output, 1)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestReadProfile splits flag defaults from environment variables and keeps
+// profile sections out of LoadEnvFile.
+func TestReadProfile(t *testing.T) {
+	path := writeProfiles(t, "w:43", "out.json")
+	p, err := readProfile(path, "com-drops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.flags["whois"] != "w:43" || p.flags["output-file"] != "out.json" || p.env["TALIA_RUN_ID"] != "drops-run" || len(p.flags) != 3 {
+		t.Errorf("profile = %+v", p)
+	}
+	if _, err := readProfile(path, "nope"); err == nil || !strings.Contains(err.Error(), `profile "nope" not found`) {
+		t.Errorf("missing profile error = %v", err)
+	}
+
+	t.Setenv("OPENAI_MODEL", "")
+	os.Unsetenv("OPENAI_MODEL")
+	t.Setenv("whois", "")
+	os.Unsetenv("whois")
+	if err := LoadEnvFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("OPENAI_MODEL") != "base" {
+		t.Error("top-level variable not loaded")
+	}
+	if _, set := os.LookupEnv("whois"); set {
+		t.Error("profile key loaded as an environment variable")
+	}
+}
+
+// TestRunCLI_Profile runs with a profile's flag defaults and environment,
+// lets explicit flags win, and rejects unknown profiles and bad values.
+func TestRunCLI_Profile(t *testing.T) {
+	srv := newWhoisServer(t)
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.json")
+	writeProfiles(t, srv.Addr, output)
+	t.Setenv("TALIA_PROFILE", "")
+	t.Setenv("TALIA_RUN_ID", "")
+	os.Unsetenv("TALIA_RUN_ID")
+
+	input := filepath.Join(dir, "in.json")
+	if err := os.WriteFile(input, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--profile=com-drops", input}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"a.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+	if raw, err := os.ReadFile(output); err != nil || !strings.Contains(string(raw), `"runId": "drops-run"`) {
+		t.Errorf("output = %s, %v (stdout %q)", raw, err, stdout)
+	}
+
+	// An explicit flag beats the profile; "talia check" has no --output-file
+	// and ignores it.
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "--profile", "com-drops", "--whois=" + srv.Addr, "b.com"}); code != 0 {
+			t.Errorf("check: exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"a.com", "b.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+
+	for _, tt := range []struct{ profile, want string }{
+		{"nope", `profile "nope" not found`},
+		{"client-x", "profile client-x: invalid sleep"},
+	} {
+		_, stderr := captureOutput(t, func() {
+			if code := RunCLI([]string{"--profile=" + tt.profile, input}); code != 1 {
+				t.Errorf("%s: exit %d, want 1", tt.profile, code)
+			}
+		})
+		if !strings.Contains(stderr, tt.want) {
+			t.Errorf("%s: stderr = %q", tt.profile, stderr)
+		}
+	}
+}
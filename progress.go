@@ -2,6 +2,7 @@ package talia
 
 import (
 	"fmt"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,16 +23,61 @@ const (
 	symbolError     = "⚠"
 )
 
+// rateWindow is the number of recent completions used for the rolling rate.
+const rateWindow = 20
+
 // progress tracks the current position in a series of operations (thread-safe).
+// Each result is printed on its own line followed by the check rate and an
+// estimated time remaining. On a terminal the rate/ETA is a separate status
+// line that is redrawn in place; otherwise it is appended to each result line.
 type progress struct {
 	current int64
 	total   int64
-	mu      sync.Mutex // protects printing
+	mu      sync.Mutex // protects printing and recent
+
+	out        *os.File
+	tty        bool
+	color      bool
+	statusLine bool        // a status line is currently drawn (tty only)
+	recent     []time.Time // start time, then the latest completion times
+}
+
+// newProgress creates a new progress counter with the given total that
+// prints to out.
+func newProgress(out *os.File, total int) *progress {
+	return &progress{
+		total:  int64(total),
+		out:    out,
+		tty:    isTerminal(out),
+		color:  useColor(out),
+		recent: []time.Time{time.Now()},
+	}
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// useColor reports whether ANSI colors should be written to f: only on a
+// terminal, and never when the NO_COLOR environment variable is set.
+func useColor(f *os.File) bool {
+	return os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+// paint wraps s in the given ANSI color when enabled is true.
+func paint(enabled bool, color, s string) string {
+	if !enabled {
+		return s
+	}
+	return color + s + colorReset
 }
 
-// newProgress creates a new progress counter with the given total.
-func newProgress(total int) *progress {
-	return &progress{total: int64(total)}
+// AddTotal grows the expected total, for runs where work is discovered while
+// checks are already in progress.
+func (p *progress) AddTotal(n int) {
+	atomic.AddInt64(&p.total, int64(n))
 }
 
 // IncrementAndPrint atomically increments the counter and prints the check result.
@@ -56,8 +102,47 @@ func (p *progress) IncrementAndPrint(domain string, available bool, reason Avail
 	}
 
 	p.mu.Lock()
-	fmt.Printf("[%d/%d] %s %s%s%s %s\n", current, p.total, domain, color, symbol, colorReset, status)
-	p.mu.Unlock()
+	defer p.mu.Unlock()
+	p.recent = append(p.recent, time.Now())
+	if len(p.recent) > rateWindow+1 {
+		p.recent = p.recent[1:]
+	}
+	total := atomic.LoadInt64(&p.total)
+	line := fmt.Sprintf("[%d/%d] %s %s %s", current, total, domain, paint(p.color, color, symbol), status)
+	eta := p.rateETA(total - current)
+
+	if !p.tty {
+		fmt.Fprintf(p.out, "%s  (%s)\n", line, eta)
+		return
+	}
+	fmt.Fprintf(p.out, "\r\033[K%s\n  %d/%d · %s", line, current, total, eta)
+	p.statusLine = true
+}
+
+// rateETA formats the rolling check rate and the estimated time for the
+// remaining checks. Callers must hold p.mu.
+func (p *progress) rateETA(remaining int64) string {
+	elapsed := p.recent[len(p.recent)-1].Sub(p.recent[0])
+	if elapsed <= 0 {
+		return "-- /s"
+	}
+	rate := float64(len(p.recent)-1) / elapsed.Seconds()
+	if remaining <= 0 {
+		return fmt.Sprintf("%.1f/s", rate)
+	}
+	eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+	return fmt.Sprintf("%.1f/s, ETA %s", rate, eta.Round(time.Second))
+}
+
+// Finish clears the in-place status line so later output starts on a clean
+// line. It is a no-op when output is not a terminal.
+func (p *progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.statusLine {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.statusLine = false
+	}
 }
 
 // checkStats tracks statistics for domain checks (thread-safe).
@@ -66,11 +151,14 @@ type checkStats struct {
 	taken     int64
 	errors    int64
 	startTime time.Time
+	out       *os.File
+	color     bool
 }
 
-// newCheckStats creates a new stats tracker and records the start time.
-func newCheckStats() *checkStats {
-	return &checkStats{startTime: time.Now()}
+// newCheckStats creates a new stats tracker that prints to out and records
+// the start time.
+func newCheckStats(out *os.File) *checkStats {
+	return &checkStats{startTime: time.Now(), out: out, color: useColor(out)}
 }
 
 // Record updates stats based on a check result (thread-safe).
@@ -88,14 +176,14 @@ func (s *checkStats) Record(available bool, reason AvailabilityReason) {
 // PrintSummary outputs a summary of the check results.
 func (s *checkStats) PrintSummary() {
 	elapsed := time.Since(s.startTime)
-	fmt.Printf("\nDone in %.1fs\n", elapsed.Seconds())
+	fmt.Fprintf(s.out, "\nDone in %.1fs\n", elapsed.Seconds())
 	if s.available > 0 {
-		fmt.Printf("  %s%s %d available%s\n", colorGreen, symbolAvailable, s.available, colorReset)
+		fmt.Fprintf(s.out, "  %s\n", paint(s.color, colorGreen, fmt.Sprintf("%s %d available", symbolAvailable, s.available)))
 	}
 	if s.taken > 0 {
-		fmt.Printf("  %s%s %d taken%s\n", colorRed, symbolTaken, s.taken, colorReset)
+		fmt.Fprintf(s.out, "  %s\n", paint(s.color, colorRed, fmt.Sprintf("%s %d taken", symbolTaken, s.taken)))
 	}
 	if s.errors > 0 {
-		fmt.Printf("  %s%s %d errors%s\n", colorYellow, symbolError, s.errors, colorReset)
+		fmt.Fprintf(s.out, "  %s\n", paint(s.color, colorYellow, fmt.Sprintf("%s %d errors", symbolError, s.errors)))
 	}
 }
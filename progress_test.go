@@ -0,0 +1,80 @@
+package talia
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestProgressRateETA computes the rolling rate and ETA from fixed timestamps.
+func TestProgressRateETA(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &progress{recent: []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second)}}
+	if got := p.rateETA(10); got != "1.0/s, ETA 10s" {
+		t.Errorf("rateETA = %q", got)
+	}
+	if got := p.rateETA(0); got != "1.0/s" {
+		t.Errorf("rateETA with nothing left = %q", got)
+	}
+
+	// Only the last rateWindow completions count.
+	for i := range 2 * rateWindow {
+		p.recent = append(p.recent, start.Add(time.Hour+time.Duration(i)*100*time.Millisecond))
+		if len(p.recent) > rateWindow+1 {
+			p.recent = p.recent[1:]
+		}
+	}
+	if got := p.rateETA(100); got != "10.0/s, ETA 10s" {
+		t.Errorf("rolling rateETA = %q", got)
+	}
+}
+
+// TestProgressNonTTYAppendsRate verifies piped output keeps one line per
+// result with the rate appended and no cursor or color control codes.
+func TestProgressNonTTYAppendsRate(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		p := newProgress(os.Stdout, 2)
+		p.IncrementAndPrint("a.com", true, ReasonNoMatch)
+		p.IncrementAndPrint("b.com", false, ReasonTaken)
+		p.Finish()
+	})
+	if strings.Contains(stdout, "\033") {
+		t.Errorf("ANSI codes in piped output: %q", stdout)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %q", stdout)
+	}
+	if !strings.HasPrefix(lines[0], "[1/2] a.com") || !strings.Contains(lines[0], "/s") {
+		t.Errorf("unexpected line: %q", lines[0])
+	}
+	if strings.Contains(stdout, "\r") {
+		t.Errorf("in-place codes in piped output: %q", stdout)
+	}
+}
+
+// TestCheckStatsSummaryPlainWhenPiped ensures the summary has no colors when
+// stdout is not a terminal.
+func TestCheckStatsSummaryPlainWhenPiped(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		s := newCheckStats(os.Stdout)
+		s.Record(true, ReasonNoMatch)
+		s.Record(false, ReasonError)
+		s.PrintSummary()
+	})
+	if strings.Contains(stdout, "\033") || !strings.Contains(stdout, "  ✓ 1 available\n") {
+		t.Errorf("unexpected summary: %q", stdout)
+	}
+}
+
+func TestPaint(t *testing.T) {
+	t.Parallel()
+	if got := paint(true, colorGreen, "ok"); got != colorGreen+"ok"+colorReset {
+		t.Errorf("paint(true) = %q", got)
+	}
+	if got := paint(false, colorGreen, "ok"); got != "ok" {
+		t.Errorf("paint(false) = %q", got)
+	}
+}
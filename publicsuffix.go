@@ -0,0 +1,69 @@
+package talia
+
+import "strings"
+
+// multiLabelSuffixes are the second-level public suffixes Talia knows about:
+// registries that sell names under a category label (brand.co.uk) rather
+// than directly under the TLD. Everything else is treated as a single-label
+// suffix. The list covers the common ccTLD categories, not the whole Public
+// Suffix List.
+var multiLabelSuffixes = map[string]bool{
+	"co.uk": true, "org.uk": true, "me.uk": true, "ltd.uk": true, "plc.uk": true, "net.uk": true, "sch.uk": true,
+	"com.au": true, "net.au": true, "org.au": true, "id.au": true, "asn.au": true,
+	"co.nz": true, "net.nz": true, "org.nz": true, "geek.nz": true,
+	"co.jp": true, "ne.jp": true, "or.jp": true,
+	"co.kr": true, "or.kr": true,
+	"co.in": true, "net.in": true, "org.in": true,
+	"co.za": true, "org.za": true, "net.za": true,
+	"co.il": true, "org.il": true,
+	"com.br": true, "net.br": true, "org.br": true,
+	"com.ar": true, "com.mx": true, "com.co": true, "com.pe": true,
+	"com.cn": true, "net.cn": true, "org.cn": true,
+	"com.hk": true, "com.tw": true, "com.sg": true, "com.my": true, "com.ph": true,
+	"com.tr": true, "com.ua": true, "com.pl": true,
+}
+
+// publicSuffix returns the public suffix of domain, lowercased: "co.uk" for
+// brand.co.uk, otherwise the last label as with tldOf.
+func publicSuffix(domain string) string {
+	d := strings.ToLower(strings.TrimSuffix(domain, "."))
+	if i := strings.LastIndex(d, "."); i >= 0 {
+		if j := strings.LastIndex(d[:i], "."); multiLabelSuffixes[d[j+1:]] {
+			return d[j+1:]
+		}
+	}
+	return tldOf(d)
+}
+
+// isPublicSuffix reports whether domain is itself a known multi-label public
+// suffix, such as "co.uk", which cannot be registered.
+func isPublicSuffix(domain string) bool {
+	return multiLabelSuffixes[strings.ToLower(strings.TrimSuffix(domain, "."))]
+}
+
+// registrableDomain returns the name that would be registered for domain:
+// the label just above its public suffix plus the suffix, e.g. brand.co.uk
+// for www.brand.co.uk. It returns "" if domain is a bare suffix.
+func registrableDomain(domain string) string {
+	d := strings.ToLower(strings.TrimSuffix(domain, "."))
+	suffix := publicSuffix(d)
+	rest, ok := strings.CutSuffix(d, "."+suffix)
+	if !ok || rest == "" {
+		return ""
+	}
+	return rest[strings.LastIndex(rest, ".")+1:] + "." + suffix
+}
+
+// suffixKeys returns the per-TLD override keys for domain, most specific
+// first: [".co.uk", ".uk"] for brand.co.uk, [".com"] for brand.com, and
+// none for a name without a dot.
+func suffixKeys(domain string) []string {
+	if !strings.Contains(strings.TrimSuffix(domain, "."), ".") {
+		return nil
+	}
+	suffix, tld := publicSuffix(domain), tldOf(strings.TrimSuffix(domain, "."))
+	if suffix == tld {
+		return []string{"." + tld}
+	}
+	return []string{"." + suffix, "." + tld}
+}
@@ -0,0 +1,123 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPublicSuffix splits names under second-level suffixes at the right
+// label.
+func TestPublicSuffix(t *testing.T) {
+	t.Parallel()
+	tests := []struct{ domain, suffix, registrable string }{
+		{"brand.co.uk", "co.uk", "brand.co.uk"},
+		{"www.Brand.COM.au", "com.au", "brand.com.au"},
+		{"brand.uk", "uk", "brand.uk"},
+		{"ns1.example.com.", "com", "example.com"},
+		{"co.uk", "co.uk", ""},
+	}
+	for _, tt := range tests {
+		if got := publicSuffix(tt.domain); got != tt.suffix {
+			t.Errorf("publicSuffix(%q) = %q, want %q", tt.domain, got, tt.suffix)
+		}
+		if got := registrableDomain(tt.domain); got != tt.registrable {
+			t.Errorf("registrableDomain(%q) = %q, want %q", tt.domain, got, tt.registrable)
+		}
+	}
+	if got := suffixKeys("brand.co.uk"); !slices.Equal(got, []string{".co.uk", ".uk"}) {
+		t.Errorf("suffixKeys = %v", got)
+	}
+}
+
+// TestRouteServerSecondLevel routes brand.co.uk to the .uk registry, and to a
+// co.uk entry when the database has one; overrides keyed on .co.uk apply.
+func TestRouteServerSecondLevel(t *testing.T) {
+	dir := useServerCache(t)
+	if got, err := routeServer("brand.co.uk"); err != nil || got != "whois.nic.uk:43" {
+		t.Errorf("routeServer(brand.co.uk) = %q, %v", got, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, serversFileName), []byte(`{"com.au":{"whois":"au.example:43"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resetServerDB()
+	if got, err := routeServer("brand.com.au"); err != nil || got != "au.example:43" {
+		t.Errorf("routeServer(brand.com.au) = %q, %v", got, err)
+	}
+	if got, err := routeServer("brand.au"); err != nil || got != "whois.auda.org.au:43" {
+		t.Errorf("routeServer(brand.au) = %q, %v", got, err)
+	}
+
+	cfg := runConfig{sleep: time.Second, sleepOverrides: sleepOverrides{".co.uk": 3 * time.Second, ".uk": 2 * time.Second}}
+	if got := cfg.baseSleepFor("brand.co.uk"); got != 3*time.Second {
+		t.Errorf("sleep for brand.co.uk = %v", got)
+	}
+	if got := cfg.baseSleepFor("brand.uk"); got != 2*time.Second {
+		t.Errorf("sleep for brand.uk = %v", got)
+	}
+}
+
+// TestRunCLICheckPublicSuffix rejects a bare second-level suffix.
+func TestRunCLICheckPublicSuffix(t *testing.T) {
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "co.uk"}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "public suffix") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestNormalizeListDomainSecondLevel keeps registrable names under any
+// suffix and rejects bare suffixes and subdomains.
+func TestNormalizeListDomainSecondLevel(t *testing.T) {
+	t.Parallel()
+	for in, want := range map[string]string{
+		"Brand.co.uk.":    "brand.co.uk",
+		"brand.io":        "brand.io",
+		"brand.com.com":   "brand.com",
+		"co.uk":           "",
+		"www.brand.co.uk": "",
+		"-brand.co.uk":    "",
+		"brand":           "",
+	} {
+		if got := normalizeListDomain(in); got != want {
+			t.Errorf("normalizeListDomain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestDupKeySecondLevel treats folded spellings of a name under a
+// second-level suffix as duplicates, but not its subdomains.
+func TestDupKeySecondLevel(t *testing.T) {
+	t.Parallel()
+	dups := findArrayDuplicates([]DomainRecord{{Domain: "brand.co.uk"}, {Domain: "shop.brand.co.uk"}, {Domain: "Brand.co.uk."}, {Domain: "other.co.uk"}})
+	if len(dups) != 1 || dups[0].Domain != "brand.co.uk" || !slices.Equal(dups[0].Locations, []string{"[0]", "[2]"}) {
+		t.Errorf("duplicates = %+v", dups)
+	}
+}
+
+// TestRunCLI_CleanKeepsSecondLevel keeps names under second-level suffixes
+// when cleaning.
+func TestRunCLI_CleanKeepsSecondLevel(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "brand.co.uk"}, {Domain: "co.uk"}}})
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--clean", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Removed 1 invalid domains") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Unverified) != 1 || data.Unverified[0].Domain != "brand.co.uk" {
+		t.Errorf("unverified = %+v", data.Unverified)
+	}
+}
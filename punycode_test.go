@@ -0,0 +1,25 @@
+package talia
+
+import "testing"
+
+// TestIDNToASCII checks the RFC 3492 encoder against known labels.
+func TestIDNToASCII(t *testing.T) {
+	t.Parallel()
+	tests := []struct{ in, want string }{
+		{"bücher.com", "xn--bcher-kva.com"},
+		{"München.com", "xn--mnchen-3ya.com"},
+		{"café.com", "xn--caf-dma.com"},
+		{"españa.com", "xn--espaa-rta.com"},
+		{"日本語.com", "xn--wgv71a119e.com"},
+		{"ascii.com", "ascii.com"},
+	}
+	for _, tt := range tests {
+		got, err := idnToASCII(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("idnToASCII(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := idnToASCII("caf€.com"); err == nil {
+		t.Error("expected an error for a symbol in the label")
+	}
+}
@@ -0,0 +1,58 @@
+package talia
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryFormats holds --query-format templates: the query line sent for a
+// domain, with "%s" standing for the domain. Keys are TLDs (".jp", ".co.jp"), server
+// addresses ("whois.jprs.jp:43"), or "" for the default.
+type queryFormats map[string]string
+
+// parseQueryFormats parses a comma-separated list of templates, e.g.
+// "domain %s" (every server) or "whois.denic.de:43=-T dn %s,.jp=%s/e". An
+// item is keyed when the text before its first "=" is a TLD (leading dot) or
+// a host:port; otherwise the whole item is the default template, so a bare
+// "=%s" works as is.
+func parseQueryFormats(s string) (queryFormats, error) {
+	out := queryFormats{}
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, format := "", item
+		if k, v, ok := strings.Cut(item, "="); ok && (strings.HasPrefix(k, ".") || strings.Contains(k, ":")) {
+			key, format = strings.ToLower(strings.TrimSpace(k)), v
+		}
+		if strings.Count(format, "%s") != 1 {
+			return nil, fmt.Errorf("invalid query format %q: must contain %%s exactly once", item)
+		}
+		out[key] = format
+	}
+	return out, nil
+}
+
+// forDomain returns the template for domain on server: a TLD entry wins over
+// a server entry, which wins over the default. "" means the bare domain.
+func (q queryFormats) forDomain(domain, server string) string {
+	for _, key := range suffixKeys(domain) {
+		if f, ok := q[key]; ok {
+			return f
+		}
+	}
+	if f, ok := q[strings.ToLower(server)]; ok {
+		return f
+	}
+	return q[""]
+}
+
+// whoisQuery returns the query line for domain under format ("" sends the
+// domain as is).
+func whoisQuery(format, domain string) string {
+	if format == "" {
+		return domain
+	}
+	return strings.Replace(format, "%s", domain, 1)
+}
@@ -0,0 +1,75 @@
+package talia
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestParseQueryFormats covers default and keyed templates and rejects
+// templates without exactly one %s.
+func TestParseQueryFormats(t *testing.T) {
+	t.Parallel()
+	q, err := parseQueryFormats("=%s, Whois.DENIC.de:43=-T dn %s,.jp=%s/e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := queryFormats{"": "=%s", "whois.denic.de:43": "-T dn %s", ".jp": "%s/e"}
+	if len(q) != len(want) {
+		t.Fatalf("got %v, want %v", q, want)
+	}
+	for k, v := range want {
+		if q[k] != v {
+			t.Errorf("q[%q] = %q, want %q", k, q[k], v)
+		}
+	}
+	if q, err := parseQueryFormats("domain %s"); err != nil || q[""] != "domain %s" {
+		t.Errorf("default only: %v, %v", q, err)
+	}
+	for _, bad := range []string{"domain", "%s %s", ".jp=x"} {
+		if _, err := parseQueryFormats(bad); err == nil {
+			t.Errorf("parseQueryFormats(%q): expected error", bad)
+		}
+	}
+}
+
+// TestQueryFormatsForDomain checks TLD > server > default precedence and the
+// query line built from it.
+func TestQueryFormatsForDomain(t *testing.T) {
+	t.Parallel()
+	q := queryFormats{"": "domain %s", "whois.denic.de:43": "-T dn %s", ".jp": "%s/e"}
+	tests := []struct{ domain, server, want string }{
+		{"a.jp", "whois.denic.de:43", "a.jp/e"},
+		{"a.de", "whois.denic.de:43", "-T dn a.de"},
+		{"a.com", "whois.verisign-grs.com:43", "domain a.com"},
+	}
+	for _, tt := range tests {
+		if got := whoisQuery(q.forDomain(tt.domain, tt.server), tt.domain); got != tt.want {
+			t.Errorf("query for %s on %s = %q, want %q", tt.domain, tt.server, got, tt.want)
+		}
+	}
+	if got := whoisQuery(queryFormats(nil).forDomain("a.com", "x:43"), "a.com"); got != "a.com" {
+		t.Errorf("no formats: %q", got)
+	}
+}
+
+// TestRunCLICheckQueryFormat sends the templated query line to the server.
+func TestRunCLICheckQueryFormat(t *testing.T) {
+	srv := newWhoisServer(t)
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "--whois=" + srv.Addr, "--sleep=0s", "--query-format=domain %s", "a.com"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"domain a.com"}) {
+		t.Errorf("queries = %q", got)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "--query-format=domain", "a.com"}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if stderr == "" {
+		t.Error("expected an error for a template without a placeholder")
+	}
+}
@@ -0,0 +1,42 @@
+package talia
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// readOnlyConflicts are the file-mode flags that write files of their own,
+// which --read-only rejects rather than half-honoring.
+var readOnlyConflicts = []string{
+	"clean", "dedupe", "strip-logs", "migrate-checked-at", "migrate-status",
+	"merge", "export-available", "suggest", "split-output", "upload",
+	"log-budget", "pprof",
+}
+
+// readOnlyConflict returns the first flag set on fs that --read-only can't
+// be combined with, or "".
+func readOnlyConflict(fs *flag.FlagSet) string {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range readOnlyConflicts {
+		if set[name] {
+			return name
+		}
+	}
+	return ""
+}
+
+// emitReadOnly prints out, the document a --read-only run would have written
+// to path, on stdout, where it is alone since status output goes to stderr.
+// With --print=available stdout is kept for the domain names instead.
+func emitReadOnly(cfg runConfig, path string, out []byte) {
+	if !cfg.printAvailable {
+		if !bytes.HasSuffix(out, []byte("\n")) {
+			out = append(out, '\n')
+		}
+		_, _ = os.Stdout.Write(out)
+	}
+	fmt.Fprintln(cfg.status(), "Read-only: left", path, "unchanged")
+}
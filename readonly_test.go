@@ -0,0 +1,72 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestRunCLI_ReadOnly checks both file kinds, prints what would have been
+// written, and leaves the files, journal, and WHOIS cache untouched.
+func TestRunCLI_ReadOnly(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("TALIA_CACHE_DIR", cacheDir)
+	srv := newWhoisServer(t)
+	srv.Handle("free.com", taliatest.Response{Body: taliatest.Available("free.com")})
+
+	dir := t.TempDir()
+	array := filepath.Join(dir, "list.json")
+	if err := os.WriteFile(array, []byte(`[{"domain":"free.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	grouped := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "free.com"}}})
+
+	for _, tc := range []struct {
+		path string
+		args []string
+	}{
+		{array, nil},
+		{grouped, nil},
+		{array, []string{"--grouped-output"}},
+	} {
+		before, _ := os.ReadFile(tc.path)
+		args := append([]string{"--read-only", "--whois=" + srv.Addr, "--sleep=0s", "--whois-cache=1h"}, tc.args...)
+		stdout, stderr := captureOutput(t, func() {
+			if code := RunCLI(append(args, tc.path)); code != 0 {
+				t.Errorf("%s %v: exit %d", tc.path, tc.args, code)
+			}
+		})
+		if after, _ := os.ReadFile(tc.path); string(after) != string(before) {
+			t.Errorf("%s %v: file changed to %s", tc.path, tc.args, after)
+		}
+		if !json.Valid([]byte(stdout)) || !strings.Contains(stdout, `"reason": "NO_MATCH"`) {
+			t.Errorf("%s %v: stdout = %q", tc.path, tc.args, stdout)
+		}
+		if !strings.Contains(stderr, "Read-only: left "+tc.path+" unchanged") {
+			t.Errorf("%s %v: stderr = %q", tc.path, tc.args, stderr)
+		}
+		if _, err := os.Stat(journalPath(tc.path)); !os.IsNotExist(err) {
+			t.Errorf("%s %v: journal left behind (%v)", tc.path, tc.args, err)
+		}
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("cache directory written: %v", entries)
+	}
+}
+
+// TestRunCLI_ReadOnlyConflict rejects flags that write files of their own.
+func TestRunCLI_ReadOnlyConflict(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "free.com"}}})
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--read-only", "--split-output=" + t.TempDir(), path}); code == 0 {
+			t.Error("exit 0, want a usage error")
+		}
+	})
+	if !strings.Contains(stderr, "--read-only can't be combined with --split-output") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
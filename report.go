@@ -0,0 +1,338 @@
+package talia
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats for "talia check" and "talia report".
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+// validFormat reports whether f is one of the record output formats.
+func validFormat(f string) bool {
+	return f == formatTable || f == formatCSV || f == formatJSON
+}
+
+// recordFilter selects records for output. The zero value matches everything.
+type recordFilter struct {
+	registrar string // case-insensitive substring of the registrar
+
+	// minAge and maxAge bound the domain age in years; 0 means unbounded.
+	// Records without a creation date fail either bound.
+	minAge, maxAge float64
+
+	// dropping keeps only domains on hold or in redemption.
+	dropping bool
+
+	nsContains string // case-insensitive substring of any name server
+	parked     bool   // keeps only likely parked or for-sale domains
+	deadSite   bool   // keeps only domains whose --http-probe failed
+}
+
+// addFilterFlags registers the record filter flags on fs.
+func addFilterFlags(fs *flag.FlagSet) *recordFilter {
+	f := &recordFilter{}
+	fs.StringVar(&f.registrar, "filter-registrar", "", "Only show domains whose registrar contains this text (case-insensitive), e.g. GoDaddy")
+	fs.Float64Var(&f.minAge, "min-age", 0, "Only show domains registered at least this many years ago")
+	fs.Float64Var(&f.maxAge, "max-age", 0, "Only show domains registered at most this many years ago")
+	fs.BoolVar(&f.dropping, "dropping", false, "Only show taken domains on hold or in redemption, which may soon become registrable")
+	fs.StringVar(&f.nsContains, "ns-contains", "", "Only show domains with a name server containing this text (case-insensitive), e.g. sedoparking")
+	fs.BoolVar(&f.parked, "parked", false, "Only show taken domains that are likely parked or for sale, which are often acquirable")
+	fs.BoolVar(&f.deadSite, "dead-site", false, "Only show taken domains whose --http-probe found no working site")
+	return f
+}
+
+// match reports whether rec passes every filter that is set, with ages taken
+// at now.
+func (f recordFilter) match(rec DomainRecord, now time.Time) bool {
+	if f.registrar != "" && !strings.Contains(strings.ToLower(rec.Registrar), strings.ToLower(f.registrar)) {
+		return false
+	}
+	if f.dropping && !rec.OnHold && !rec.InRedemption {
+		return false
+	}
+	if f.nsContains != "" && !nameServerContains(rec.NameServers, strings.ToLower(f.nsContains)) {
+		return false
+	}
+	if f.parked && !isParked(rec) {
+		return false
+	}
+	if f.deadSite && !isDeadSite(rec) {
+		return false
+	}
+	if f.minAge > 0 || f.maxAge > 0 {
+		age, ok := domainAge(rec.CreatedAt, now)
+		if !ok || (f.minAge > 0 && age < f.minAge) || (f.maxAge > 0 && age > f.maxAge) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the records that match f, in order.
+func (f recordFilter) apply(records []DomainRecord) []DomainRecord {
+	now := time.Now()
+	var out []DomainRecord
+	for _, rec := range records {
+		if f.match(rec, now) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// Orders for --sort. The default keeps the file's order.
+const (
+	sortDomain = "domain"
+	sortValue  = "value"
+)
+
+// addSortFlag registers --sort on fs.
+func addSortFlag(fs *flag.FlagSet) *string {
+	return fs.String("sort", "", "Sort by 'domain' (alphabetically) or 'value' (highest estimated value first); default: file order")
+}
+
+// validSort reports whether s is a --sort value.
+func validSort(s string) bool {
+	return s == "" || s == sortDomain || s == sortValue
+}
+
+// sortRecords sorts records in place by domain or by estimated value
+// (highest first, unvalued records last, ties by domain). Any other order
+// leaves them unchanged.
+func sortRecords(records []DomainRecord, by string) {
+	switch by {
+	case sortDomain:
+		slices.SortStableFunc(records, func(a, b DomainRecord) int {
+			return strings.Compare(a.Domain, b.Domain)
+		})
+	case sortValue:
+		slices.SortStableFunc(records, func(a, b DomainRecord) int {
+			if c := cmp.Compare(b.EstimatedValue, a.EstimatedValue); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Domain, b.Domain)
+		})
+	}
+}
+
+// readRecords reads an array, JSON Lines, or grouped file (including its
+// pending log) as a flat list of records: available, then unavailable, then
+// unverified for grouped files. Grouped records written before "status"
+// existed get one from their bucket.
+func readRecords(path string) ([]DomainRecord, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isJSONLines(path) {
+		records, err := parseJSONLines(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return records, nil
+	}
+	var arr []DomainRecord
+	if err := json.Unmarshal(raw, &arr); err == nil {
+		return arr, nil
+	}
+	var ext ExtendedGroupedData
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := applyPendingLog(path, &ext); err != nil {
+		return nil, err
+	}
+
+	records := make([]DomainRecord, 0, len(ext.Available)+len(ext.Unavailable)+len(ext.Unverified))
+	for _, gd := range ext.Available {
+		rec := gd.record()
+		rec.Available = true
+		if rec.Status == "" {
+			rec.Status = statusFor(true, rec.Reason)
+		}
+		records = append(records, rec)
+	}
+	for _, gd := range ext.Unavailable {
+		rec := gd.record()
+		if rec.Status == "" {
+			rec.Status = statusFor(false, rec.Reason)
+		}
+		records = append(records, rec)
+	}
+	return append(records, ext.Unverified...), nil
+}
+
+// reportColumns are the columns of table and CSV output.
+var reportColumns = []string{"DOMAIN", "STATUS", "REASON", "REGISTRAR", "AGE", "FLAGS", "VALUE", "PRICE", "CONFIDENCE", "NOTES"}
+
+// reportRow returns the table and CSV cells for rec. AGE is in years at now
+// and empty when the creation date is unknown. FLAGS lists the notable
+// boolean fields of the record. VALUE is the estimated value in whole
+// dollars and PRICE the first-year price with its currency; both are empty
+// if unknown. CONFIDENCE is aged to now with DecayedConfidence, so old
+// verdicts show as less certain. NOTES is the record's notes on one line.
+func reportRow(rec DomainRecord, now time.Time) []string {
+	age := ""
+	if years, ok := domainAge(rec.CreatedAt, now); ok {
+		age = strconv.FormatFloat(years, 'f', 1, 64)
+	}
+	var flags []string
+	if rec.OnHold {
+		flags = append(flags, "hold")
+	}
+	if rec.InRedemption {
+		flags = append(flags, "redemption")
+	}
+	if rec.PrivacyProtected {
+		flags = append(flags, "privacy")
+	}
+	if rec.Premium {
+		flags = append(flags, "premium")
+	}
+	if rec.Favorite {
+		flags = append(flags, "favorite")
+	}
+	if rec.Rejected {
+		flags = append(flags, "rejected")
+	}
+	if rec.OwnedByUs {
+		flags = append(flags, "ours")
+	}
+	if isParked(rec) {
+		flags = append(flags, "parked")
+	}
+	if isDeadSite(rec) {
+		flags = append(flags, "dead")
+	}
+	value := ""
+	if rec.EstimatedValue > 0 {
+		value = strconv.FormatFloat(rec.EstimatedValue, 'f', 0, 64)
+	}
+	price := ""
+	if rec.FirstYearPrice > 0 {
+		price = strconv.FormatFloat(rec.FirstYearPrice, 'f', 2, 64) + " " + rec.PriceCurrency
+	}
+	return []string{
+		rec.Domain,
+		string(rec.Status),
+		string(rec.Reason),
+		rec.Registrar,
+		age,
+		strings.Join(flags, ","),
+		value,
+		price,
+		strconv.FormatFloat(DecayedConfidence(rec, now), 'f', 2, 64),
+		strings.Join(strings.Fields(rec.Notes), " "),
+	}
+}
+
+// writeRecords writes records to w in the given format.
+func writeRecords(w io.Writer, format string, records []DomainRecord) error {
+	now := time.Now()
+	switch format {
+	case formatJSON:
+		if records == nil {
+			records = []DomainRecord{}
+		}
+		out, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(out))
+		return err
+	default:
+		rows := make([][]string, len(records))
+		for i, rec := range records {
+			rows[i] = reportRow(rec, now)
+		}
+		return writeRows(w, format, reportColumns, rows)
+	}
+}
+
+// writeRows writes a header and rows as CSV, or as an aligned table for any
+// other format.
+func writeRows(w io.Writer, format string, columns []string, rows [][]string) error {
+	if format == formatCSV {
+		cw := csv.NewWriter(w)
+		_ = cw.Write(columns)
+		for _, row := range rows {
+			_ = cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// runReportCommand implements "talia report <file>": the records of an array
+// or grouped file are printed as a table, CSV, or JSON, optionally filtered.
+// The file is not modified.
+func runReportCommand(args []string) int {
+	fs := flag.NewFlagSet("talia report", flag.ContinueOnError)
+	format := fs.String("format", formatTable, "Output format: 'table', 'csv', or 'json'")
+	filter := addFilterFlags(fs)
+	curation := addCurationFlags(fs, "show")
+	sortBy := addSortFlag(fs)
+	ics := fs.Bool("ics", false, "Print the expiration dates of taken domains as an iCalendar file instead")
+	icsAlarms := fs.String("ics-alarms", defaultICSAlarms, "With --ics, days before each expiration to raise an alarm, comma-separated")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: talia report [--format=table|csv|json | --ics] [--sort=domain|value] [--filter-registrar=name] [--min-age=years] [--max-age=years] [--dropping] [--favorites-only] [--exclude-rejected] <json-file>")
+		return 1
+	}
+	alarmDays, err := parseICSAlarms(*icsAlarms)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if !validFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Error: --format must be %q, %q, or %q\n", formatTable, formatCSV, formatJSON)
+		return 1
+	}
+	if !validSort(*sortBy) {
+		fmt.Fprintf(os.Stderr, "Error: --sort must be %q or %q\n", sortDomain, sortValue)
+		return 1
+	}
+
+	records, err := readRecords(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading file:", err)
+		return 1
+	}
+	records = curation.apply(filter.apply(records))
+	sortRecords(records, *sortBy)
+	if *ics {
+		err = writeICS(os.Stdout, records, alarmDays, time.Now())
+	} else {
+		err = writeRecords(os.Stdout, *format, records)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		return 1
+	}
+	return 0
+}
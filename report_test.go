@@ -0,0 +1,210 @@
+package talia
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestRunReportCommandFilterRegistrar filters a grouped file by registrar and
+// prints CSV.
+func TestRunReportCommandFilterRegistrar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	data := `{
+  "available": [{"domain": "free.com", "reason": "NO_MATCH"}],
+  "unavailable": [
+    {"domain": "a.com", "reason": "TAKEN", "registrar": "GoDaddy.com, LLC"},
+    {"domain": "b.com", "reason": "TAKEN", "registrar": "NameCheap, Inc."}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"report", path, "--format=csv", "--filter-registrar=godaddy"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][0] != "a.com" || rows[1][1] != "taken" || rows[1][3] != "GoDaddy.com, LLC" {
+		t.Errorf("rows = %q", rows)
+	}
+
+	raw, _ := os.ReadFile(path)
+	if string(raw) != data {
+		t.Errorf("report modified the file: %s", raw)
+	}
+}
+
+// TestRunReportCommandArray reports an array file as JSON.
+func TestRunReportCommandArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "array.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com","available":true,"status":"available"},{"domain":"b.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"report", "--format=json", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	var recs []DomainRecord
+	if err := json.Unmarshal([]byte(stdout), &recs); err != nil {
+		t.Fatalf("unmarshal %q: %v", stdout, err)
+	}
+	if len(recs) != 2 || recs[0].Status != StatusAvailable {
+		t.Errorf("records = %+v", recs)
+	}
+}
+
+// TestRunReportCommandErrors covers usage and read errors.
+func TestRunReportCommandErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"report"},
+		{"report", "--format=xml", "x.json"},
+		{"report", filepath.Join(t.TempDir(), "missing.json")},
+	} {
+		_, _ = captureOutput(t, func() {
+			if code := RunCLI(args); code != 1 {
+				t.Errorf("%v: exit %d, want 1", args, code)
+			}
+		})
+	}
+}
+
+// TestRecordFilterAge keeps records within the age bounds and drops records
+// without a creation date.
+func TestRecordFilterAge(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	old := DomainRecord{Domain: "old.com", CreatedAt: now.AddDate(-20, 0, 0)}
+	young := DomainRecord{Domain: "young.com", CreatedAt: now.AddDate(-2, 0, 0)}
+	unknown := DomainRecord{Domain: "unknown.com"}
+
+	f := recordFilter{minAge: 10}
+	if !f.match(old, now) || f.match(young, now) || f.match(unknown, now) {
+		t.Error("min-age filter mismatch")
+	}
+	f = recordFilter{maxAge: 5}
+	if f.match(old, now) || !f.match(young, now) || f.match(unknown, now) {
+		t.Error("max-age filter mismatch")
+	}
+	if !(recordFilter{}).match(unknown, now) {
+		t.Error("zero filter rejected a record")
+	}
+}
+
+// TestRecordFilterDropping keeps domains on hold or in redemption.
+func TestRecordFilterDropping(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	f := recordFilter{dropping: true}
+	if !f.match(DomainRecord{OnHold: true}, now) || !f.match(DomainRecord{InRedemption: true}, now) || f.match(DomainRecord{}, now) {
+		t.Error("dropping filter mismatch")
+	}
+	row := reportRow(DomainRecord{Domain: "a.com", OnHold: true, PrivacyProtected: true}, now)
+	if row[5] != "hold,privacy" {
+		t.Errorf("FLAGS = %q", row[5])
+	}
+}
+
+// TestReportRowDecaysConfidence shows the confidence aged to now.
+func TestReportRowDecaysConfidence(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	rec := DomainRecord{Domain: "a.com", Confidence: 0.9, CheckedAt: now.Add(-confidenceHalfLife)}
+	if row := reportRow(rec, now); row[8] != "0.45" {
+		t.Errorf("CONFIDENCE = %q, want 0.45", row[8])
+	}
+}
+
+// TestRecordFilterNameServers matches name server text and parking
+// services.
+func TestRecordFilterNameServers(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	parked := DomainRecord{Domain: "a.com", NameServers: []string{"ns1.sedoparking.com"}}
+	hosted := DomainRecord{Domain: "b.com", NameServers: []string{"ns1.cloudflare.com"}}
+	f := recordFilter{nsContains: "SedoParking"}
+	if !f.match(parked, now) || f.match(hosted, now) || f.match(DomainRecord{}, now) {
+		t.Error("ns-contains filter mismatch")
+	}
+	f = recordFilter{parked: true}
+	if !f.match(parked, now) || f.match(hosted, now) {
+		t.Error("parked filter mismatch")
+	}
+	if row := reportRow(parked, now); row[5] != "parked" {
+		t.Errorf("FLAGS = %q", row[5])
+	}
+}
+
+// TestSortRecords orders by domain, or by value with unvalued records last.
+func TestSortRecords(t *testing.T) {
+	t.Parallel()
+	records := []DomainRecord{
+		{Domain: "c.com"},
+		{Domain: "b.com", EstimatedValue: 100},
+		{Domain: "a.com"},
+		{Domain: "d.com", EstimatedValue: 2500},
+	}
+	names := func() string {
+		var s []string
+		for _, r := range records {
+			s = append(s, r.Domain)
+		}
+		return strings.Join(s, " ")
+	}
+	sortRecords(records, sortValue)
+	if got := names(); got != "d.com b.com a.com c.com" {
+		t.Errorf("by value = %q", got)
+	}
+	sortRecords(records, sortDomain)
+	if got := names(); got != "a.com b.com c.com d.com" {
+		t.Errorf("by domain = %q", got)
+	}
+	if row := reportRow(records[3], time.Now()); row[6] != "2500" {
+		t.Errorf("VALUE = %q", row[6])
+	}
+}
+
+// TestRunCLI_NotesKept keeps hand-written notes through a check run and
+// shows them on one line in the report table.
+func TestRunCLI_NotesKept(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("a.com", taliatest.Response{Body: taliatest.Registered("a.com")})
+	array := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(array, []byte(`[{"domain":"a.com","notes":"ask the owner\nin March"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	grouped := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "a.com", Notes: "ask the owner\nin March"}}})
+
+	for _, path := range []string{array, grouped} {
+		stdout, _ := captureOutput(t, func() {
+			if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+				t.Errorf("%s: exit %d", path, code)
+			}
+			if code := RunCLI([]string{"report", path}); code != 0 {
+				t.Errorf("%s: report exit %d", path, code)
+			}
+		})
+		records, err := readRecords(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 1 || records[0].Status != StatusTaken || records[0].Notes != "ask the owner\nin March" {
+			t.Errorf("%s: records = %+v", path, records)
+		}
+		if !strings.Contains(stdout, "NOTES") || !strings.Contains(stdout, "ask the owner in March") {
+			t.Errorf("%s: report = %q", path, stdout)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package talia
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Commands accepted on stdin during an interactive sequential run. The
+// terminal is left in line mode, so each is typed and followed by Enter.
+const (
+	keyPause  = 'p'
+	keyResume = 'r'
+	keySkip   = 's'
+)
+
+// runControl lets the user steer a sequential run from the terminal: it
+// draws a countdown while sleeping between checks and reads pause, resume,
+// and skip commands. A nil *runControl is valid and does nothing, which is
+// what non-interactive runs get.
+type runControl struct {
+	out    *os.File
+	keys   <-chan byte
+	paused bool
+	skip   bool
+}
+
+// stdinKeys delivers the first letter of each line typed on stdin. It is
+// started once per process, since a read on stdin can't be interrupted and
+// a second reader would steal lines from the first.
+var stdinKeys = sync.OnceValue(func() <-chan byte {
+	ch := make(chan byte, 16)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(strings.ToLower(scanner.Text())); line != "" {
+				ch <- line[0]
+			}
+		}
+		close(ch)
+	}()
+	return ch
+})
+
+// newRunControl returns a runControl drawing to out, or nil unless both out
+// and stdin are terminals.
+func newRunControl(out *os.File) *runControl {
+	if !isTerminal(out) || !isTerminal(os.Stdin) {
+		return nil
+	}
+	fmt.Fprintln(out, "Keys: p+Enter pause, r+Enter resume, s+Enter skip the next domain")
+	return &runControl{out: out, keys: stdinKeys()}
+}
+
+// handle applies one command.
+func (c *runControl) handle(key byte) {
+	switch key {
+	case keyPause:
+		c.paused = true
+	case keyResume:
+		c.paused = false
+	case keySkip:
+		c.skip = true
+	}
+}
+
+// poll applies the commands typed so far without blocking.
+func (c *runControl) poll() {
+	for {
+		select {
+		case key, ok := <-c.keys:
+			if !ok {
+				c.keys = nil
+				c.paused = false
+				return
+			}
+			c.handle(key)
+		default:
+			return
+		}
+	}
+}
+
+// skipNext reports whether the user asked to skip the domain about to be
+// checked, and clears the request.
+func (c *runControl) skipNext() bool {
+	if c == nil {
+		return false
+	}
+	c.poll()
+	skip := c.skip
+	c.skip = false
+	return skip
+}
+
+// wait sleeps for d on clk in one-second steps, drawing the time left on
+// the status line. While paused it blocks until resumed, however long d
+// was, so a paused run makes no queries.
+func (c *runControl) wait(clk clock, d time.Duration) {
+	if c == nil {
+		clk.Sleep(d)
+		return
+	}
+	for remaining := d; ; {
+		c.poll()
+		if c.paused {
+			fmt.Fprintf(c.out, "\r\033[K  paused · r+Enter to resume")
+			if key, ok := <-c.keys; ok {
+				c.handle(key)
+			} else {
+				c.keys, c.paused = nil, false
+			}
+			continue
+		}
+		if remaining <= 0 {
+			break
+		}
+		step := min(time.Second, remaining)
+		fmt.Fprintf(c.out, "\r\033[K  next check in %s · p pause · s skip", remaining.Round(time.Second))
+		clk.Sleep(step)
+		remaining -= step
+	}
+	fmt.Fprint(c.out, "\r\033[K")
+}
+
+// skippedResult is the result recorded for a domain the user skipped: an
+// error, so --retry-errors keeps it for the next run.
+func skippedResult(domain string, clk clock) checkResult {
+	return checkResult{Domain: domain, Reason: ReasonError, Log: "Error: skipped by user", CheckedAt: clk.Now()}
+}
@@ -0,0 +1,80 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRunControl returns a runControl reading keys from a channel and
+// drawing to a temp file, whose contents the returned func reads.
+func testRunControl(t *testing.T) (*runControl, chan byte, func() string) {
+	t.Helper()
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = out.Close() })
+	keys := make(chan byte, 4)
+	read := func() string {
+		raw, _ := os.ReadFile(out.Name())
+		return string(raw)
+	}
+	return &runControl{out: out, keys: keys}, keys, read
+}
+
+// TestRunControlCountdown sleeps in one-second steps and draws the time
+// left; a nil control sleeps once.
+func TestRunControlCountdown(t *testing.T) {
+	t.Parallel()
+	ctl, _, read := testRunControl(t)
+	clk := &fakeClock{}
+	ctl.wait(clk, 2500*time.Millisecond)
+	if want := []time.Duration{time.Second, time.Second, 500 * time.Millisecond}; !slices.Equal(clk.sleeps, want) {
+		t.Errorf("sleeps = %v, want %v", clk.sleeps, want)
+	}
+	if out := read(); !strings.Contains(out, "next check in 3s") || !strings.Contains(out, "next check in 1s") {
+		t.Errorf("countdown = %q", out)
+	}
+
+	clk = &fakeClock{}
+	(*runControl)(nil).wait(clk, time.Minute)
+	if !slices.Equal(clk.sleeps, []time.Duration{time.Minute}) {
+		t.Errorf("nil control sleeps = %v", clk.sleeps)
+	}
+}
+
+// TestRunControlPauseSkip blocks while paused until resumed, and skips the
+// next domain once.
+func TestRunControlPauseSkip(t *testing.T) {
+	t.Parallel()
+	ctl, keys, read := testRunControl(t)
+	keys <- keyPause
+	keys <- keySkip
+	done := make(chan struct{})
+	go func() {
+		ctl.wait(&fakeClock{}, 0)
+		close(done)
+	}()
+	for !strings.Contains(read(), "paused") {
+		select {
+		case <-done:
+			t.Fatalf("wait returned while paused: %q", read())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	keys <- keyResume
+	<-done
+	if !ctl.skipNext() || ctl.skipNext() {
+		t.Error("skip should apply to exactly one domain")
+	}
+
+	close(keys)
+	ctl.wait(&fakeClock{}, 0)
+	if ctl.keys != nil || (*runControl)(nil).skipNext() {
+		t.Error("closed input not handled")
+	}
+}
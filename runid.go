@@ -0,0 +1,32 @@
+package talia
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+// runIDHeader carries the run ID on --post-results requests.
+const runIDHeader = "X-Talia-Run-ID"
+
+// newRunID returns an ID for a check run: the start time, so IDs sort in run
+// order, and random bytes, so runs started in the same second on different
+// machines do not collide, e.g. "20260102T030405Z-9f86d081".
+func newRunID(now time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b)
+}
+
+// runID returns the --run-id value, else TALIA_RUN_ID, else a new ID.
+// Setting one lets the runs of a scheduled or multi-machine job share it.
+func runID(flagValue string, now time.Time) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if env := os.Getenv("TALIA_RUN_ID"); env != "" {
+		return env
+	}
+	return newRunID(now)
+}
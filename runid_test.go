@@ -0,0 +1,27 @@
+package talia
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestRunID checks the generated format and the flag and env precedence.
+func TestRunID(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("x", 3600))
+	id := newRunID(now)
+	if !regexp.MustCompile(`^20260102T020405Z-[0-9a-f]{8}$`).MatchString(id) {
+		t.Errorf("newRunID = %q", id)
+	}
+	if newRunID(now) == id {
+		t.Error("two run IDs in the same second are equal")
+	}
+
+	t.Setenv("TALIA_RUN_ID", "from-env")
+	if got := runID("from-flag", now); got != "from-flag" {
+		t.Errorf("flag: got %q", got)
+	}
+	if got := runID("", now); got != "from-env" {
+		t.Errorf("env: got %q", got)
+	}
+}
@@ -0,0 +1,66 @@
+package talia
+
+import (
+	"fmt"
+	"time"
+)
+
+// Recheck intervals for --recheck-due, by what the last verdict says about
+// how soon it may change.
+const (
+	recheckError     = time.Hour           // failed checks, likely transient
+	recheckDropping  = 24 * time.Hour      // expiring soon, on hold, or in redemption
+	recheckAvailable = 24 * time.Hour      // free names can be registered any day
+	recheckTaken     = 30 * 24 * time.Hour // stable registrations
+)
+
+// expiringSoon is how close to its expiry date a taken domain is checked
+// daily instead of monthly.
+const expiringSoon = 30 * 24 * time.Hour
+
+// recheckInterval returns how long the verdict of gd, from the available
+// bucket or not, stays good at now. Domains that were given up are never
+// due.
+func recheckInterval(gd GroupedDomain, available bool, now time.Time) (time.Duration, bool) {
+	switch {
+	case available:
+		return recheckAvailable, true
+	case gd.Reason == ReasonGivenUp:
+		return 0, false
+	case gd.Reason == ReasonError:
+		return recheckError, true
+	case gd.OnHold || gd.InRedemption || (!gd.ExpiresAt.IsZero() && gd.ExpiresAt.Sub(now) < expiringSoon):
+		return recheckDropping, true
+	default:
+		return recheckTaken, true
+	}
+}
+
+// isDue reports whether gd should be checked again at now. Records without
+// CheckedAt are always due, since their age is unknown.
+func isDue(gd GroupedDomain, available bool, now time.Time) bool {
+	interval, ok := recheckInterval(gd, available, now)
+	return ok && (gd.CheckedAt.IsZero() || now.Sub(gd.CheckedAt) >= interval)
+}
+
+// requeueDue moves the available and unavailable domains of ext that are
+// due at now to unverified, for --recheck-due, and reports how many on cfg's
+// status output.
+func requeueDue(cfg runConfig, ext *ExtendedGroupedData, now time.Time) {
+	moved := 0
+	requeue := func(bucket []GroupedDomain, available bool) []GroupedDomain {
+		kept := bucket[:0:0]
+		for _, gd := range bucket {
+			if isDue(gd, available, now) {
+				ext.Unverified = append(ext.Unverified, gd.record())
+				moved++
+			} else {
+				kept = append(kept, gd)
+			}
+		}
+		return kept
+	}
+	ext.Available = requeue(ext.Available, true)
+	ext.Unavailable = requeue(ext.Unavailable, false)
+	fmt.Fprintf(cfg.status(), "Rechecking %d domains due by their schedule\n", moved)
+}
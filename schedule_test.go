@@ -0,0 +1,83 @@
+package talia
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestIsDue applies the interval for each kind of verdict.
+func TestIsDue(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	cases := []struct {
+		name      string
+		gd        GroupedDomain
+		available bool
+		want      bool
+	}{
+		{"available, checked yesterday", GroupedDomain{CheckedAt: ago(25 * time.Hour)}, true, true},
+		{"available, checked this morning", GroupedDomain{CheckedAt: ago(3 * time.Hour)}, true, false},
+		{"error, checked 2h ago", GroupedDomain{Reason: ReasonError, CheckedAt: ago(2 * time.Hour)}, false, true},
+		{"taken, checked a week ago", GroupedDomain{Reason: ReasonTaken, CheckedAt: ago(7 * 24 * time.Hour)}, false, false},
+		{"taken, checked 31 days ago", GroupedDomain{Reason: ReasonTaken, CheckedAt: ago(31 * 24 * time.Hour)}, false, true},
+		{"taken, expiring, checked 2 days ago", GroupedDomain{Reason: ReasonTaken, ExpiresAt: now.Add(10 * 24 * time.Hour), CheckedAt: ago(48 * time.Hour)}, false, true},
+		{"taken, on hold, checked 2 days ago", GroupedDomain{Reason: ReasonTaken, OnHold: true, CheckedAt: ago(48 * time.Hour)}, false, true},
+		{"given up", GroupedDomain{Reason: ReasonGivenUp}, false, false},
+		{"never checked", GroupedDomain{Reason: ReasonTaken}, false, true},
+	}
+	for _, c := range cases {
+		if got := isDue(c.gd, c.available, now); got != c.want {
+			t.Errorf("%s: isDue = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// TestRunCLIRecheckDue checks the unverified domains plus the due ones, and
+// rejects domain lists.
+func TestRunCLIRecheckDue(t *testing.T) {
+	srv := newWhoisServer(t)
+	now := time.Now()
+	path := writeGroupedFixture(t, GroupedData{
+		Available: []GroupedDomain{
+			{Domain: "fresh.com", Reason: ReasonNoMatch, CheckedAt: now},
+			{Domain: "old.com", Reason: ReasonNoMatch, CheckedAt: now.Add(-48 * time.Hour)},
+		},
+		Unavailable: []GroupedDomain{
+			{Domain: "stable.com", Reason: ReasonTaken, CheckedAt: now.Add(-48 * time.Hour)},
+			{Domain: "failed.com", Reason: ReasonError, CheckedAt: now.Add(-2 * time.Hour)},
+		},
+		Unverified: []DomainRecord{{Domain: "new.com"}},
+	})
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--recheck-due", "--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"new.com", "old.com", "failed.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+	if !strings.Contains(stdout, "Rechecking 2 domains due") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(data.Available) + len(data.Unavailable); n != 5 || len(data.Unverified) != 0 {
+		t.Errorf("buckets = %+v", data)
+	}
+
+	list := writeGroupedFixture(t, GroupedData{})
+	if err := writeFileAtomic(list, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--recheck-due", "--whois=" + srv.Addr, list}); code == 0 {
+			t.Errorf("domain list accepted: exit %d", code)
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package talia
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// embeddedServers maps common TLDs to their WHOIS (and, where known, RDAP)
+// servers. It is the fallback when the cache has no refreshed copy.
+//
+//go:embed whois-servers.json
+var embeddedServers []byte
+
+// serverEntry is where to query one TLD.
+type serverEntry struct {
+	Whois string `json:"whois,omitempty"` // host:port
+	RDAP  string `json:"rdap,omitempty"`  // RDAP base URL
+}
+
+// serverDB maps TLDs and second-level suffixes (lowercase, without the
+// leading dot) to their servers.
+type serverDB map[string]serverEntry
+
+// serversFileName is the refreshed server database in the cache directory.
+const serversFileName = "whois-servers.json"
+
+// loadServerDB returns the embedded database overlaid with the refreshed
+// copy in the cache directory, if there is one.
+func loadServerDB() (serverDB, error) {
+	db := serverDB{}
+	if err := json.Unmarshal(embeddedServers, &db); err != nil {
+		return nil, fmt.Errorf("embedded server database: %w", err)
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return db, nil
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, serversFileName))
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cached serverDB
+	if err := json.Unmarshal(raw, &cached); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", serversFileName, err)
+	}
+	maps.Copy(db, cached)
+	return db, nil
+}
+
+// The server database is loaded once per process on first use;
+// resetServerDB forces a reload after update-servers or in tests.
+var (
+	serverDBMu     sync.Mutex
+	serverDBLoaded serverDB
+	serverDBErr    error
+)
+
+func currentServerDB() (serverDB, error) {
+	serverDBMu.Lock()
+	defer serverDBMu.Unlock()
+	if serverDBLoaded == nil && serverDBErr == nil {
+		serverDBLoaded, serverDBErr = loadServerDB()
+	}
+	return serverDBLoaded, serverDBErr
+}
+
+func resetServerDB() {
+	serverDBMu.Lock()
+	defer serverDBMu.Unlock()
+	serverDBLoaded, serverDBErr = nil, nil
+}
+
+// tldOf returns the last label of domain, lowercased.
+func tldOf(domain string) string {
+	return strings.ToLower(domain[strings.LastIndex(domain, ".")+1:])
+}
+
+// routeServer returns the WHOIS server for domain's TLD from the server
+// database. A second-level suffix with its own entry (co.uk) is preferred
+// over the TLD, so registries that run a separate server for it are reached.
+func routeServer(domain string) (string, error) {
+	db, err := currentServerDB()
+	if err != nil {
+		return "", err
+	}
+	if suffix := publicSuffix(domain); suffix != tldOf(domain) {
+		if e, ok := db[suffix]; ok && e.Whois != "" {
+			return e.Whois, nil
+		}
+	}
+	tld := tldOf(domain)
+	if e, ok := db[tld]; ok && e.Whois != "" {
+		return e.Whois, nil
+	}
+	return "", fmt.Errorf("no WHOIS server known for .%s; pass --whois or run 'talia update-servers %s'", tld, tld)
+}
+
+// serverFor returns the WHOIS server for domain: server if one was given,
+// otherwise the routed one ("" if the TLD is unknown).
+func serverFor(domain, server string) string {
+	if server != "" {
+		return server
+	}
+	routed, _ := routeServer(domain)
+	return routed
+}
+
+// runUpdateServersCommand implements "talia update-servers [tld...]": it asks
+// IANA for the current WHOIS server of each TLD (by default every TLD in the
+// database), looks up RDAP base URLs from the bootstrap file, and writes the
+// result to the cache directory, where it takes precedence over the embedded
+// copy.
+func runUpdateServersCommand(args []string) int {
+	fs := flag.NewFlagSet("talia update-servers", flag.ContinueOnError)
+	server := fs.String("server", defaultIANAWhois, "IANA WHOIS server")
+	bootstrap := fs.String("rdap-bootstrap", defaultRDAPBootstrap, "RDAP bootstrap file URL ('' to skip RDAP)")
+	sleep := fs.Duration("sleep", time.Second, "Time to sleep between IANA queries")
+	tlds, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: no cache directory:", err)
+		return 1
+	}
+	db, err := loadServerDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	if len(tlds) == 0 {
+		tlds = slices.Sorted(maps.Keys(db))
+	}
+
+	var boot rdapBootstrap
+	if *bootstrap != "" {
+		if boot, err = fetchRDAPBootstrap(http.DefaultClient, *bootstrap); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: RDAP lookup failed:", err)
+		}
+	}
+
+	updated := 0
+	for i, tld := range tlds {
+		tld = strings.ToLower(strings.Trim(tld, "."))
+		if i > 0 {
+			time.Sleep(*sleep)
+		}
+		resp, err := NetWhoisClient{Server: *server}.Lookup(tld)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: .%s: %v\n", tld, err)
+			continue
+		}
+		whois, _ := parseIANATLD(resp)
+		entry := db[tld]
+		if whois != "" {
+			entry.Whois = whois
+		}
+		if rdap := boot.base(tld); rdap != "" {
+			entry.RDAP = rdap
+		}
+		if entry.Whois == "" && entry.RDAP == "" {
+			fmt.Fprintf(os.Stderr, "Warning: .%s: IANA lists no WHOIS or RDAP server\n", tld)
+			continue
+		}
+		db[tld] = entry
+		updated++
+	}
+
+	out, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+		return 1
+	}
+	path := filepath.Join(dir, serversFileName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing server database:", err)
+		return 1
+	}
+	if err := writeFileAtomic(path, out, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing server database:", err)
+		return 1
+	}
+	resetServerDB()
+	fmt.Printf("Updated %d of %d TLDs in %s\n", updated, len(tlds), path)
+	if updated < len(tlds) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package talia
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useServerCache points the cache directory at a temp dir and reloads the
+// server database around the test.
+func useServerCache(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("TALIA_CACHE_DIR", dir)
+	resetServerDB()
+	t.Cleanup(resetServerDB)
+	return dir
+}
+
+// TestRouteServerEmbedded routes common TLDs from the embedded database.
+func TestRouteServerEmbedded(t *testing.T) {
+	useServerCache(t)
+	if got, err := routeServer("Example.COM"); err != nil || got != "whois.verisign-grs.com:43" {
+		t.Errorf("routeServer(com) = %q, %v", got, err)
+	}
+	if _, err := routeServer("example.unknown-tld"); err == nil || !strings.Contains(err.Error(), "talia update-servers unknown-tld") {
+		t.Errorf("unknown TLD error = %v", err)
+	}
+	if got := serverFor("a.io", "override:43"); got != "override:43" {
+		t.Errorf("serverFor with explicit server = %q", got)
+	}
+	res := checkOne("a.unknown-tld", runConfig{})
+	if res.Reason != ReasonError || !strings.Contains(res.Log, "no WHOIS server known") {
+		t.Errorf("unroutable checkOne = %+v", res)
+	}
+}
+
+// TestRunUpdateServersCommand refreshes a TLD from IANA into the cache, after
+// which file-mode runs route it without --whois.
+func TestRunUpdateServersCommand(t *testing.T) {
+	dir := useServerCache(t)
+	t.Setenv("WHOIS_SERVER", "")
+	registry := startWhoisServer(t, "No match for domain\n")
+	iana := startWhoisServer(t, "domain: TEST\norganisation: Test Registry\nwhois: "+registry+"\n")
+	boot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"services":[[["test"],["https://rdap.example.test/"]]]}`)
+	}))
+	defer boot.Close()
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"update-servers", "--server=" + iana, "--rdap-bootstrap=" + boot.URL, "--sleep=0", "test"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Updated 1 of 1 TLDs") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, serversFileName))
+	if err != nil || !strings.Contains(string(raw), "https://rdap.example.test/") || !strings.Contains(string(raw), `"com"`) {
+		t.Errorf("cache file = %s, %v", raw, err)
+	}
+
+	input := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(input, []byte(`[{"domain":"free.test"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"--sleep=0", input}); code != 0 {
+			t.Errorf("routed run exit %d", code)
+		}
+	})
+	recs, err := readRecords(input)
+	if err != nil || len(recs) != 1 || !recs[0].Available {
+		t.Errorf("routed result = %+v, %v", recs, err)
+	}
+}
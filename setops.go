@@ -0,0 +1,142 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Operations of "talia set".
+const (
+	setUnion     = "union"
+	setIntersect = "intersect"
+	setSubtract  = "subtract"
+)
+
+// readDomainList reads the records of a JSON file (array, JSON Lines, or
+// grouped, as readRecords does) or of a plain-text list with one domain per
+// line.
+func readDomainList(path string) ([]DomainRecord, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isJSONLines(path) || json.Valid(raw) {
+		return readRecords(path)
+	}
+	return textListRecords(raw)
+}
+
+// combineDomainSets applies op to lists, comparing domains by dupKey. The
+// result keeps the first list's order (then each later list's, for union)
+// and the first record seen for each domain:
+//
+//   - union: domains in any list
+//   - intersect: domains of the first list that are in every other list
+//   - subtract: domains of the first list that are in none of the others
+func combineDomainSets(op string, lists [][]DomainRecord) []DomainRecord {
+	var out []DomainRecord
+	emitted := make(map[string]bool)
+	emit := func(rec DomainRecord) {
+		if k := dupKey(rec.Domain); !emitted[k] {
+			emitted[k] = true
+			out = append(out, rec)
+		}
+	}
+	if op == setUnion {
+		for _, list := range lists {
+			for _, rec := range list {
+				emit(rec)
+			}
+		}
+		return out
+	}
+
+	// count[k] is the number of other lists holding k.
+	count := make(map[string]int)
+	for _, list := range lists[1:] {
+		inList := make(map[string]bool)
+		for _, rec := range list {
+			inList[dupKey(rec.Domain)] = true
+		}
+		for k := range inList {
+			count[k]++
+		}
+	}
+	for _, rec := range lists[0] {
+		n := count[dupKey(rec.Domain)]
+		if (op == setIntersect && n == len(lists)-1) || (op == setSubtract && n == 0) {
+			emit(rec)
+		}
+	}
+	return out
+}
+
+// runSetCommand implements "talia set union|intersect|subtract <file>...":
+// set arithmetic on the domains of JSON and text files. The result is
+// printed one domain per line, or written to --output as a JSON array of the
+// kept records (.json), JSON Lines (.jsonl), or a text list.
+func runSetCommand(args []string) int {
+	fs := flag.NewFlagSet("talia set", flag.ContinueOnError)
+	output := fs.String("output", "", "Write the result to this file: a record array for .json, JSON Lines for .jsonl, else one domain per line")
+	rest, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(rest) < 3 || (rest[0] != setUnion && rest[0] != setIntersect && rest[0] != setSubtract) {
+		fmt.Fprintln(os.Stderr, "Usage: talia set union|intersect|subtract [--output=file] <file> <file>...")
+		return 1
+	}
+	op, files := rest[0], rest[1:]
+
+	lists := make([][]DomainRecord, len(files))
+	for i, path := range files {
+		if lists[i], err = readDomainList(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			return 1
+		}
+	}
+	result := combineDomainSets(op, lists)
+
+	var buf bytes.Buffer
+	switch {
+	case *output != "" && isJSONLines(*output):
+		out, err := marshalJSONLines(result)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		buf.Write(out)
+	case strings.HasSuffix(strings.ToLower(*output), ".json"):
+		if result == nil {
+			result = []DomainRecord{}
+		}
+		out, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		buf.Write(append(out, '\n'))
+	default:
+		for _, rec := range result {
+			fmt.Fprintln(&buf, rec.Domain)
+		}
+	}
+
+	if *output == "" {
+		_, _ = os.Stdout.Write(buf.Bytes())
+		// stdout holds only the domains.
+		fmt.Fprintf(os.Stderr, "%s: %d domains\n", op, len(result))
+		return 0
+	}
+	if err := auditWrite("set", "", *output, func() error { return writeFileAtomic(*output, buf.Bytes(), 0644) }); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing file:", err)
+		return 1
+	}
+	fmt.Printf("%s: wrote %d domains to %s\n", op, len(result), *output)
+	return 0
+}
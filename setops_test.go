@@ -0,0 +1,82 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestCombineDomainSets checks each operation, ignoring case, with more
+// than two lists.
+func TestCombineDomainSets(t *testing.T) {
+	t.Parallel()
+	recs := func(domains ...string) []DomainRecord {
+		out := make([]DomainRecord, len(domains))
+		for i, d := range domains {
+			out[i] = DomainRecord{Domain: d}
+		}
+		return out
+	}
+	lists := [][]DomainRecord{recs("a.com", "b.com", "c.com", "A.com"), recs("B.com", "c.com", "d.com"), recs("c.com", "b.com")}
+	domains := func(rs []DomainRecord) []string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.Domain)
+		}
+		return out
+	}
+	tests := map[string][]string{
+		setUnion:     {"a.com", "b.com", "c.com", "d.com"},
+		setIntersect: {"b.com", "c.com"},
+		setSubtract:  {"a.com"},
+	}
+	for op, want := range tests {
+		if got := domains(combineDomainSets(op, lists)); !slices.Equal(got, want) {
+			t.Errorf("%s = %v, want %v", op, got, want)
+		}
+	}
+}
+
+// TestRunSetCommand subtracts a text list from a grouped file and keeps the
+// records when writing JSON.
+func TestRunSetCommand(t *testing.T) {
+	grouped := writeGroupedFixture(t, GroupedData{
+		Available:   []GroupedDomain{{Domain: "free.com", Favorite: true}},
+		Unavailable: []GroupedDomain{{Domain: "ours.com", Reason: ReasonTaken}},
+	})
+	ours := filepath.Join(t.TempDir(), "ours.txt")
+	if err := os.WriteFile(ours, []byte("# registered by us\nOURS.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"set", "subtract", grouped, ours}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "free.com\n" || stderr != "subtract: 1 domains\n" {
+		t.Errorf("stdout = %q, stderr = %q", stdout, stderr)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.json")
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"set", "union", "--output=" + out, grouped, ours}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	var recs []DomainRecord
+	raw, _ := os.ReadFile(out)
+	if err := json.Unmarshal(raw, &recs); err != nil || len(recs) != 2 || !recs[0].Favorite || recs[1].Status != StatusTaken {
+		t.Errorf("out = %s", raw)
+	}
+
+	_, stderr = captureOutput(t, func() {
+		if code := RunCLI([]string{"set", "xor", grouped, ours}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if stderr == "" {
+		t.Error("no usage message for an unknown operation")
+	}
+}
@@ -0,0 +1,72 @@
+package talia
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// shardSpec selects the domains of one shard for --shard=K/N: those whose
+// hash mod N is K-1. The zero value selects every domain.
+type shardSpec struct {
+	index, count int // index is 1-based
+}
+
+// parseShard parses "K/N" with 1 <= K <= N.
+func parseShard(s string) (shardSpec, error) {
+	k, n, ok := strings.Cut(s, "/")
+	index, err1 := strconv.Atoi(k)
+	count, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil || count < 1 || index < 1 || index > count {
+		return shardSpec{}, fmt.Errorf("--shard must be K/N with 1 <= K <= N, e.g. 3/8")
+	}
+	return shardSpec{index: index, count: count}, nil
+}
+
+// active reports whether s selects a subset.
+func (s shardSpec) active() bool {
+	return s.count > 1
+}
+
+// keep reports whether domain belongs to s. Domains are hashed by dupKey,
+// so the assignment doesn't depend on casing, the input order, or the
+// machine.
+func (s shardSpec) keep(domain string) bool {
+	if !s.active() {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(dupKey(domain)))
+	return int(h.Sum32()%uint32(s.count)) == s.index-1
+}
+
+func (s shardSpec) String() string {
+	return fmt.Sprintf("%d/%d", s.index, s.count)
+}
+
+// runMergeCommand implements "talia merge [-o file] <file>...": the grouped
+// files, typically the outputs of one --shard run per machine, are merged
+// as --merge does, into -o or else the first file.
+func runMergeCommand(args []string) int {
+	fs := flag.NewFlagSet("talia merge", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the merged file here instead of into the first file")
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error parsing flags: %v", err)
+	}
+	if len(files) == 0 || (len(files) < 2 && *output == "") {
+		return fail(cliError{Code: errCodeUsage}, "Usage: talia merge [-o file] <grouped-file> <grouped-file>...")
+	}
+	target := *output
+	if target == "" {
+		target = files[0]
+	}
+	total, err := mergeFiles(target, files)
+	if err != nil {
+		return failMerge(err)
+	}
+	fmt.Printf("Merged %d domains into %s\n", total, target)
+	return 0
+}
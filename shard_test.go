@@ -0,0 +1,99 @@
+package talia
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	s, err := parseShard("3/8")
+	if err != nil || s != (shardSpec{index: 3, count: 8}) || s.String() != "3/8" {
+		t.Fatalf("parseShard(3/8) = %+v, %v", s, err)
+	}
+	for _, bad := range []string{"", "3", "0/8", "9/8", "1/0", "a/b", "-1/2"} {
+		if _, err := parseShard(bad); err == nil {
+			t.Errorf("parseShard(%q) succeeded", bad)
+		}
+	}
+	if one, _ := parseShard("1/1"); one.active() {
+		t.Error("1/1 should select every domain")
+	}
+}
+
+// TestShardPartition checks that the shards of a split cover every domain
+// exactly once, whatever its casing.
+func TestShardPartition(t *testing.T) {
+	for i := range 200 {
+		domain := fmt.Sprintf("name%d.com", i)
+		n := 0
+		for k := 1; k <= 4; k++ {
+			s := shardSpec{index: k, count: 4}
+			if s.keep(domain) {
+				n++
+				if !s.keep("NAME" + domain[4:]) {
+					t.Errorf("%s: casing changed its shard", domain)
+				}
+			}
+		}
+		if n != 1 {
+			t.Errorf("%s is in %d shards", domain, n)
+		}
+	}
+}
+
+// TestRunCLI_ShardMerge runs each shard of a grouped file into its own copy,
+// then recombines them with "talia merge".
+func TestRunCLI_ShardMerge(t *testing.T) {
+	srv := newWhoisServer(t)
+	var unverified []DomainRecord
+	for i := range 10 {
+		unverified = append(unverified, DomainRecord{Domain: fmt.Sprintf("name%d.com", i)})
+	}
+	src := writeGroupedFixture(t, GroupedData{Unverified: unverified})
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	var shards []string
+	for k := 1; k <= 2; k++ {
+		path := filepath.Join(dir, fmt.Sprintf("shard%d.json", k))
+		if err := os.WriteFile(path, raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+		captureOutput(t, func() {
+			if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", fmt.Sprintf("--shard=%d/2", k), path}); code != 0 {
+				t.Errorf("shard %d: exit %d", k, code)
+			}
+		})
+		shards = append(shards, path)
+	}
+	if got := len(srv.Queries()); got != 10 {
+		t.Errorf("%d queries, want each domain once", got)
+	}
+
+	out := filepath.Join(dir, "all.json")
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI(append([]string{"merge", "-o", out}, shards...)); code != 0 {
+			t.Errorf("merge: exit %d", code)
+		}
+	})
+	data, err := readGroupedFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Available) != 10 || len(data.Unverified) != 0 {
+		t.Errorf("merged: %d available, %d unverified; stdout %q", len(data.Available), len(data.Unverified), stdout)
+	}
+}
+
+func TestRunCLI_ShardInvalid(t *testing.T) {
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "a.com"}}})
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--shard=9/8", path}); code == 0 {
+			t.Error("--shard=9/8 succeeded")
+		}
+	})
+}
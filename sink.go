@@ -0,0 +1,83 @@
+package talia
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Formats accepted by --post-format.
+const (
+	postFormatJSON   = "json"
+	postFormatNDJSON = "ndjson"
+)
+
+// resultRecords converts check results into DomainRecords, the shape used for
+// per-domain output such as NDJSON.
+func resultRecords(results []checkResult) []DomainRecord {
+	records := make([]DomainRecord, len(results))
+	for i, res := range results {
+		records[i] = res.record()
+	}
+	return records
+}
+
+// postResults sends the outcome of a run to an HTTP endpoint. In "json" format
+// the final document (grouped object or array) is posted as a single body; in
+// "ndjson" format each checked record is streamed as one JSON object per line.
+// A non-empty runID is sent in the X-Talia-Run-ID header.
+func postResults(client httpDoer, url, format, runID string, doc any, records []DomainRecord) error {
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch format {
+	case "", postFormatJSON:
+		payload, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("marshal results: %w", err)
+		}
+		body = bytes.NewReader(payload)
+		contentType = "application/json"
+	case postFormatNDJSON:
+		pr, pw := io.Pipe()
+		go func() {
+			enc := json.NewEncoder(pw)
+			for _, rec := range records {
+				if err := enc.Encode(rec); err != nil {
+					_ = pw.CloseWithError(err)
+					return
+				}
+			}
+			_ = pw.Close()
+		}()
+		body = pr
+		contentType = "application/x-ndjson"
+	default:
+		return fmt.Errorf("unknown post format %q (want %s or %s)", format, postFormatJSON, postFormatNDJSON)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if runID != "" {
+		req.Header.Set(runIDHeader, runID)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post results: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post results: status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package talia
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPostResultsJSON verifies the final document is posted as a single JSON body.
+func TestPostResultsJSON(t *testing.T) {
+	t.Parallel()
+	var gotType, gotRunID string
+	var got GroupedData
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType = r.Header.Get("Content-Type")
+		gotRunID = r.Header.Get(runIDHeader)
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	doc := GroupedData{Available: []GroupedDomain{{Domain: "a.com", Reason: ReasonNoMatch}}}
+	if err := postResults(fakeHTTPClient{srv}, srv.URL, postFormatJSON, "run-1", doc, nil); err != nil {
+		t.Fatalf("postResults: %v", err)
+	}
+	if gotType != "application/json" || gotRunID != "run-1" {
+		t.Errorf("content type = %q, run ID = %q", gotType, gotRunID)
+	}
+	if len(got.Available) != 1 || got.Available[0].Domain != "a.com" {
+		t.Errorf("unexpected body: %+v", got)
+	}
+}
+
+// TestPostResultsNDJSON verifies each record is sent on its own line.
+func TestPostResultsNDJSON(t *testing.T) {
+	t.Parallel()
+	var lines []DomainRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var rec DomainRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			lines = append(lines, rec)
+		}
+	}))
+	defer srv.Close()
+
+	records := []DomainRecord{
+		{Domain: "a.com", Available: true, Reason: ReasonNoMatch},
+		{Domain: "b.com", Reason: ReasonTaken},
+	}
+	if err := postResults(fakeHTTPClient{srv}, srv.URL, postFormatNDJSON, "", nil, records); err != nil {
+		t.Fatalf("postResults: %v", err)
+	}
+	if len(lines) != 2 || lines[1].Domain != "b.com" {
+		t.Errorf("unexpected lines: %+v", lines)
+	}
+}
+
+// TestPostResultsErrors covers bad status codes and unknown formats.
+func TestPostResultsErrors(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if err := postResults(fakeHTTPClient{srv}, srv.URL, postFormatJSON, "", []DomainRecord{}, nil); err == nil {
+		t.Error("expected error on HTTP 502")
+	}
+	if err := postResults(fakeHTTPClient{srv}, srv.URL, "xml", "", nil, nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+// TestRunCLI_PostResults verifies --post-results sends the written file after a run.
+func TestRunCLI_PostResults(t *testing.T) {
+	whois := startWhoisServer(t, "No match for domain\n")
+
+	var posted []DomainRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&posted)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "domains.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, stderr := captureOutput(t, func() {
+		code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", "--post-results=" + srv.URL, path})
+		if code != 0 {
+			t.Errorf("exit code = %d", code)
+		}
+	})
+	if stderr != "" {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+	if !strings.Contains(stdout, "Posted results to") {
+		t.Errorf("missing post confirmation: %s", stdout)
+	}
+	if len(posted) != 1 || !posted[0].Available {
+		t.Errorf("unexpected posted body: %+v", posted)
+	}
+}
+
+// TestRunCLI_PostResultsGroupedMerged verifies a grouped run posts the file
+// it merged its results into, not just this run's results.
+func TestRunCLI_PostResultsGroupedMerged(t *testing.T) {
+	whois := startWhoisServer(t, "No match for domain\n")
+
+	var posted GroupedData
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&posted)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "domains.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"new.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "grouped.json")
+	if err := os.WriteFile(output, []byte(`{"unavailable":[{"domain":"old.com","reason":"TAKEN"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	captureOutput(t, func() {
+		code := RunCLI([]string{"--whois=" + whois, "--sleep=0s", "--grouped-output", "--output-file=" + output, "--post-results=" + srv.URL, path})
+		if code != 0 {
+			t.Errorf("exit code = %d", code)
+		}
+	})
+	if len(posted.Unavailable) != 1 || posted.Unavailable[0].Domain != "old.com" || len(posted.Available) != 1 || posted.Available[0].Domain != "new.com" {
+		t.Errorf("posted body = %+v, want old.com and new.com", posted)
+	}
+}
+
+// TestRunCLI_PostFormatInvalid ensures an unknown --post-format is rejected up front.
+func TestRunCLI_PostFormatInvalid(t *testing.T) {
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--post-format=xml", "x.json"}); code == 0 {
+			t.Error("expected non-zero exit")
+		}
+	})
+	if !strings.Contains(stderr, "--post-format") {
+		t.Errorf("unexpected stderr: %s", stderr)
+	}
+}
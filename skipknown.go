@@ -0,0 +1,31 @@
+package talia
+
+import "fmt"
+
+// knownDomains returns the domains in the available and unavailable buckets
+// of data, keyed by dupKey.
+func knownDomains(data GroupedData) map[string]bool {
+	known := make(map[string]bool, len(data.Available)+len(data.Unavailable))
+	for _, gd := range data.Available {
+		known[dupKey(gd.Domain)] = true
+	}
+	for _, gd := range data.Unavailable {
+		known[dupKey(gd.Domain)] = true
+	}
+	return known
+}
+
+// skipKnown drops the records whose domain is in known, for --skip-known,
+// and reports how many it dropped on cfg's status output.
+func skipKnown(cfg runConfig, records []DomainRecord, known map[string]bool, path string) []DomainRecord {
+	kept := records[:0:0]
+	for _, rec := range records {
+		if !known[dupKey(rec.Domain)] {
+			kept = append(kept, rec)
+		}
+	}
+	if skipped := len(records) - len(kept); skipped > 0 {
+		fmt.Fprintf(cfg.status(), "Skipping %d domains already resolved in %s\n", skipped, path)
+	}
+	return kept
+}
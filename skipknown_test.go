@@ -0,0 +1,71 @@
+package talia
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestRunCLI_SkipKnownArray checks only the domains of a list that the
+// grouped output file has not resolved yet.
+func TestRunCLI_SkipKnownArray(t *testing.T) {
+	srv := newWhoisServer(t)
+	dir := t.TempDir()
+	list := filepath.Join(dir, "list.json")
+	if err := os.WriteFile(list, []byte(`[{"domain":"a.com"},{"domain":"B.com"},{"domain":"c.com"},{"domain":"d.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	grouped := writeGroupedFixture(t, GroupedData{
+		Available:   []GroupedDomain{{Domain: "a.com"}},
+		Unavailable: []GroupedDomain{{Domain: "b.com"}},
+		Unverified:  []DomainRecord{{Domain: "c.com"}},
+	})
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--grouped-output", "--output-file=" + grouped, "--skip-known", list}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"c.com", "d.com"}) {
+		t.Errorf("queries = %v, want c.com and d.com", got)
+	}
+	if !strings.Contains(stdout, "Skipping 2 domains") {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--grouped-output", "--skip-known", list}); code != 1 {
+			t.Errorf("without --output-file: exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "--output-file") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestRunCLI_SkipKnownGrouped drops unverified entries that the file
+// already resolved instead of checking them again.
+func TestRunCLI_SkipKnownGrouped(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := writeGroupedFixture(t, GroupedData{
+		Available:  []GroupedDomain{{Domain: "a.com"}},
+		Unverified: []DomainRecord{{Domain: "a.com"}, {Domain: "new.com"}},
+	})
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--skip-known", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"new.com"}) {
+		t.Errorf("queries = %v, want only new.com", got)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Available) != 2 || len(data.Unverified) != 0 {
+		t.Errorf("file = %+v", data)
+	}
+}
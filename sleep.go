@@ -0,0 +1,66 @@
+package talia
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// sleepOverrides maps WHOIS servers ("host:port") and TLDs (".io", ".co.uk") to their
+// own inter-query delay, parsed from --sleep-per-server.
+type sleepOverrides map[string]time.Duration
+
+// parseSleepOverrides parses a comma-separated list of key=duration pairs,
+// e.g. "whois.verisign-grs.com:43=2s,whois.nic.io:43=5s,.io=5s". Keys
+// starting with a dot are TLDs; anything else is a server address.
+func parseSleepOverrides(s string) (sleepOverrides, error) {
+	out := sleepOverrides{}
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid sleep override %q: want server=duration or .tld=duration", item)
+		}
+		key := strings.ToLower(strings.TrimSpace(item[:i]))
+		d, err := time.ParseDuration(strings.TrimSpace(item[i+1:]))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration in sleep override %q", item)
+		}
+		out[key] = d
+	}
+	return out, nil
+}
+
+// sleepFor returns the delay to apply after checking domain on its server
+// (cfg.whoisServer, or the one routed by TLD):
+// a TLD override wins over a server override, which wins over cfg.sleep.
+// With cfg.sleepJitter set, the delay is then moved by a random amount within
+// ±sleepJitter (never below zero) so queries are not perfectly periodic.
+func (cfg runConfig) sleepFor(domain string) time.Duration {
+	return jitter(cfg.baseSleepFor(domain), cfg.sleepJitter)
+}
+
+func (cfg runConfig) baseSleepFor(domain string) time.Duration {
+	for _, key := range suffixKeys(domain) {
+		if d, ok := cfg.sleepOverrides[key]; ok {
+			return d
+		}
+	}
+	if d, ok := cfg.sleepOverrides[strings.ToLower(serverFor(domain, cfg.whoisServer))]; ok {
+		return d
+	}
+	return cfg.sleep
+}
+
+// jitter returns d shifted uniformly at random within [d-band, d+band],
+// clamped at zero.
+func jitter(d, band time.Duration) time.Duration {
+	if band <= 0 {
+		return d
+	}
+	return max(d+rand.N(2*band+1)-band, 0)
+}
@@ -0,0 +1,77 @@
+package talia
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseSleepOverrides covers server keys with ports, TLD keys, and errors.
+func TestParseSleepOverrides(t *testing.T) {
+	t.Parallel()
+	got, err := parseSleepOverrides("whois.verisign-grs.com:43=2s, whois.nic.io:43=5s,.IO=7s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sleepOverrides{
+		"whois.verisign-grs.com:43": 2 * time.Second,
+		"whois.nic.io:43":           5 * time.Second,
+		".io":                       7 * time.Second,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+
+	for _, bad := range []string{"whois.nic.io:43", "=2s", "x=fast", "x=-1s"} {
+		if _, err := parseSleepOverrides(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+// TestSleepFor checks TLD overrides beat server overrides, which beat --sleep.
+func TestSleepFor(t *testing.T) {
+	t.Parallel()
+	cfg := runConfig{
+		whoisServer:    "whois.nic.io:43",
+		sleep:          time.Second,
+		sleepOverrides: sleepOverrides{"whois.nic.io:43": 5 * time.Second, ".ai": 9 * time.Second},
+	}
+	if got := cfg.sleepFor("foo.io"); got != 5*time.Second {
+		t.Errorf("server override: %v", got)
+	}
+	if got := cfg.sleepFor("foo.AI"); got != 9*time.Second {
+		t.Errorf("tld override: %v", got)
+	}
+	cfg.whoisServer = "whois.verisign-grs.com:43"
+	if got := cfg.sleepFor("foo.com"); got != time.Second {
+		t.Errorf("default: %v", got)
+	}
+}
+
+// TestJitter verifies jittered delays stay within the band, never go
+// negative, and actually vary.
+func TestJitter(t *testing.T) {
+	t.Parallel()
+	seen := make(map[time.Duration]bool)
+	for range 1000 {
+		d := jitter(time.Second, 500*time.Millisecond)
+		if d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("jitter out of band: %v", d)
+		}
+		seen[d] = true
+		if d := jitter(100*time.Millisecond, time.Second); d < 0 {
+			t.Fatalf("negative delay: %v", d)
+		}
+	}
+	if len(seen) < 2 {
+		t.Error("jitter produced a constant delay")
+	}
+	if jitter(time.Second, 0) != time.Second {
+		t.Error("zero band must not change the delay")
+	}
+}
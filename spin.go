@@ -0,0 +1,125 @@
+package talia
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// spinOptions selects the transforms "talia spin" applies to each base word.
+type spinOptions struct {
+	prefixes   []string // prepended to the word: get+word
+	suffixes   []string // appended to the word: word+hq
+	dropVowels bool     // flickr from flicker; the first letter is kept
+	double     bool     // the last letter doubled: grabb from grab
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for part := range strings.SplitSeq(s, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// dropVowels removes the vowels after the first letter of word.
+func dropVowels(word string) string {
+	if word == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte(word[0])
+	for i := 1; i < len(word); i++ {
+		if !strings.ContainsRune("aeiou", rune(word[i])) {
+			b.WriteByte(word[i])
+		}
+	}
+	return b.String()
+}
+
+// spinWords returns candidate labels for words: each word itself, then its
+// prefixed, suffixed, vowel-dropped, and doubled forms, in that order.
+// Words and labels that are not valid domain labels, and duplicates, are
+// skipped.
+func spinWords(words []string, opts spinOptions) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(label string) {
+		if !seen[label] && validDomainLabel.MatchString(label) {
+			seen[label] = true
+			out = append(out, label)
+		}
+	}
+	for _, w := range words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if !validDomainLabel.MatchString(w) {
+			continue
+		}
+		add(w)
+		for _, p := range opts.prefixes {
+			add(p + w)
+		}
+		for _, s := range opts.suffixes {
+			add(w + s)
+		}
+		if opts.dropVowels {
+			if d := dropVowels(w); len(d) > 1 {
+				add(d)
+			}
+		}
+		if opts.double {
+			add(w + w[len(w)-1:])
+		}
+	}
+	return out
+}
+
+// runSpinCommand implements "talia spin <word>...": candidate .com names are
+// generated from the base words and written to the output file's
+// "unverified" list, ready for a checking run, or printed one per line when
+// no output file is given.
+func runSpinCommand(args []string) int {
+	fs := flag.NewFlagSet("talia spin", flag.ContinueOnError)
+	prefixes := fs.String("prefixes", "get,try,use", "Comma-separated prefixes ('' for none)")
+	suffixes := fs.String("suffixes", "hq,app,ly", "Comma-separated suffixes ('' for none)")
+	noVowels := fs.Bool("drop-vowels", true, "Add each word without its vowels (first letter kept)")
+	double := fs.Bool("double", true, "Add each word with its last letter doubled")
+	output := fs.String("output", "", "Grouped JSON file to add the candidates to as unverified (default: print them)")
+	words, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(words) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: talia spin [--prefixes=a,b] [--suffixes=a,b] [--drop-vowels=false] [--double=false] [--output=file.json] <word>...")
+		return 1
+	}
+
+	labels := spinWords(words, spinOptions{
+		prefixes:   splitList(*prefixes),
+		suffixes:   splitList(*suffixes),
+		dropVowels: *noVowels,
+		double:     *double,
+	})
+	if *output == "" {
+		for _, l := range labels {
+			fmt.Println(l + ".com")
+		}
+		return 0
+	}
+
+	list := make([]DomainRecord, len(labels))
+	for i, l := range labels {
+		list[i] = DomainRecord{Domain: l + ".com"}
+	}
+	if err := writeSuggestionsFile(*output, list); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing suggestions file:", err)
+		return 1
+	}
+	fmt.Printf("Spun %d candidates from %d words, wrote to %s (duplicates removed)\n", len(labels), len(words), *output)
+	return 0
+}
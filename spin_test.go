@@ -0,0 +1,68 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSpinWords checks the transform order, deduplication, and that invalid
+// labels are skipped.
+func TestSpinWords(t *testing.T) {
+	t.Parallel()
+	got := spinWords([]string{"Flicker", "flicker", "-x"}, spinOptions{
+		prefixes:   []string{"get"},
+		suffixes:   []string{"hq", "-"},
+		dropVowels: true,
+		double:     true,
+	})
+	want := "flicker getflicker flickerhq flckr flickerr"
+	if strings.Join(got, " ") != want {
+		t.Errorf("spinWords = %q, want %q", got, want)
+	}
+
+	if got := spinWords([]string{"ai"}, spinOptions{dropVowels: true}); strings.Join(got, " ") != "ai" {
+		t.Errorf("one-letter vowel drop kept: %q", got)
+	}
+}
+
+// TestRunCLISpinSubcommand checks printing and writing to unverified.
+func TestRunCLISpinSubcommand(t *testing.T) {
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"spin", "--prefixes=try", "--suffixes=", "--drop-vowels=false", "--double=false", "cloud"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "cloud.com\ntrycloud.com\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.json")
+	if err := os.WriteFile(path, []byte(`{"unavailable":[{"domain":"cloud.com","reason":"TAKEN"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"spin", "--output", path, "--suffixes=ly", "--drop-vowels=false", "--double=false", "--prefixes=", "cloud"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	var ext ExtendedGroupedData
+	raw, _ := os.ReadFile(path)
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		t.Fatal(err)
+	}
+	if len(ext.Unverified) != 1 || ext.Unverified[0].Domain != "cloudly.com" {
+		t.Errorf("unverified = %+v", ext.Unverified)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"spin"}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "Usage: talia spin") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,98 @@
+package talia
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// splitIndexName is the index file --split-output writes next to the
+// bucket files.
+const splitIndexName = "index.json"
+
+// splitBucket describes one bucket file in the split index.
+type splitBucket struct {
+	File  string `json:"file"`
+	Count int    `json:"count"`
+}
+
+// splitIndex is the content of index.json: where each bucket went and how
+// big it is, so a consumer can pick a file without opening the others.
+type splitIndex struct {
+	Source    string                 `json:"source"`
+	RunID     string                 `json:"runId,omitempty"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+	Buckets   map[string]splitBucket `json:"buckets"`
+}
+
+// splitGroupedFile writes the buckets of the grouped file at path (with its
+// pending log) to available.json, unavailable.json, and unverified.json in
+// dir, each a JSON array, plus index.json. The grouped file itself is left
+// as it is.
+func splitGroupedFile(path, dir, runID string, now time.Time) (splitIndex, error) {
+	index := splitIndex{Source: path, RunID: runID, UpdatedAt: now.UTC(), Buckets: map[string]splitBucket{}}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		return index, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return index, err
+	}
+	buckets := []struct {
+		name    string
+		records any
+		count   int
+	}{
+		{bucketAvailable, nonNil(data.Available), len(data.Available)},
+		{bucketUnavailable, nonNil(data.Unavailable), len(data.Unavailable)},
+		{bucketUnverified, nonNil(data.Unverified), len(data.Unverified)},
+	}
+	for _, b := range buckets {
+		file := b.name + ".json"
+		if err := writeSplitFile(filepath.Join(dir, file), runID, b.records); err != nil {
+			return index, err
+		}
+		index.Buckets[b.name] = splitBucket{File: file, Count: b.count}
+	}
+	return index, writeSplitFile(filepath.Join(dir, splitIndexName), runID, index)
+}
+
+// writeSplitFile writes one file of a split with writeJSONFile, recording it
+// in the audit log.
+func writeSplitFile(path, runID string, v any) error {
+	return auditWrite("split", runID, path, func() error { return writeJSONFile(path, v) })
+}
+
+// nonNil returns s, or an empty slice for nil so it is written as [].
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// writeJSONFile writes v to path as indented JSON, atomically.
+func writeJSONFile(path string, v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(out, '\n'), 0644)
+}
+
+// splitRunResults writes the split files for --split-output after a run
+// wrote its grouped results to path. It returns the process exit code.
+func splitRunResults(cfg runConfig, path string) int {
+	if cfg.splitDir == "" {
+		return 0
+	}
+	index, err := splitGroupedFile(path, cfg.splitDir, cfg.runID, cfg.timeSource().Now())
+	if err != nil {
+		return fail(cliError{Code: errCodeOutputWrite, Path: cfg.splitDir}, "Error splitting %s: %v", path, err)
+	}
+	fmt.Fprintf(cfg.status(), "Split %s into %s (%d available, %d unavailable, %d unverified)\n", path, cfg.splitDir,
+		index.Buckets[bucketAvailable].Count, index.Buckets[bucketUnavailable].Count, index.Buckets[bucketUnverified].Count)
+	return 0
+}
@@ -0,0 +1,78 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSplitGroupedFile writes one array per bucket and an index with the
+// counts.
+func TestSplitGroupedFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	if err := writeGrouped(path, GroupedData{
+		Available:  []GroupedDomain{{Domain: "a.com"}, {Domain: "b.com"}},
+		Unverified: []DomainRecord{{Domain: "c.com"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(t.TempDir(), "split")
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := splitGroupedFile(path, dir, "run-1", now); err != nil {
+		t.Fatal(err)
+	}
+
+	var available []GroupedDomain
+	raw, _ := os.ReadFile(filepath.Join(dir, "available.json"))
+	if err := json.Unmarshal(raw, &available); err != nil || len(available) != 2 {
+		t.Errorf("available.json = %s", raw)
+	}
+	if raw, _ := os.ReadFile(filepath.Join(dir, "unavailable.json")); strings.TrimSpace(string(raw)) != "[]" {
+		t.Errorf("unavailable.json = %s", raw)
+	}
+	var index splitIndex
+	raw, _ = os.ReadFile(filepath.Join(dir, "index.json"))
+	if err := json.Unmarshal(raw, &index); err != nil {
+		t.Fatal(err)
+	}
+	if index.Source != path || index.RunID != "run-1" || !index.UpdatedAt.Equal(now) ||
+		index.Buckets["available"] != (splitBucket{File: "available.json", Count: 2}) || index.Buckets["unverified"].Count != 1 {
+		t.Errorf("index = %+v", index)
+	}
+}
+
+// TestRunCLI_SplitOutput splits the grouped file after a run and requires
+// grouped output for a domain list.
+func TestRunCLI_SplitOutput(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := writeGroupedFixture(t, GroupedData{Unverified: []DomainRecord{{Domain: "new.com"}}})
+	dir := filepath.Join(t.TempDir(), "split")
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", "--split-output=" + dir, path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "(1 available, 0 unavailable, 0 unverified)") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if raw, err := os.ReadFile(filepath.Join(dir, "available.json")); err != nil || !strings.Contains(string(raw), "new.com") {
+		t.Errorf("available.json = %s, %v", raw, err)
+	}
+
+	list := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(list, []byte(`[{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--split-output=" + dir, list}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "--grouped-output") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
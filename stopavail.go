@@ -0,0 +1,62 @@
+package talia
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// stopOnAvailableFlag is the value of --stop-on-available[=N]: the number of
+// available domains after which a run stops, or 0 to check every domain. The
+// bare flag means 1.
+type stopOnAvailableFlag int
+
+func (f *stopOnAvailableFlag) String() string { return strconv.Itoa(int(*f)) }
+
+// IsBoolFlag lets the flag be given without a value.
+func (f *stopOnAvailableFlag) IsBoolFlag() bool { return true }
+
+func (f *stopOnAvailableFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*f = 1
+		return nil
+	case "false":
+		*f = 0
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return fmt.Errorf("must be a positive number of available domains")
+	}
+	*f = stopOnAvailableFlag(n)
+	return nil
+}
+
+// availableGoal counts the available domains a run has found, for
+// --stop-on-available. It is shared by the copies of a run's checkLimits and
+// safe for concurrent use; a nil goal is never reached.
+type availableGoal struct {
+	want  int64
+	found atomic.Int64
+}
+
+// newAvailableGoal returns a goal of want available domains, or nil for 0.
+func newAvailableGoal(want int) *availableGoal {
+	if want <= 0 {
+		return nil
+	}
+	return &availableGoal{want: int64(want)}
+}
+
+// record counts res if it is available.
+func (g *availableGoal) record(res checkResult) {
+	if g != nil && res.Avail {
+		g.found.Add(1)
+	}
+}
+
+// reached reports whether enough available domains have been found.
+func (g *availableGoal) reached() bool {
+	return g != nil && g.found.Load() >= g.want
+}
@@ -0,0 +1,65 @@
+package talia
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+func TestStopOnAvailableFlag(t *testing.T) {
+	for in, want := range map[string]stopOnAvailableFlag{"true": 1, "false": 0, "3": 3} {
+		var f stopOnAvailableFlag
+		if err := f.Set(in); err != nil || f != want {
+			t.Errorf("Set(%q) = %d, %v; want %d", in, f, err, want)
+		}
+	}
+	for _, in := range []string{"0", "-2", "some"} {
+		var f stopOnAvailableFlag
+		if err := f.Set(in); err == nil {
+			t.Errorf("Set(%q) accepted", in)
+		}
+	}
+}
+
+// TestRunCLI_StopOnAvailable stops querying once enough available domains
+// are found, writes them, and keeps the rest in unverified.
+func TestRunCLI_StopOnAvailable(t *testing.T) {
+	records := []DomainRecord{{Domain: "taken.com"}, {Domain: "one.com"}, {Domain: "two.com"}, {Domain: "three.com", Favorite: true}}
+
+	for _, tc := range []struct {
+		flag    string
+		queries []string
+	}{
+		{"--stop-on-available", []string{"taken.com", "one.com"}},
+		{"--stop-on-available=2", []string{"taken.com", "one.com", "two.com"}},
+	} {
+		srv := newWhoisServer(t)
+		srv.Handle("taken.com", taliatest.Response{Body: taliatest.Registered("taken.com")})
+		srv.Handle("one.com", taliatest.Response{Body: taliatest.Available("one.com")})
+		srv.Handle("two.com", taliatest.Response{Body: taliatest.Available("two.com")})
+		path := writeGroupedFixture(t, GroupedData{Unverified: records})
+		stdout, _ := captureOutput(t, func() {
+			if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", tc.flag, path}); code != 0 {
+				t.Errorf("%s: exit %d", tc.flag, code)
+			}
+		})
+		if got := srv.Queries(); !slices.Equal(got, tc.queries) {
+			t.Errorf("%s: queries = %v", tc.flag, got)
+		}
+		if !strings.Contains(stdout, "Stop on available: found") {
+			t.Errorf("%s: stdout = %q", tc.flag, stdout)
+		}
+		data, err := readGroupedFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data.Available)+len(data.Unavailable) != len(tc.queries) {
+			t.Errorf("%s: checked %+v %+v", tc.flag, data.Available, data.Unavailable)
+		}
+		if last := data.Unverified[len(data.Unverified)-1]; last.Domain != "three.com" || !last.Favorite {
+			t.Errorf("%s: unverified = %+v", tc.flag, data.Unverified)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package talia
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// streamArg is the argument of "talia check" that reads domains from stdin.
+const streamArg = "-"
+
+// errStreamInput wraps the error streamChecks returns when reading its input
+// fails, to tell it apart from failing to write the verdicts.
+var errStreamInput = errors.New("reading domains")
+
+// streamChecks reads domains from in, one per line, for as long as it stays
+// open, and writes each verdict to out as a JSON line as soon as it is
+// known, so Talia can sit in a shell pipeline as a long-lived filter. Blank
+// lines and "#" comments are skipped, and invalid domains are reported on
+// cfg's status output without stopping the stream.
+//
+// Sequential streams keep cfg's sleep between queries, counting the time
+// spent waiting for input; with cfg.workers set, that many domains are
+// checked at once (-1 for no limit) and verdicts come out as they complete.
+// Records that don't pass filter are not written. Once cfg.limits' deadline
+// passes, the rest of the input is left unread.
+func streamChecks(cfg runConfig, in io.Reader, out io.Writer, filter recordFilter) error {
+	clk := cfg.timeSource()
+	stats := newCheckStats(cfg.status())
+	if cfg.runID != "" {
+		fmt.Fprintln(cfg.status(), "Run ID:", cfg.runID)
+	}
+
+	var mu sync.Mutex
+	var writeErr error
+	enc := json.NewEncoder(out)
+	emit := func(res checkResult) {
+		stats.Record(res.Avail, res.Reason)
+		rec := res.record()
+		if !filter.match(rec, time.Now()) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if writeErr == nil {
+			writeErr = enc.Encode(rec)
+		}
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return writeErr != nil
+	}
+
+	var sem chan struct{}
+	if cfg.workers > 0 {
+		sem = make(chan struct{}, cfg.workers)
+	}
+	var wg sync.WaitGroup
+	var last time.Time
+	var pause time.Duration
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() && !failed() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domain, err := checkArgDomain(line)
+		if err != nil {
+			fmt.Fprintln(cfg.status(), "Skipping:", err)
+			continue
+		}
+		if cfg.limits.expired(clk.Now()) {
+			fmt.Fprintln(cfg.status(), "Run timeout: stopped reading domains")
+			break
+		}
+		if cfg.workers == 0 {
+			if wait := pause - clk.Now().Sub(last); !last.IsZero() && wait > 0 {
+				clk.Sleep(wait)
+			}
+			emit(streamCheck(domain, cfg, clk))
+			last, pause = clk.Now(), cfg.sleepFor(domain)
+			continue
+		}
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			emit(streamCheck(domain, cfg, clk))
+		}()
+	}
+	wg.Wait()
+	stats.PrintSummary()
+	if writeErr != nil {
+		return writeErr
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: %w", errStreamInput, err)
+	}
+	return nil
+}
+
+// streamCheck checks one streamed domain. The run-wide WHOIS cache would
+// answer a domain sent again hours later with the first response, so each
+// lookup gets its own, sharing only the --whois-cache directory.
+func streamCheck(domain string, cfg runConfig, clk clock) checkResult {
+	if c := cfg.whoisCache; c != nil && c.dir != "" {
+		cfg.whoisCache = newWhoisCache(c.dir, c.ttl, clk)
+		cfg.whoisCache.readOnly = c.readOnly
+	} else {
+		cfg.whoisCache = nil
+	}
+	results := []checkResult{checkOne(domain, cfg)}
+	enrichResults(results, cfg)
+	return results[0]
+}
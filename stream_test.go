@@ -0,0 +1,114 @@
+package talia
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// streamRecords decodes the JSON lines written by streamChecks.
+func streamRecords(t *testing.T, out []byte) []DomainRecord {
+	t.Helper()
+	records, err := parseJSONLines(out)
+	if err != nil {
+		t.Fatalf("output is not JSON Lines: %v\n%s", err, out)
+	}
+	return records
+}
+
+// TestStreamChecksSequential writes a line per valid domain, skips blanks,
+// comments, and invalid names, and keeps the sleep between queries.
+func TestStreamChecksSequential(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("free.com", taliatest.Response{Body: taliatest.Available("free.com")})
+	srv.Handle("taken.com", taliatest.Response{Body: taliatest.Registered("taken.com")})
+	clk := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	cfg := runConfig{whoisServer: srv.Addr, sleep: 2 * time.Second, clock: clk, runID: "r1"}
+
+	var out bytes.Buffer
+	in := strings.NewReader("free.com\n\n# comment\nnot a domain\nTaken.com\n")
+	_, stderr := captureOutput(t, func() {
+		cfg.statusOut = os.Stderr
+		if err := streamChecks(cfg, in, &out, recordFilter{}); err != nil {
+			t.Errorf("streamChecks: %v", err)
+		}
+	})
+
+	records := streamRecords(t, out.Bytes())
+	if len(records) != 2 || records[0].Domain != "free.com" || !records[0].Available || records[1].Domain != "taken.com" || records[1].Status != StatusTaken {
+		t.Fatalf("records = %+v", records)
+	}
+	if records[0].RunID != "r1" {
+		t.Errorf("runId = %q", records[0].RunID)
+	}
+	if !slices.Equal(clk.sleeps, []time.Duration{2 * time.Second}) {
+		t.Errorf("sleeps = %v, want one 2s pause", clk.sleeps)
+	}
+	if !strings.Contains(stderr, `Skipping: invalid domain "not a domain"`) {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestStreamChecksEmitsBeforeEOF writes each verdict while stdin is still
+// open.
+func TestStreamChecksEmitsBeforeEOF(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("free.com", taliatest.Response{Body: taliatest.Available("free.com")})
+	cfg := runConfig{whoisServer: srv.Addr, workers: 2, statusOut: os.Stderr}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- streamChecks(cfg, inR, outW, recordFilter{}) }()
+
+	if _, err := io.WriteString(inW, "free.com\n"); err != nil {
+		t.Fatal(err)
+	}
+	var rec DomainRecord
+	if err := json.NewDecoder(outR).Decode(&rec); err != nil || rec.Domain != "free.com" || !rec.Available {
+		t.Fatalf("first line = %+v (%v)", rec, err)
+	}
+	_ = inW.Close()
+	go func() { _, _ = io.Copy(io.Discard, outR) }()
+	if err := <-done; err != nil {
+		t.Errorf("streamChecks: %v", err)
+	}
+}
+
+// TestStreamChecksFilter writes only the records that pass the filter.
+func TestStreamChecksFilter(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("free.com", taliatest.Response{Body: taliatest.Available("free.com")})
+	srv.Handle("taken.com", taliatest.Response{Body: taliatest.Registered("taken.com")})
+	cfg := runConfig{whoisServer: srv.Addr, workers: -1}
+
+	var out bytes.Buffer
+	captureOutput(t, func() {
+		cfg.statusOut = os.Stderr
+		if err := streamChecks(cfg, strings.NewReader("free.com\ntaken.com\n"), &out, recordFilter{registrar: "example"}); err != nil {
+			t.Errorf("streamChecks: %v", err)
+		}
+	})
+	if records := streamRecords(t, out.Bytes()); len(records) != 1 || records[0].Domain != "taken.com" {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+// TestRunCheckCommandStreamArgs rejects "-" mixed with domains.
+func TestRunCheckCommandStreamArgs(t *testing.T) {
+	_, stderr := captureOutput(t, func() {
+		if code := runCheckCommand([]string{"-", "a.com"}); code == 0 {
+			t.Error("exit 0, want an error")
+		}
+	})
+	if !strings.Contains(stderr, "can't be combined with domain arguments") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
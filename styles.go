@@ -0,0 +1,43 @@
+package talia
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// promptStyles are the --style presets: curated naming instructions put in
+// front of the user's --prompt, so a good request doesn't take prompt
+// engineering.
+var promptStyles = map[string]string{
+	"brandable": "Invent brandable names: short (ideally 5-8 letters), easy to say and spell on first hearing, " +
+		"made-up or blended words rather than dictionary phrases, with no hyphens or digits.",
+	"descriptive": "Suggest descriptive names that say plainly what the product or business does, " +
+		"using common words a customer would search for, with no hyphens or digits.",
+	"two-word": "Suggest names made of exactly two real, common English words joined together " +
+		"(like 'bluebottle' or 'fastlane') that read naturally, with no hyphens or digits.",
+	"short": "Suggest the shortest possible names: at most 6 letters before .com, " +
+		"pronounceable, with no hyphens or digits.",
+}
+
+// styleNames returns the --style values in sorted order.
+func styleNames() []string {
+	return slices.Sorted(maps.Keys(promptStyles))
+}
+
+// styledPrompt returns prompt wrapped with the instructions of style, or
+// prompt unchanged for style "".
+func styledPrompt(style, prompt string) (string, error) {
+	if style == "" {
+		return prompt, nil
+	}
+	instructions, ok := promptStyles[strings.ToLower(style)]
+	if !ok {
+		return "", fmt.Errorf("unknown --style %q: want one of %s", style, strings.Join(styleNames(), ", "))
+	}
+	if prompt == "" {
+		return instructions, nil
+	}
+	return instructions + " Topic: " + prompt, nil
+}
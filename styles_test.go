@@ -0,0 +1,67 @@
+package talia
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStyledPrompt puts the preset in front of the prompt and rejects
+// unknown styles.
+func TestStyledPrompt(t *testing.T) {
+	t.Parallel()
+	if got, err := styledPrompt("", "coffee"); err != nil || got != "coffee" {
+		t.Errorf("no style: %q, %v", got, err)
+	}
+	got, err := styledPrompt("Two-Word", "coffee")
+	if err != nil || !strings.HasPrefix(got, promptStyles["two-word"]) || !strings.HasSuffix(got, "Topic: coffee") {
+		t.Errorf("two-word: %q, %v", got, err)
+	}
+	if got, err := styledPrompt("short", ""); err != nil || got != promptStyles["short"] {
+		t.Errorf("style without prompt: %q, %v", got, err)
+	}
+	if _, err := styledPrompt("fancy", "x"); err == nil || !strings.Contains(err.Error(), "brandable, descriptive, short, two-word") {
+		t.Errorf("unknown style error = %v", err)
+	}
+}
+
+// TestRunCLISuggestStyle sends the preset instructions to the model.
+func TestRunCLISuggestStyle(t *testing.T) {
+	var request string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		request = string(raw)
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"tool_calls":[{"function":{"name":"suggest_domains","arguments":"{\"unverified\":[{\"domain\":\"b.com\"}]}"}}]}}]}`)
+	}))
+	defer srv.Close()
+	testHTTPClient = fakeHTTPClient{srv}
+	testBaseURL = srv.URL
+	t.Cleanup(func() {
+		testHTTPClient = nil
+		testBaseURL = ""
+	})
+	t.Setenv("OPENAI_API_KEY", "key")
+	t.Setenv("TALIA_STYLE", "")
+
+	path := filepath.Join(t.TempDir(), "sugg.json")
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=1", "--style=brandable", "--prompt=coffee", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(request, "Invent brandable names") || !strings.Contains(request, "Topic: coffee") {
+		t.Errorf("request = %s", request)
+	}
+
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=1", "--style=fancy", path}); code != 1 {
+			t.Errorf("exit %d, want 1", code)
+		}
+	})
+	if !strings.Contains(stderr, "unknown --style") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
@@ -0,0 +1,321 @@
+package talia
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// runSubcommand dispatches "talia <name> ..." invocations. It reports false
+// when args do not start with a known subcommand, so RunCLI falls through to
+// the flag-based file workflow.
+func runSubcommand(args []string) (int, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "whois":
+		return runWhoisCommand(args[1:]), true
+	case "check":
+		return runCheckCommand(args[1:]), true
+	case "report":
+		return runReportCommand(args[1:]), true
+	case "tld-info":
+		return runTLDInfoCommand(args[1:]), true
+	case "update-servers":
+		return runUpdateServersCommand(args[1:]), true
+	case "spin":
+		return runSpinCommand(args[1:]), true
+	case "lookalikes":
+		return runLookalikesCommand(args[1:]), true
+	case "import":
+		return runImportCommand(args[1:]), true
+	case "watchlist":
+		return runWatchlistCommand(args[1:]), true
+	case "clean":
+		return runCleanCommand(args[1:]), true
+	case "export":
+		return runExportCommand(args[1:]), true
+	case "set":
+		return runSetCommand(args[1:]), true
+	case "merge":
+		return runMergeCommand(args[1:]), true
+	case "new":
+		return runNewCommand(args[1:]), true
+	default:
+		return 0, false
+	}
+}
+
+// runWhoisCommand implements "talia whois <domain>": a single lookup whose
+// raw response is printed, followed by how Talia classified it. With --json
+// the classified record (including the response as "log") is printed instead.
+func runWhoisCommand(args []string) int {
+	fs := flag.NewFlagSet("talia whois", flag.ContinueOnError)
+	server := fs.String("server", "", "WHOIS server, e.g. whois.verisign-grs.com:43 (env: WHOIS_SERVER); default: by TLD")
+	asJSON := fs.Bool("json", false, "Print the classified record as JSON instead of the raw response")
+	queryFormat := fs.String("query-format", "", "Query template with %s for the domain, e.g. 'domain %s' (default: the bare domain)")
+	if err := applyProfile(fs); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := fs.Parse(args); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error parsing flags: %v", err)
+	}
+	if fs.NArg() != 1 {
+		return fail(cliError{Code: errCodeUsage}, "Usage: talia whois [--server=host:port] [--json] <domain>")
+	}
+	domain := fs.Arg(0)
+
+	if *server == "" {
+		*server = os.Getenv("WHOIS_SERVER")
+	}
+	if *server == "" {
+		routed, err := routeServer(domain)
+		if err != nil {
+			return fail(cliError{Code: errCodeUsage, Hint: "pass --server=host:port"}, "Error: %v", err)
+		}
+		*server = routed
+	}
+
+	queries, err := parseQueryFormats(*queryFormat)
+	if err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	res := checkOne(domain, runConfig{whoisServer: *server, queryFormats: queries, verbose: true})
+	if *asJSON {
+		out, err := json.MarshalIndent(res.record(), "", "  ")
+		if err != nil {
+			return fail(cliError{Code: errCodeGeneric}, "Error marshaling JSON: %v", err)
+		}
+		fmt.Println(string(out))
+	} else {
+		if res.Reason == ReasonError {
+			fmt.Fprintln(os.Stderr, res.Log)
+		} else {
+			fmt.Print(res.Log)
+		}
+		fmt.Fprintf(os.Stderr, "\n%s: status=%s reason=%s confidence=%.2f", domain, statusFor(res.Avail, res.Reason), res.Reason, res.Confidence)
+		rec := res.record()
+		if rec.PrivacyProtected {
+			fmt.Fprint(os.Stderr, " privacyProtected")
+		}
+		if rec.OnHold {
+			fmt.Fprint(os.Stderr, " onHold")
+		}
+		if rec.InRedemption {
+			fmt.Fprint(os.Stderr, " inRedemption")
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if res.Reason == ReasonError {
+		emitError(cliError{Code: errCodeWhoisUnreachable, Message: errorMessage(res.Log), Path: *server})
+		return 1
+	}
+	return 0
+}
+
+// parseInterspersed parses args with fs, allowing flags after positional
+// arguments (e.g. "a.com b.io --whois=..."), and returns the positionals.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	if err := applyProfile(fs); err != nil {
+		return nil, err
+	}
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// checkFlags are the checking flags of subcommands that run WHOIS checks and
+// print the results; they behave as in file mode.
+type checkFlags struct {
+	whoisServer string
+	sleep       time.Duration
+	lightspeed  string
+	verbose     bool
+	dnsPrecheck bool
+	runID       string
+	queryFormat string
+	portfolio   string
+	whoisCache  time.Duration
+	perDomain   time.Duration
+	runTimeout  time.Duration
+	sourceIP    string
+	ipVersion   string
+}
+
+// addCheckFlags registers the checking flags on fs.
+func addCheckFlags(fs *flag.FlagSet) *checkFlags {
+	f := &checkFlags{}
+	fs.StringVar(&f.whoisServer, "whois", "", "WHOIS server, e.g. whois.verisign-grs.com:43 (env: WHOIS_SERVER); default: by TLD")
+	fs.DurationVar(&f.sleep, "sleep", 2*time.Second, "Time to sleep between domain checks (default 2s)")
+	fs.StringVar(&f.lightspeed, "lightspeed", "", "Parallel workers: number or 'max' (env: TALIA_LIGHTSPEED)")
+	fs.BoolVar(&f.verbose, "verbose", false, "Include WHOIS log in 'log' field even for successful checks")
+	fs.BoolVar(&f.dnsPrecheck, "dns-precheck", false, "Resolve NS records first and skip WHOIS for domains that are clearly registered")
+	fs.StringVar(&f.runID, "run-id", "", "ID stamped on results to correlate runs (env: TALIA_RUN_ID); default: generated")
+	fs.StringVar(&f.queryFormat, "query-format", "", "WHOIS query template with %s for the domain, e.g. 'domain %s', optionally per server or TLD: 'whois.denic.de:43=-T dn %s,.jp=%s/e'")
+	fs.DurationVar(&f.whoisCache, "whois-cache", 0, "Reuse WHOIS responses younger than this, e.g. 24h, kept in the cache directory (0 = no cache)")
+	fs.DurationVar(&f.perDomain, "per-domain-timeout", 0, "Give up on a domain's WHOIS lookup after this long, connecting included, and record an error (0 = no limit)")
+	fs.DurationVar(&f.runTimeout, "run-timeout", 0, "Stop starting new checks this long after the run began; unchecked domains are left out (0 = no limit)")
+	fs.StringVar(&f.sourceIP, "source-ip", "", "Send WHOIS queries from this local address, or cycle through a comma-separated list of them (default: chosen by the system)")
+	fs.StringVar(&f.ipVersion, "ip-version", "auto", "IP version for WHOIS connections: '4', '6', or 'auto' (either, IPv6 first with a quick IPv4 fallback)")
+	fs.StringVar(&f.portfolio, "portfolio", "", "File of domains we own (plus 'registrant:' and 'nameserver:' patterns); taken domains that match get ownedByUs (env: TALIA_PORTFOLIO)")
+	return f
+}
+
+// config returns the run configuration for the flags. Progress and the
+// summary go to stderr so stdout holds only the results.
+func (f *checkFlags) config() (runConfig, error) {
+	queries, err := parseQueryFormats(f.queryFormat)
+	if err != nil {
+		return runConfig{}, err
+	}
+	cfg := runConfig{
+		whoisServer:    f.whoisServer,
+		sleep:          f.sleep,
+		verbose:        f.verbose,
+		workers:        parseWorkers(f.lightspeed),
+		dnsPrecheck:    f.dnsPrecheck,
+		dnsConcurrency: defaultDNSConcurrency,
+		statusOut:      os.Stderr,
+		runID:          runID(f.runID, time.Now()),
+		queryFormats:   queries,
+	}
+	if cfg.whoisServer == "" {
+		cfg.whoisServer = os.Getenv("WHOIS_SERVER")
+	}
+	if cfg.limits, err = newCheckLimits(f.perDomain, f.runTimeout, time.Now()); err != nil {
+		return runConfig{}, err
+	}
+	if cfg.dialer, err = newWhoisDialer(f.sourceIP, f.ipVersion); err != nil {
+		return runConfig{}, err
+	}
+	if err := applyWhoisCache(&cfg, f.whoisCache); err != nil {
+		return runConfig{}, err
+	}
+	if err := applyPortfolio(&cfg, f.portfolio); err != nil {
+		return runConfig{}, err
+	}
+	return cfg, nil
+}
+
+// checkArgDomain normalizes a domain given to "talia check". normalizeDomain
+// is .com-only; any TLD may be checked here.
+func checkArgDomain(d string) (string, error) {
+	domain := strings.ToLower(strings.TrimSpace(d))
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "", fmt.Errorf("invalid domain %q", d)
+	}
+	if isPublicSuffix(domain) {
+		return "", fmt.Errorf("invalid domain %q: .%s is a public suffix, not a registrable name", d, domain)
+	}
+	return domain, nil
+}
+
+// runCheckCommand implements "talia check <domain>...": the domains given as
+// arguments (brace patterns such as 'get{app,kit}.{com,io}' are expanded) are
+// checked and the results printed to stdout, without reading or writing any
+// file. Progress goes to stderr so stdout holds only the results. Given "-"
+// instead, it reads domains from stdin and streams the verdicts (see
+// streamChecks).
+func runCheckCommand(args []string) int {
+	fs := flag.NewFlagSet("talia check", flag.ContinueOnError)
+	checks := addCheckFlags(fs)
+	format := fs.String("format", formatTable, "Output format: 'table', 'csv', or 'json'")
+	filter := addFilterFlags(fs)
+	alternatives := addAlternativesFlags(fs)
+	valuation := addValuationFlags(fs)
+	pricing := addPricingFlags(fs)
+	parked := addParkedFlags(fs)
+	httpProbe := addHTTPProbeFlags(fs)
+	sortBy := addSortFlag(fs)
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error parsing flags: %v", err)
+	}
+	if len(args) == 0 {
+		return fail(cliError{Code: errCodeUsage}, "Usage: talia check [--whois=host:port] [--format=table|csv|json] <domain>... | -")
+	}
+	stream := slices.Contains(args, streamArg)
+	if stream && len(args) > 1 {
+		return fail(cliError{Code: errCodeUsage}, "Error: \"-\" reads every domain from stdin and can't be combined with domain arguments")
+	}
+	if !validFormat(*format) {
+		return fail(cliError{Code: errCodeUsage}, "Error: --format must be %q, %q, or %q", formatTable, formatCSV, formatJSON)
+	}
+	if !validSort(*sortBy) {
+		return fail(cliError{Code: errCodeUsage}, "Error: --sort must be %q or %q", sortDomain, sortValue)
+	}
+
+	if stream && (*format == formatCSV || *sortBy != "" || checks.dnsPrecheck) {
+		return fail(cliError{Code: errCodeUsage}, "Error: \"-\" writes each verdict as a JSON line as it arrives; --format=csv, --sort, and --dns-precheck need the whole list")
+	}
+
+	// Streamed domains are read once the run is set up.
+	if stream {
+		args = nil
+	}
+	domains := make([]string, 0, len(args))
+	for _, arg := range args {
+		expanded, err := expandBraces(arg)
+		if err != nil {
+			return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+		}
+		for _, d := range expanded {
+			domain, err := checkArgDomain(d)
+			if err != nil {
+				return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+			}
+			domains = append(domains, domain)
+		}
+	}
+
+	cfg, err := checks.config()
+	if err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := alternatives.apply(&cfg, openAIModel(defaultOpenAIModel), openAIBaseURL("")); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := valuation.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := pricing.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := parked.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if err := httpProbe.apply(&cfg); err != nil {
+		return fail(cliError{Code: errCodeUsage}, "Error: %v", err)
+	}
+	if stream {
+		if err := streamChecks(cfg, os.Stdin, os.Stdout, *filter); err != nil {
+			code := errCodeOutputWrite
+			if errors.Is(err, errStreamInput) {
+				code = errCodeInputRead
+			}
+			return fail(cliError{Code: code}, "Error streaming results: %v", err)
+		}
+		return 0
+	}
+	records := filter.apply(resultRecords(checkedResults(checkDomains(domains, cfg))))
+	sortRecords(records, *sortBy)
+	if err := writeRecords(os.Stdout, *format, records); err != nil {
+		return fail(cliError{Code: errCodeOutputWrite}, "Error writing results: %v", err)
+	}
+	return 0
+}
@@ -0,0 +1,137 @@
+package talia
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// newWhoisServer starts a fake WHOIS server that is closed when the test
+// ends.
+func newWhoisServer(t *testing.T) *taliatest.Server {
+	t.Helper()
+	srv := taliatest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// startWhoisServer serves resp to every query until the test ends and
+// returns the server address.
+func startWhoisServer(t *testing.T, resp string) string {
+	t.Helper()
+	srv := newWhoisServer(t)
+	srv.HandleFunc(func(string) taliatest.Response { return taliatest.Response{Body: resp} })
+	return srv.Addr
+}
+
+// TestRunCLIWhoisSubcommand prints the raw response on stdout and the
+// classification on stderr.
+func TestRunCLIWhoisSubcommand(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: EXAMPLE.COM\nRegistrant: REDACTED FOR PRIVACY\n")
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"whois", "--server=" + addr, "example.com"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "Domain Name: EXAMPLE.COM\nRegistrant: REDACTED FOR PRIVACY\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if !strings.Contains(stderr, "status=taken reason=TAKEN") || !strings.Contains(stderr, "privacyProtected") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestRunCLIWhoisSubcommandJSON checks --json output and the env fallback.
+func TestRunCLIWhoisSubcommandJSON(t *testing.T) {
+	t.Setenv("WHOIS_SERVER", startWhoisServer(t, "No match for \"FREE.COM\".\n"))
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"whois", "--json", "free.com"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	var rec DomainRecord
+	if err := json.Unmarshal([]byte(stdout), &rec); err != nil {
+		t.Fatalf("bad JSON %q: %v", stdout, err)
+	}
+	if rec.Status != StatusAvailable || rec.Log == "" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+// TestRunCLIWhoisSubcommandErrors covers usage and lookup failures.
+func TestRunCLIWhoisSubcommandErrors(t *testing.T) {
+	t.Setenv("WHOIS_SERVER", "")
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"whois"}); code != 1 {
+			t.Errorf("missing domain: exit %d", code)
+		}
+		if code := RunCLI([]string{"whois", "a.unknown-tld"}); code != 1 {
+			t.Errorf("unroutable TLD: exit %d", code)
+		}
+		if code := RunCLI([]string{"whois", "--server=127.0.0.1:1", "a.com"}); code != 1 {
+			t.Errorf("dial failure: exit %d", code)
+		}
+	})
+	if !strings.Contains(stderr, "Usage: talia whois") || !strings.Contains(stderr, "no WHOIS server known for .unknown-tld") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestRunCLICheckSubcommand checks domains given as arguments, with flags
+// after them, and prints a table on stdout and progress on stderr.
+func TestRunCLICheckSubcommand(t *testing.T) {
+	addr := startWhoisServer(t, "No match for domain\n")
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "Free.com", "other.io", "--whois=" + addr, "--sleep=0"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "DOMAIN") {
+		t.Fatalf("stdout = %q", stdout)
+	}
+	if !strings.HasPrefix(lines[1], "free.com ") || !strings.Contains(lines[1], "available") {
+		t.Errorf("row = %q", lines[1])
+	}
+	if !strings.Contains(stderr, "[2/2]") {
+		t.Errorf("progress missing from stderr: %q", stderr)
+	}
+}
+
+// TestRunCLICheckSubcommandJSON prints the records as a JSON array.
+func TestRunCLICheckSubcommandJSON(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: TAKEN.COM\n")
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"check", "--format=json", "--lightspeed=max", "--whois=" + addr, "--run-id=nightly-7", "taken.com"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	var recs []DomainRecord
+	if err := json.Unmarshal([]byte(stdout), &recs); err != nil {
+		t.Fatalf("unmarshal %q: %v", stdout, err)
+	}
+	if len(recs) != 1 || recs[0].Status != StatusTaken || recs[0].RunID != "nightly-7" {
+		t.Errorf("records = %+v", recs)
+	}
+	if !strings.Contains(stderr, "Run ID: nightly-7") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
+
+// TestRunCLICheckSubcommandErrors covers usage errors.
+func TestRunCLICheckSubcommandErrors(t *testing.T) {
+	t.Setenv("WHOIS_SERVER", "")
+	for _, args := range [][]string{
+		{"check"},
+		{"check", "bad"},
+		{"check", "--format=xml", "a.com"},
+	} {
+		_, _ = captureOutput(t, func() {
+			if code := RunCLI(args); code != 1 {
+				t.Errorf("%v: exit %d, want 1", args, code)
+			}
+		})
+	}
+}
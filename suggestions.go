@@ -1,12 +1,15 @@
 package talia
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 )
@@ -17,7 +20,7 @@ var validDomainLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
 
 const (
 	defaultOpenAIBase      = "https://api.openai.com/v1"
-	systemPrompt           = "You generate domain name ideas. All domain names must end with .com. Do not return any domain without .com."
+	systemPrompt           = "You generate domain name ideas. All domain names must end with .com. Do not return any domain without .com. For each domain, give a one-sentence rationale and the keywords from the request it draws on."
 	userPromptTemplate     = "%s Return %d unique domain suggestions in the 'unverified' array. Each domain must end with .com. Do not return any domain without .com."
 	userPromptWithExcludes = "%s Return %d unique domain suggestions in the 'unverified' array. Each domain must end with .com. Do not return any domain without .com. Do NOT suggest any of these existing domains: %s"
 	defaultOpenAIModel     = "gpt-5-mini"
@@ -85,6 +88,15 @@ func generateSuggestions(apiKey, prompt string, count int, model string, client
 								"type": "object",
 								"properties": map[string]any{
 									"domain": map[string]any{"type": "string"},
+									"rationale": map[string]any{
+										"type":        "string",
+										"description": "One short sentence on why this name fits the request.",
+									},
+									"keywords": map[string]any{
+										"type":        "array",
+										"items":       map[string]any{"type": "string"},
+										"description": "Keywords from the request that the name draws on.",
+									},
 								},
 								"required": []string{"domain"},
 							},
@@ -205,6 +217,30 @@ func normalizeDomain(domain string) string {
 	return d
 }
 
+// normalizeListDomain cleans up and validates a domain from a list the user
+// keeps, where any TLD is allowed: .com names are normalized as suggestions
+// are (see normalizeDomain), and others must be one valid label under their
+// public suffix (see publicSuffix), so brand.io and brand.co.uk are kept.
+// Returns empty string if the domain is invalid.
+func normalizeListDomain(domain string) string {
+	d := foldDomain(domain)
+	if strings.HasSuffix(d, ".com") {
+		return normalizeDomain(d)
+	}
+	for strings.Contains(d, "..") {
+		d = strings.ReplaceAll(d, "..", ".")
+	}
+	if registrableDomain(d) != d {
+		return ""
+	}
+	for label := range strings.SplitSeq(d, ".") {
+		if !validDomainLabel.MatchString(label) {
+			return ""
+		}
+	}
+	return d
+}
+
 // writeSuggestionsFile writes the suggested domains to path in the
 // ExtendedGroupedData format. If the file already exists, it merges
 // new suggestions with existing data and deduplicates.
@@ -235,7 +271,7 @@ func writeSuggestionsFile(path string, list []DomainRecord) error {
 		}
 		if !seen[domain] {
 			seen[domain] = true
-			existing.Unverified = append(existing.Unverified, DomainRecord{Domain: domain})
+			existing.Unverified = append(existing.Unverified, DomainRecord{Domain: domain, Rationale: rec.Rationale, Keywords: rec.Keywords})
 		}
 	}
 
@@ -243,7 +279,7 @@ func writeSuggestionsFile(path string, list []DomainRecord) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0644)
+	return auditWrite("suggest", "", path, func() error { return os.WriteFile(path, b, 0644) })
 }
 
 // cleanSuggestionsFile reads an existing suggestions file, normalizes all domains,
@@ -258,46 +294,52 @@ func cleanSuggestionsFile(path string) (removed []string, err error) {
 	if err := json.Unmarshal(raw, &data); err != nil {
 		return nil, err
 	}
+	if err := applyPendingLog(path, &data); err != nil {
+		return nil, err
+	}
 
 	seen := make(map[string]bool)
 	var cleaned ExtendedGroupedData
 
 	// Process available
 	for _, d := range data.Available {
-		n := normalizeDomain(d.Domain)
+		n := normalizeListDomain(d.Domain)
 		if n == "" {
 			removed = append(removed, d.Domain)
 			continue
 		}
 		if !seen[n] {
 			seen[n] = true
-			cleaned.Available = append(cleaned.Available, GroupedDomain{Domain: n, Reason: d.Reason, Log: d.Log})
+			d.Domain = n
+			cleaned.Available = append(cleaned.Available, d)
 		}
 	}
 
 	// Process unavailable
 	for _, d := range data.Unavailable {
-		n := normalizeDomain(d.Domain)
+		n := normalizeListDomain(d.Domain)
 		if n == "" {
 			removed = append(removed, d.Domain)
 			continue
 		}
 		if !seen[n] {
 			seen[n] = true
-			cleaned.Unavailable = append(cleaned.Unavailable, GroupedDomain{Domain: n, Reason: d.Reason, Log: d.Log})
+			d.Domain = n
+			cleaned.Unavailable = append(cleaned.Unavailable, d)
 		}
 	}
 
 	// Process unverified
 	for _, d := range data.Unverified {
-		n := normalizeDomain(d.Domain)
+		n := normalizeListDomain(d.Domain)
 		if n == "" {
 			removed = append(removed, d.Domain)
 			continue
 		}
 		if !seen[n] {
 			seen[n] = true
-			cleaned.Unverified = append(cleaned.Unverified, DomainRecord{Domain: n})
+			d.Domain = n
+			cleaned.Unverified = append(cleaned.Unverified, d)
 		}
 	}
 
@@ -305,52 +347,127 @@ func cleanSuggestionsFile(path string) (removed []string, err error) {
 	if err != nil {
 		return removed, err
 	}
-	return removed, os.WriteFile(path, out, 0644)
+	return removed, auditWrite("clean", "", path, func() error {
+		if err := writeFileAtomic(path, out, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(path)
+	})
 }
 
-// cleanTextFile reads a plain text domain list (one per line), normalizes,
-// removes invalid domains, deduplicates, and writes back sorted.
+// avgDomainLineBytes is a rough size of one line in a domain list, used to
+// estimate how many domains a text file holds from its size.
+const avgDomainLineBytes = 16
+
+// cleanTextFile reads a plain text domain list (one per line), expands brace
+// patterns (see expandBraces), normalizes, removes invalid domains,
+// deduplicates, and writes back in the original order.
+// The file is streamed, and very large lists are deduplicated with a
+// memory-bounded domainSet, so multi-million-line files don't need to fit in
+// memory.
 func cleanTextFile(path string) (removed []string, err error) {
-	raw, err := os.ReadFile(path)
+	in, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = in.Close() }()
 
-	seen := make(map[string]bool)
-	var cleaned []string
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+	seen, err := newDomainSet(int(info.Size() / avgDomainLineBytes))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = seen.Close() }()
 
-	for _, line := range strings.Split(string(raw), "\n") {
-		line = strings.TrimSpace(line)
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	w := bufio.NewWriter(tmp)
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
-		n := normalizeDomain(line)
-		if n == "" {
+		candidates, err := expandBraces(line)
+		if err != nil {
 			removed = append(removed, line)
 			continue
 		}
-		if !seen[n] {
-			seen[n] = true
-			cleaned = append(cleaned, n)
+		for _, c := range candidates {
+			n := normalizeListDomain(c)
+			if n == "" {
+				removed = append(removed, c)
+				continue
+			}
+			added, err := seen.Add(n)
+			if err != nil {
+				_ = tmp.Close()
+				return removed, err
+			}
+			if added {
+				_, _ = w.WriteString(n)
+				_ = w.WriteByte('\n')
+			}
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		_ = tmp.Close()
+		return removed, err
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return removed, err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return removed, err
+	}
+	if err := tmp.Close(); err != nil {
+		return removed, err
+	}
+	return removed, auditWrite("clean", "", path, func() error { return os.Rename(tmp.Name(), path) })
+}
 
-	content := strings.Join(cleaned, "\n")
-	if len(cleaned) > 0 {
-		content += "\n"
+// mergeError is an error from mergeFiles, with the file it concerns and the
+// code of the JSON error object it is reported as.
+type mergeError struct {
+	code string
+	path string
+	err  error
+}
+
+func (e *mergeError) Error() string { return e.err.Error() }
+func (e *mergeError) Unwrap() error { return e.err }
+
+// failMerge reports an error from mergeFiles (see fail).
+func failMerge(err error) int {
+	e := cliError{Code: errCodeGeneric}
+	var merr *mergeError
+	if errors.As(err, &merr) {
+		e.Code, e.Path = merr.code, merr.path
 	}
-	return removed, os.WriteFile(path, []byte(content), 0644)
+	return fail(e, "Error merging files: %v", err)
 }
 
 // mergeFiles merges domains from multiple input files into outputFile, deduplicating.
+// Checked domains win over unverified ones from any file, so outputs of
+// --shard runs, each holding the other shards' domains as unverified,
+// recombine into the full result.
 // Returns the total number of unique domains in the merged result.
 func mergeFiles(outputFile string, inputFiles []string) (int, error) {
 	var merged ExtendedGroupedData
 	seen := make(map[string]bool)
 
-	// Helper to add domains from a source to the merged result
-	mergeSource := func(source ExtendedGroupedData) {
+	// Helpers to add domains from a source to the merged result
+	mergeChecked := func(source ExtendedGroupedData) {
 		for _, d := range source.Available {
 			domain := normalizeDomain(d.Domain)
 			if domain == "" {
@@ -358,7 +475,8 @@ func mergeFiles(outputFile string, inputFiles []string) (int, error) {
 			}
 			if !seen[domain] {
 				seen[domain] = true
-				merged.Available = append(merged.Available, GroupedDomain{Domain: domain, Reason: d.Reason, Log: d.Log})
+				d.Domain = domain
+				merged.Available = append(merged.Available, d)
 			}
 		}
 		for _, d := range source.Unavailable {
@@ -368,9 +486,12 @@ func mergeFiles(outputFile string, inputFiles []string) (int, error) {
 			}
 			if !seen[domain] {
 				seen[domain] = true
-				merged.Unavailable = append(merged.Unavailable, GroupedDomain{Domain: domain, Reason: d.Reason, Log: d.Log})
+				d.Domain = domain
+				merged.Unavailable = append(merged.Unavailable, d)
 			}
 		}
+	}
+	mergeUnverified := func(source ExtendedGroupedData) {
 		for _, d := range source.Unverified {
 			domain := normalizeDomain(d.Domain)
 			if domain == "" {
@@ -378,22 +499,33 @@ func mergeFiles(outputFile string, inputFiles []string) (int, error) {
 			}
 			if !seen[domain] {
 				seen[domain] = true
-				merged.Unverified = append(merged.Unverified, DomainRecord{Domain: domain})
+				d.Domain = domain
+				merged.Unverified = append(merged.Unverified, d)
 			}
 		}
 	}
 
-	// Read and merge all input files
+	// Read all input files
+	sources := make([]ExtendedGroupedData, 0, len(inputFiles))
 	for _, inputFile := range inputFiles {
 		raw, err := os.ReadFile(inputFile)
 		if err != nil {
-			return 0, fmt.Errorf("reading %s: %w", inputFile, err)
+			return 0, &mergeError{errCodeInputRead, inputFile, fmt.Errorf("reading %s: %w", inputFile, err)}
 		}
 		var source ExtendedGroupedData
 		if err := json.Unmarshal(raw, &source); err != nil {
-			return 0, fmt.Errorf("parsing %s: %w", inputFile, err)
+			return 0, &mergeError{errCodeInputParse, inputFile, fmt.Errorf("parsing %s: %w", inputFile, err)}
+		}
+		if err := applyPendingLog(inputFile, &source); err != nil {
+			return 0, &mergeError{errCodeInputRead, inputFile, fmt.Errorf("reading %s: %w", inputFile, err)}
 		}
-		mergeSource(source)
+		sources = append(sources, source)
+	}
+	for _, source := range sources {
+		mergeChecked(source)
+	}
+	for _, source := range sources {
+		mergeUnverified(source)
 	}
 
 	totalDomains := len(merged.Available) + len(merged.Unavailable) + len(merged.Unverified)
@@ -402,7 +534,16 @@ func mergeFiles(outputFile string, inputFiles []string) (int, error) {
 	if err != nil {
 		return totalDomains, err
 	}
-	return totalDomains, os.WriteFile(outputFile, out, 0644)
+	err = auditWrite("merge", "", outputFile, func() error {
+		if err := writeFileAtomic(outputFile, out, 0644); err != nil {
+			return err
+		}
+		return clearPendingLog(outputFile)
+	})
+	if err != nil {
+		return totalDomains, &mergeError{errCodeOutputWrite, outputFile, fmt.Errorf("writing %s: %w", outputFile, err)}
+	}
+	return totalDomains, nil
 }
 
 // exportAvailableDomains reads an input file and exports all available domains
@@ -417,6 +558,9 @@ func exportAvailableDomains(inputFile, outputFile string) (int, error) {
 	if err := json.Unmarshal(raw, &data); err != nil {
 		return 0, fmt.Errorf("parsing %s: %w", inputFile, err)
 	}
+	if err := applyPendingLog(inputFile, &data); err != nil {
+		return 0, fmt.Errorf("reading %s: %w", inputFile, err)
+	}
 
 	var lines []string
 	for _, d := range data.Available {
@@ -428,7 +572,7 @@ func exportAvailableDomains(inputFile, outputFile string) (int, error) {
 		content += "\n"
 	}
 
-	if err := os.WriteFile(outputFile, []byte(content), 0644); err != nil {
+	if err := auditWrite("export", "", outputFile, func() error { return os.WriteFile(outputFile, []byte(content), 0644) }); err != nil {
 		return 0, fmt.Errorf("writing %s: %w", outputFile, err)
 	}
 
@@ -447,6 +591,7 @@ func readExistingDomains(path string) []string {
 	if err := json.Unmarshal(raw, &data); err != nil {
 		return nil
 	}
+	_ = applyPendingLog(path, &data)
 
 	var domains []string
 	for _, d := range data.Available {
@@ -347,3 +347,46 @@ func TestNormalizeDomain(t *testing.T) {
 		})
 	}
 }
+
+// TestRunCLISuggestMetadata asks for a rationale and keywords per domain and
+// keeps them on the record through verification.
+func TestRunCLISuggestMetadata(t *testing.T) {
+	var request string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		request = string(raw)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"tool_calls":[{"function":{"name":"suggest_domains","arguments":"{\"unverified\":[{\"domain\":\"brewly.com\",\"rationale\":\"Short and coffee-themed.\",\"keywords\":[\"coffee\",\"brew\"]}]}"}}]}}]}`)
+	}))
+	defer srv.Close()
+	testHTTPClient = fakeHTTPClient{srv}
+	testBaseURL = srv.URL
+	t.Cleanup(func() {
+		testHTTPClient = nil
+		testBaseURL = ""
+	})
+	t.Setenv("OPENAI_API_KEY", "key")
+
+	path := filepath.Join(t.TempDir(), "sugg.json")
+	whois := startWhoisServer(t, "No match for domain\n")
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=1", "--prompt=coffee brands", "--whois=" + whois, path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(request, `"rationale"`) || !strings.Contains(request, `"keywords"`) {
+		t.Errorf("schema lacks metadata fields: %s", request)
+	}
+
+	raw, _ := os.ReadFile(path)
+	var out ExtendedGroupedData
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Available) != 1 || out.Available[0].Rationale != "Short and coffee-themed." || strings.Join(out.Available[0].Keywords, ",") != "coffee,brew" {
+		t.Errorf("available = %+v", out.Available)
+	}
+	if out.Available[0].Extra != nil {
+		t.Errorf("metadata kept as extra fields: %v", out.Available[0].Extra)
+	}
+}
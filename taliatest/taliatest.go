@@ -0,0 +1,186 @@
+// Package taliatest provides a fake WHOIS server for tests of code that talks
+// WHOIS, such as Talia itself. It serves canned responses per domain over
+// real TCP and can inject latency and connection failures, much as
+// net/http/httptest does for HTTP.
+//
+//	srv := taliatest.NewServer()
+//	defer srv.Close()
+//	srv.Handle("taken.com", taliatest.Response{Body: taliatest.Registered("taken.com")})
+//	// query srv.Addr; every other domain gets taliatest.Available
+package taliatest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault is a connection failure the server injects instead of replying.
+type Fault int
+
+const (
+	// NoFault sends the response normally.
+	NoFault Fault = iota
+	// Drop closes the connection without a reply, so the client reads an
+	// empty response.
+	Drop
+	// Reset aborts the connection with a TCP reset after reading the query.
+	Reset
+)
+
+// Response is what the server does for one query.
+type Response struct {
+	Body  string        // sent verbatim
+	Delay time.Duration // waited before replying or failing
+	Fault Fault
+}
+
+// Available returns a typical "no match" response for domain, which Talia
+// classifies as available.
+func Available(domain string) string {
+	return fmt.Sprintf("No match for %q.\n", strings.ToUpper(domain))
+}
+
+// Registered returns a typical thin-registry response for a registered
+// domain, with a registrar, creation date, and an EPP status.
+func Registered(domain string) string {
+	return fmt.Sprintf("   Domain Name: %s\n   Registrar: Example Registrar, Inc.\n   Creation Date: 2001-02-03T04:05:06Z\n   Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited\n",
+		strings.ToUpper(domain))
+}
+
+// Server is a fake WHOIS server listening on a loopback port. Its methods
+// are safe for concurrent use.
+type Server struct {
+	// Addr is the host:port to query, e.g. "127.0.0.1:54321".
+	Addr string
+
+	ln        net.Listener
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	mu        sync.Mutex
+	responses map[string]Response
+	fallback  func(domain string) Response
+	queries   []string
+}
+
+// NewServer starts a server that answers every domain with Available until
+// Handle or HandleFunc say otherwise. Call Close when done.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("taliatest: failed to listen: %v", err))
+	}
+	s := &Server{
+		Addr:      ln.Addr().String(),
+		ln:        ln,
+		done:      make(chan struct{}),
+		responses: make(map[string]Response),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Handle sets the response for domain (case-insensitive).
+func (s *Server) Handle(domain string, r Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[strings.ToLower(domain)] = r
+}
+
+// HandleFunc sets the response for domains without a Handle entry. f gets
+// the lowercased domain.
+func (s *Server) HandleFunc(f func(domain string) Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fallback = f
+}
+
+// Queries returns the domains queried so far, lowercased, in arrival order.
+func (s *Server) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+// Close stops the server, closes open connections (cutting pending delays
+// short), and waits for their handlers to return.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.ln.Close()
+	})
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.answer(c)
+		}()
+	}
+}
+
+// answer reads one query line and replies as configured.
+func (s *Server) answer(c net.Conn) {
+	defer func() { _ = c.Close() }()
+	answered := make(chan struct{})
+	defer close(answered)
+	go func() {
+		select {
+		case <-s.done:
+			_ = c.Close()
+		case <-answered:
+		}
+	}()
+
+	line, err := bufio.NewReader(c).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return
+	}
+	domain := strings.ToLower(strings.TrimSpace(line))
+
+	s.mu.Lock()
+	s.queries = append(s.queries, domain)
+	r, ok := s.responses[domain]
+	fallback := s.fallback
+	s.mu.Unlock()
+	switch {
+	case ok:
+	case fallback != nil:
+		r = fallback(domain)
+	default:
+		r = Response{Body: Available(domain)}
+	}
+
+	if r.Delay > 0 {
+		select {
+		case <-time.After(r.Delay):
+		case <-s.done:
+			return
+		}
+	}
+	switch r.Fault {
+	case Drop:
+		return
+	case Reset:
+		if tcp, ok := c.(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0)
+		}
+		return
+	}
+	_, _ = io.WriteString(c, r.Body)
+}
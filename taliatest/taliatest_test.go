@@ -0,0 +1,98 @@
+package taliatest
+
+import (
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// query sends domain to addr the way a WHOIS client does and returns the
+// reply.
+func query(t *testing.T, addr, domain string) (string, error) {
+	t.Helper()
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+	_, _ = io.WriteString(c, domain+"\r\n")
+	_ = c.(*net.TCPConn).CloseWrite()
+	b, err := io.ReadAll(c)
+	return string(b), err
+}
+
+// TestServerResponses covers the default, per-domain, and fallback answers
+// and the query log.
+func TestServerResponses(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	if got, _ := query(t, srv.Addr, "free.com"); got != Available("free.com") {
+		t.Errorf("default = %q", got)
+	}
+	srv.Handle("Taken.com", Response{Body: Registered("taken.com")})
+	if got, _ := query(t, srv.Addr, "TAKEN.COM"); !strings.Contains(got, "Domain Name: TAKEN.COM") {
+		t.Errorf("handled = %q", got)
+	}
+	srv.HandleFunc(func(domain string) Response { return Response{Body: "fallback " + domain} })
+	if got, _ := query(t, srv.Addr, "other.io"); got != "fallback other.io" {
+		t.Errorf("fallback = %q", got)
+	}
+	if got := srv.Queries(); !slices.Equal(got, []string{"free.com", "taken.com", "other.io"}) {
+		t.Errorf("queries = %q", got)
+	}
+}
+
+// TestServerFaults covers latency and injected failures.
+func TestServerFaults(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Handle("slow.com", Response{Body: "ok", Delay: 50 * time.Millisecond})
+	srv.Handle("drop.com", Response{Fault: Drop})
+	srv.Handle("reset.com", Response{Fault: Reset})
+
+	start := time.Now()
+	if got, _ := query(t, srv.Addr, "slow.com"); got != "ok" || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("slow = %q after %v", got, time.Since(start))
+	}
+	if got, err := query(t, srv.Addr, "drop.com"); got != "" || err != nil {
+		t.Errorf("drop = %q, %v", got, err)
+	}
+	if _, err := query(t, srv.Addr, "reset.com"); !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("reset err = %v", err)
+	}
+}
+
+// TestServerCloseCutsDelay returns from Close without waiting out delays.
+func TestServerCloseCutsDelay(t *testing.T) {
+	srv := NewServer()
+	srv.Handle("slow.com", Response{Delay: time.Hour})
+	go func() { _, _ = query(t, srv.Addr, "slow.com") }()
+	for len(srv.Queries()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	srv.Close()
+	srv.Close() // idempotent
+	if time.Since(start) > time.Second {
+		t.Errorf("Close took %v", time.Since(start))
+	}
+}
+
+// TestServerCloseSilentClient returns from Close while a client has
+// connected but not sent a query.
+func TestServerCloseSilentClient(t *testing.T) {
+	srv := NewServer()
+	c, err := net.Dial("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+	time.Sleep(10 * time.Millisecond)
+	srv.Close()
+}
@@ -0,0 +1,83 @@
+package talia
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkLimits bounds the WHOIS phase of a run. The zero value sets no
+// limits.
+type checkLimits struct {
+	// perDomain bounds each lookup, connecting included (--per-domain-timeout).
+	perDomain time.Duration
+
+	// deadline, when set, stops dispatching new checks once passed
+	// (--run-timeout). Checks already running finish; the domains not yet
+	// started are left unchecked.
+	deadline time.Time
+
+	// goal, when set, stops dispatching new checks once enough available
+	// domains are found (--stop-on-available), the same way deadline does.
+	goal *availableGoal
+}
+
+// newCheckLimits returns the limits for --per-domain-timeout and
+// --run-timeout, with the run's deadline counted from start.
+func newCheckLimits(perDomain, run time.Duration, start time.Time) (checkLimits, error) {
+	if perDomain < 0 || run < 0 {
+		return checkLimits{}, fmt.Errorf("--per-domain-timeout and --run-timeout must not be negative")
+	}
+	l := checkLimits{perDomain: perDomain}
+	if run > 0 {
+		l.deadline = start.Add(run)
+	}
+	return l, nil
+}
+
+// expired reports whether l's deadline has passed at now.
+func (l checkLimits) expired(now time.Time) bool {
+	return !l.deadline.IsZero() && !now.Before(l.deadline)
+}
+
+// stopped reports whether a run bounded by l should start no more checks at
+// now: its deadline has passed or its goal has been reached.
+func (l checkLimits) stopped(now time.Time) bool {
+	return l.expired(now) || l.goal.reached()
+}
+
+// uncheckedResult stands for a domain the run left unchecked because
+// --run-timeout expired or --stop-on-available was satisfied first. It has no verdict, so the run writes the
+// input record back as it was (see checkResult.checked).
+func uncheckedResult(domain string) checkResult {
+	return checkResult{Domain: domain}
+}
+
+// checked reports whether res holds a verdict, as opposed to a domain left
+// unchecked by --run-timeout or --stop-on-available.
+func (res checkResult) checked() bool {
+	return res.Reason != ""
+}
+
+// checkedResults returns the results that hold a verdict.
+func checkedResults(results []checkResult) []checkResult {
+	kept := results[:0:0]
+	for _, res := range results {
+		if res.checked() {
+			kept = append(kept, res)
+		}
+	}
+	return kept
+}
+
+// reportUnchecked prints how many domains --run-timeout or
+// --stop-on-available left unchecked.
+func reportUnchecked(cfg runConfig, results []checkResult) {
+	n := len(results) - len(checkedResults(results))
+	switch {
+	case n == 0:
+	case cfg.limits.goal.reached():
+		fmt.Fprintf(cfg.status(), "Stop on available: found %d available domains; %d domains left unchecked for the next run\n", cfg.limits.goal.found.Load(), n)
+	default:
+		fmt.Fprintf(cfg.status(), "Run timeout: stopped dispatching; %d domains left unchecked for the next run\n", n)
+	}
+}
@@ -0,0 +1,121 @@
+package talia
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sustanza/talia/taliatest"
+)
+
+// TestNetWhoisClientTimeout gives up on a server that accepts the
+// connection but never answers.
+func TestNetWhoisClientTimeout(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("slow.com", taliatest.Response{Delay: time.Hour})
+
+	start := time.Now()
+	_, err := NetWhoisClient{Server: srv.Addr, Timeout: 50 * time.Millisecond}.Lookup("slow.com")
+	if err == nil || !strings.Contains(err.Error(), "no WHOIS response within 50ms") {
+		t.Fatalf("err = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("lookup took %v", elapsed)
+	}
+}
+
+func TestNewCheckLimits(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	l, err := newCheckLimits(time.Second, time.Hour, start)
+	if err != nil || l.perDomain != time.Second || !l.deadline.Equal(start.Add(time.Hour)) {
+		t.Fatalf("limits = %+v, %v", l, err)
+	}
+	if l.expired(start.Add(time.Minute)) || !l.expired(start.Add(time.Hour)) {
+		t.Error("expired is wrong around the deadline")
+	}
+	if (checkLimits{}).expired(start) {
+		t.Error("no deadline expired")
+	}
+	if _, err := newCheckLimits(-time.Second, 0, start); err == nil {
+		t.Error("negative timeout accepted")
+	}
+}
+
+// TestCheckDomainsSequentialRunTimeout stops before a pause that would end
+// past the deadline and leaves the rest unchecked.
+func TestCheckDomainsSequentialRunTimeout(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clk := &fakeClock{now: start}
+	cfg := runConfig{
+		whoisServer: startWhoisServer(t, "No match for domain\n"),
+		sleep:       time.Minute,
+		clock:       clk,
+		limits:      checkLimits{deadline: start.Add(90 * time.Second)},
+	}
+	var results []checkResult
+	stdout, _ := captureOutput(t, func() {
+		results = checkDomains([]string{"a.com", "b.com", "c.com", "d.com"}, cfg)
+	})
+	var checked []string
+	for _, res := range checkedResults(results) {
+		checked = append(checked, res.Domain)
+	}
+	if !slices.Equal(checked, []string{"a.com", "b.com"}) || len(results) != 4 {
+		t.Errorf("checked %v of %d results", checked, len(results))
+	}
+	if !slices.Equal(clk.sleeps, []time.Duration{time.Minute}) {
+		t.Errorf("sleeps = %v", clk.sleeps)
+	}
+	if !strings.Contains(stdout, "2 domains left unchecked") {
+		t.Errorf("stdout = %q", stdout)
+	}
+}
+
+// TestCheckDomainsParallelRunTimeout dispatches nothing past the deadline.
+func TestCheckDomainsParallelRunTimeout(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	limits := checkLimits{deadline: clk.now}
+	var results []checkResult
+	captureOutput(t, func() {
+		results = checkParallel(runConfig{whoisServer: "127.0.0.1:1", workers: 2, clock: clk, limits: limits}, []string{"a.com", "b.com"}, nil)
+	})
+	if len(results) != 2 || len(checkedResults(results)) != 0 || results[1].Domain != "b.com" {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+// TestRunGroupedInputRunTimeout writes the checked domains and keeps the
+// unchecked ones in unverified with their fields.
+func TestRunGroupedInputRunTimeout(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	clk := &fakeClock{now: start}
+	path := writeGroupedFixture(t, GroupedData{
+		Unverified: []DomainRecord{{Domain: "a.com"}, {Domain: "b.com", Favorite: true}},
+	})
+	ext, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := runConfig{
+		whoisServer: startWhoisServer(t, "No match for domain\n"),
+		sleep:       time.Hour,
+		clock:       clk,
+		limits:      checkLimits{deadline: start.Add(time.Minute)},
+	}
+	captureOutput(t, func() {
+		if code := runGroupedInput(cfg, path, ExtendedGroupedData(ext)); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	data, err := readGroupedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Available) != 1 || data.Available[0].Domain != "a.com" {
+		t.Errorf("available = %+v", data.Available)
+	}
+	if len(data.Unverified) != 1 || data.Unverified[0].Domain != "b.com" || !data.Unverified[0].Favorite || data.Unverified[0].RunID != "" {
+		t.Errorf("unverified = %+v", data.Unverified)
+	}
+}
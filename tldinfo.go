@@ -0,0 +1,211 @@
+package talia
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sources for "talia tld-info".
+const (
+	defaultIANAWhois     = "whois.iana.org:43"
+	defaultRDAPBootstrap = "https://data.iana.org/rdap/dns.json"
+)
+
+// tldInfoTTL is how long a cached tld-info answer is used before IANA is
+// asked again. Registry delegations change rarely.
+const tldInfoTTL = 30 * 24 * time.Hour
+
+// tldInfo is what IANA publishes about a TLD that matters for checking it.
+type tldInfo struct {
+	TLD         string    `json:"tld"`
+	WhoisServer string    `json:"whoisServer,omitempty"` // host:port, ready for --whois
+	RDAPBase    string    `json:"rdapBase,omitempty"`
+	Registry    string    `json:"registry,omitempty"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
+
+// parseIANATLD reads the WHOIS server and registry organisation from an IANA
+// TLD record. The first "organisation" line is the sponsoring registry; later
+// ones belong to the contacts.
+func parseIANATLD(resp string) (whoisServer, registry string) {
+	for key, value := range whoisFields(resp) {
+		switch {
+		case key == "whois" && whoisServer == "":
+			whoisServer = value
+		case key == "organisation" && registry == "":
+			registry = value
+		}
+	}
+	if whoisServer != "" && !strings.Contains(whoisServer, ":") {
+		whoisServer += ":43"
+	}
+	return whoisServer, registry
+}
+
+// rdapBootstrap is the IANA RDAP bootstrap file for DNS (RFC 9224). Each
+// service is a pair of [TLDs, base URLs].
+type rdapBootstrap struct {
+	Services [][][]string `json:"services"`
+}
+
+// fetchRDAPBootstrap downloads the RDAP bootstrap file at url.
+func fetchRDAPBootstrap(client httpDoer, url string) (rdapBootstrap, error) {
+	var boot rdapBootstrap
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return boot, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return boot, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return boot, fmt.Errorf("RDAP bootstrap: unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&boot); err != nil {
+		return boot, fmt.Errorf("RDAP bootstrap: %w", err)
+	}
+	return boot, nil
+}
+
+// base returns the first RDAP base URL listed for tld, or "" if the TLD has
+// none.
+func (b rdapBootstrap) base(tld string) string {
+	for _, svc := range b.Services {
+		if len(svc) < 2 || len(svc[1]) == 0 {
+			continue
+		}
+		for _, t := range svc[0] {
+			if strings.EqualFold(t, tld) {
+				return svc[1][0]
+			}
+		}
+	}
+	return ""
+}
+
+// readTLDInfoCache returns the cached answer for tld if it is younger than
+// tldInfoTTL.
+func readTLDInfoCache(path string, now time.Time) (tldInfo, bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return tldInfo{}, false
+	}
+	var info tldInfo
+	if json.Unmarshal(raw, &info) != nil || now.Sub(info.FetchedAt) > tldInfoTTL {
+		return tldInfo{}, false
+	}
+	return info, true
+}
+
+// writeTLDInfoCache stores info at path, creating the directory if needed.
+func writeTLDInfoCache(path string, info tldInfo) error {
+	out, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out, 0644)
+}
+
+// fetchTLDInfo asks IANA's WHOIS server about tld and looks up its RDAP base
+// URL. A failed RDAP lookup is reported as a warning; the WHOIS data is still
+// returned.
+func fetchTLDInfo(tld, ianaServer, bootstrapURL string) (tldInfo, error) {
+	resp, err := NetWhoisClient{Server: ianaServer}.Lookup(tld)
+	if err != nil {
+		return tldInfo{}, err
+	}
+	info := tldInfo{TLD: tld, FetchedAt: time.Now().UTC()}
+	info.WhoisServer, info.Registry = parseIANATLD(resp)
+	if info.WhoisServer == "" && info.Registry == "" {
+		return tldInfo{}, fmt.Errorf("IANA has no record for TLD %q", tld)
+	}
+	if bootstrapURL != "" {
+		boot, err := fetchRDAPBootstrap(http.DefaultClient, bootstrapURL)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: RDAP lookup failed:", err)
+		}
+		info.RDAPBase = boot.base(tld)
+	}
+	return info, nil
+}
+
+// runTLDInfoCommand implements "talia tld-info <tld>": it prints the WHOIS
+// server, RDAP base URL, and registry of a TLD as published by IANA. Answers
+// are cached under the cache directory for tldInfoTTL.
+func runTLDInfoCommand(args []string) int {
+	fs := flag.NewFlagSet("talia tld-info", flag.ContinueOnError)
+	server := fs.String("server", defaultIANAWhois, "IANA WHOIS server")
+	bootstrap := fs.String("rdap-bootstrap", defaultRDAPBootstrap, "RDAP bootstrap file URL ('' to skip the RDAP lookup)")
+	refresh := fs.Bool("refresh", false, "Ignore the cache and ask IANA again")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: talia tld-info [--refresh] [--json] <tld>")
+		return 1
+	}
+	tld := strings.ToLower(strings.Trim(strings.TrimSpace(args[0]), "."))
+	if tld == "" || strings.ContainsAny(tld, "./\\") {
+		fmt.Fprintf(os.Stderr, "Error: invalid TLD %q\n", args[0])
+		return 1
+	}
+
+	var cachePath string
+	if dir, err := cacheDir(); err == nil {
+		cachePath = filepath.Join(dir, "tld", tld+".json")
+	}
+	info, cached := tldInfo{}, false
+	if cachePath != "" && !*refresh {
+		info, cached = readTLDInfoCache(cachePath, time.Now())
+	}
+	if !cached {
+		info, err = fetchTLDInfo(tld, *server, *bootstrap)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return 1
+		}
+		if cachePath != "" {
+			if err := writeTLDInfoCache(cachePath, info); err != nil {
+				fmt.Fprintln(os.Stderr, "Warning: could not cache result:", err)
+			}
+		}
+	}
+
+	if cached {
+		fmt.Fprintf(os.Stderr, "(cached %s; use --refresh to update)\n", info.FetchedAt.Format(time.DateOnly))
+	}
+	if *asJSON {
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(out))
+		return 0
+	}
+	orNone := func(s string) string {
+		if s == "" {
+			return "(none)"
+		}
+		return s
+	}
+	fmt.Printf("TLD:          .%s\n", info.TLD)
+	fmt.Printf("WHOIS server: %s\n", orNone(info.WhoisServer))
+	fmt.Printf("RDAP base:    %s\n", orNone(info.RDAPBase))
+	fmt.Printf("Registry:     %s\n", orNone(info.Registry))
+	return 0
+}
@@ -0,0 +1,80 @@
+package talia
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const ianaIOResponse = `% IANA WHOIS server
+
+domain:       IO
+
+organisation: Internet Computer Bureau Limited
+address:      c/o Sure, Diego Garcia
+
+contact:      administrative
+organisation: Someone Else
+
+whois:        whois.nic.io
+
+status:       ACTIVE
+`
+
+// TestParseIANATLD takes the registry from the first organisation line and
+// adds the WHOIS port.
+func TestParseIANATLD(t *testing.T) {
+	t.Parallel()
+	server, registry := parseIANATLD(ianaIOResponse)
+	if server != "whois.nic.io:43" || registry != "Internet Computer Bureau Limited" {
+		t.Errorf("got %q, %q", server, registry)
+	}
+}
+
+// TestRunTLDInfoCommand fetches from IANA and the RDAP bootstrap, then serves
+// the second call from the cache.
+func TestRunTLDInfoCommand(t *testing.T) {
+	t.Setenv("TALIA_CACHE_DIR", t.TempDir())
+	addr := startWhoisServer(t, ianaIOResponse)
+	boot := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"services":[[["com","net"],["https://rdap.verisign.com/com/v1/"]],[["io","sh"],["https://rdap.nic.io/"]]]}`)
+	}))
+	defer boot.Close()
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"tld-info", "--server=" + addr, "--rdap-bootstrap=" + boot.URL, ".IO"}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	for _, want := range []string{"WHOIS server: whois.nic.io:43", "RDAP base:    https://rdap.nic.io/", "Registry:     Internet Computer Bureau Limited"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("missing %q in %q", want, stdout)
+		}
+	}
+
+	// The IANA server is unreachable now; the cached answer is used.
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"tld-info", "--server=127.0.0.1:1", "--json", "io"}); code != 0 {
+			t.Errorf("cached exit %d", code)
+		}
+	})
+	var info tldInfo
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		t.Fatalf("unmarshal %q: %v", stdout, err)
+	}
+	if info.WhoisServer != "whois.nic.io:43" || info.RDAPBase != "https://rdap.nic.io/" {
+		t.Errorf("cached info = %+v", info)
+	}
+	if !strings.Contains(stderr, "cached") {
+		t.Errorf("stderr = %q", stderr)
+	}
+
+	_, _ = captureOutput(t, func() {
+		if code := RunCLI([]string{"tld-info", "--server=127.0.0.1:1", "--refresh", "io"}); code != 1 {
+			t.Errorf("--refresh with unreachable server: exit %d, want 1", code)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package talia
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// suggestRequest asks the model for count suggestions, avoiding the domains
+// in exclude.
+type suggestRequest func(count int, exclude []string) ([]DomainRecord, error)
+
+// newSuggestions returns the normalized domains in list that are valid and
+// not in seen, marking them seen: the suggestions that will actually be
+// added to the file.
+func newSuggestions(list []DomainRecord, seen map[string]bool) []string {
+	var added []string
+	for _, rec := range list {
+		if d := normalizeDomain(rec.Domain); d != "" && !seen[d] {
+			seen[d] = true
+			added = append(added, d)
+		}
+	}
+	return added
+}
+
+// topUpSuggestions issues follow-up requests while fewer than want valid,
+// new suggestions have come back, up to budget requests. known holds the
+// domains already in the target file, results what the first round
+// returned, and exclude the domains the model was told to avoid; each
+// follow-up also excludes the suggestions collected so far. It returns the
+// extra suggestions and the number of valid, new ones in total.
+func topUpSuggestions(status io.Writer, want, budget int, known []string, results []DomainRecord, exclude []string, request suggestRequest) ([]DomainRecord, int) {
+	seen := make(map[string]bool, len(known))
+	for _, d := range known {
+		seen[normalizeDomain(d)] = true
+	}
+	collected := newSuggestions(results, seen)
+
+	var extra []DomainRecord
+	for i := 1; len(collected) < want && i <= budget; i++ {
+		short := want - len(collected)
+		fmt.Fprintf(status, "  Got %d of %d valid new suggestions, requesting %d more (top-up %d/%d)\n", len(collected), want, short, i, budget)
+		list, err := request(short, append(slices.Clone(exclude), collected...))
+		if err != nil {
+			fmt.Fprintf(status, "  Top-up request %d failed: %v\n", i, err)
+			continue
+		}
+		extra = append(extra, list...)
+		collected = append(collected, newSuggestions(list, seen)...)
+	}
+	return extra, len(collected)
+}
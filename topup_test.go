@@ -0,0 +1,118 @@
+package talia
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestTopUpSuggestions asks for the shortfall, excluding what it already
+// has, until the count is met.
+func TestTopUpSuggestions(t *testing.T) {
+	t.Parallel()
+	var asked []int
+	var excluded [][]string
+	request := func(n int, exclude []string) ([]DomainRecord, error) {
+		asked = append(asked, n)
+		excluded = append(excluded, exclude)
+		return []DomainRecord{{Domain: "new1.com"}, {Domain: "new2.com"}, {Domain: "old.com"}}, nil
+	}
+	// One valid new name, one invalid, one already in the file.
+	first := []DomainRecord{{Domain: "a.com"}, {Domain: "bad name"}, {Domain: "old.com"}}
+	var status bytes.Buffer
+	extra, got := topUpSuggestions(&status, 3, 2, []string{"old.com"}, first, []string{"old.com"}, request)
+	if got != 3 || len(extra) != 3 {
+		t.Fatalf("got %d new, %d extra records", got, len(extra))
+	}
+	if !slices.Equal(asked, []int{2}) {
+		t.Errorf("asked for %v, want [2]", asked)
+	}
+	if !slices.Equal(excluded[0], []string{"old.com", "a.com"}) {
+		t.Errorf("exclude = %v", excluded[0])
+	}
+	if !strings.Contains(status.String(), "Got 1 of 3 valid new suggestions, requesting 2 more (top-up 1/2)") {
+		t.Errorf("status = %q", status.String())
+	}
+}
+
+// TestTopUpSuggestionsBudget stops after budget requests, failed ones
+// included, and reports the count it reached.
+func TestTopUpSuggestionsBudget(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	request := func(n int, exclude []string) ([]DomainRecord, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("boom")
+		}
+		return []DomainRecord{{Domain: "b.com"}}, nil
+	}
+	_, got := topUpSuggestions(io.Discard, 5, 2, nil, []DomainRecord{{Domain: "a.com"}}, nil, request)
+	if calls != 2 || got != 2 {
+		t.Errorf("calls = %d, got = %d; want 2, 2", calls, got)
+	}
+
+	calls = 0
+	if _, got := topUpSuggestions(io.Discard, 1, 3, nil, []DomainRecord{{Domain: "a.com"}}, nil, request); calls != 0 || got != 1 {
+		t.Errorf("count met: calls = %d, got = %d", calls, got)
+	}
+}
+
+// TestRunCLISuggestTopUp fills a short response with a follow-up request
+// and warns when the retry budget runs out first.
+func TestRunCLISuggestTopUp(t *testing.T) {
+	responses := []string{
+		`{\"unverified\":[{\"domain\":\"one.com\"},{\"domain\":\"not valid\"}]}`,
+		`{\"unverified\":[{\"domain\":\"two.com\"}]}`,
+	}
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(raw))
+		args := responses[min(len(requests), len(responses))-1]
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"tool_calls":[{"function":{"name":"suggest_domains","arguments":"`+args+`"}}]}}]}`)
+	}))
+	defer srv.Close()
+	testHTTPClient = fakeHTTPClient{srv}
+	testBaseURL = srv.URL
+	t.Cleanup(func() {
+		testHTTPClient = nil
+		testBaseURL = ""
+	})
+	t.Setenv("OPENAI_API_KEY", "key")
+
+	path := filepath.Join(t.TempDir(), "sugg.json")
+	captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=2", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if len(requests) != 2 || !strings.Contains(requests[1], "Return 1 unique") || !strings.Contains(requests[1], "one.com") {
+		t.Fatalf("requests = %q", requests)
+	}
+	raw, _ := os.ReadFile(path)
+	if !strings.Contains(string(raw), "one.com") || !strings.Contains(string(raw), "two.com") {
+		t.Errorf("file = %s", raw)
+	}
+
+	// Every follow-up now repeats two.com, so the budget runs out.
+	requests = nil
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"--suggest=2", "--suggest-retries=1", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if len(requests) != 2 {
+		t.Errorf("made %d requests, want 2", len(requests))
+	}
+	if !strings.Contains(stderr, "only 0 of 2 requested suggestions were valid and new") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}
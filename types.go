@@ -1,5 +1,10 @@
 package talia
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // AvailabilityReason is a short code explaining domain availability.
 type AvailabilityReason string
 
@@ -7,30 +12,292 @@ const (
 	ReasonNoMatch AvailabilityReason = "NO_MATCH"
 	ReasonTaken   AvailabilityReason = "TAKEN"
 	ReasonError   AvailabilityReason = "ERROR"
+
+	// ReasonGivenUp marks a domain whose check kept failing until it reached
+	// --max-attempts; it is no longer retried.
+	ReasonGivenUp AvailabilityReason = "GIVEN_UP"
+)
+
+// AvailabilityStatus is the three-way outcome of a check. Unlike the
+// "available" bool it distinguishes a failed check from a confirmed
+// registration.
+type AvailabilityStatus string
+
+const (
+	StatusAvailable AvailabilityStatus = "available"
+	StatusTaken     AvailabilityStatus = "taken"
+	StatusUnknown   AvailabilityStatus = "unknown"
 )
 
+// statusFor derives the status for a check outcome. An empty reason means the
+// domain was never checked, which yields an empty status.
+func statusFor(available bool, reason AvailabilityReason) AvailabilityStatus {
+	switch {
+	case reason == ReasonError || reason == ReasonGivenUp:
+		return StatusUnknown
+	case available || reason == ReasonNoMatch:
+		return StatusAvailable
+	case reason == ReasonTaken:
+		return StatusTaken
+	default:
+		return ""
+	}
+}
+
 // DomainRecord is how we parse the input array in non-grouped mode.
-// "available" and "reason" are overwritten by Talia in non-grouped mode.
+// "available", "status" and "reason" are overwritten by Talia in non-grouped
+// mode. "available" is kept for compatibility; "status" is the field to read,
+// since an errored check is "available": false but "status": "unknown".
+// Any other JSON fields (tags, price, owner, ...) are kept in Extra and
+// written back unchanged.
 type DomainRecord struct {
 	Domain    string             `json:"domain"`
 	Available bool               `json:"available,omitempty"`
+	Status    AvailabilityStatus `json:"status,omitempty"`
 	Reason    AvailabilityReason `json:"reason,omitempty"`
 	Log       string             `json:"log,omitempty"`
+
+	// LogFile names the file holding the log instead, when --log-budget
+	// moved it out of the record.
+	LogFile string `json:"logFile,omitempty"`
+
+	// ResponseHash identifies the WHOIS response, normalized so that only
+	// real changes alter it (see responseHash).
+	ResponseHash string `json:"responseHash,omitempty"`
+
+	// PrivacyProtected is set when the WHOIS response withholds contact
+	// data by policy (GDPR redaction, privacy service).
+	PrivacyProtected bool `json:"privacyProtected,omitempty"`
+
+	// Registrar, creation and expiration date of a taken domain, as
+	// reported by WHOIS. The domain's age is derived from CreatedAt when
+	// needed (domainAge).
+	Registrar string    `json:"registrar,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitzero"`
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+
+	// EPPStatus lists the domain's EPP status codes. OnHold (clientHold or
+	// serverHold) and InRedemption (redemptionPeriod, pendingRestore or
+	// pendingDelete) flag taken domains that may soon become registrable.
+	EPPStatus    []string `json:"eppStatus,omitempty"`
+	OnHold       bool     `json:"onHold,omitempty"`
+	InRedemption bool     `json:"inRedemption,omitempty"`
+
+	// NameServers lists the name servers WHOIS reports for a taken domain,
+	// lowercased. Parking services show up here (see parkedNameServers).
+	NameServers []string `json:"nameServers,omitempty"`
+
+	// Parked tags a taken domain that is likely parked or for sale, by its
+	// name servers, registrar, or --probe-parked page.
+	Parked bool `json:"parked,omitempty"`
+
+	// HTTPStatus and HTTPRedirect are the status code and Location of the
+	// first response to --http-probe; HTTPError says why there was none. A
+	// failed or erroring site suggests a dead registration.
+	HTTPStatus   int    `json:"httpStatus,omitempty"`
+	HTTPRedirect string `json:"httpRedirect,omitempty"`
+	HTTPError    string `json:"httpError,omitempty"`
+
+	// Alternatives lists available names close to a taken domain, found
+	// with --alternatives.
+	Alternatives []string `json:"alternatives,omitempty"`
+
+	// EstimatedValue is the domain's appraised value in USD, set with
+	// --valuation for available and dropping domains.
+	EstimatedValue float64 `json:"estimatedValue,omitempty"`
+
+	// FirstYearPrice (in PriceCurrency) and Premium are set with --pricing
+	// for available domains: "available" at 3,000 USD is not the same as
+	// "available" at 12.
+	FirstYearPrice float64 `json:"firstYearPrice,omitempty"`
+	PriceCurrency  string  `json:"priceCurrency,omitempty"`
+	Premium        bool    `json:"premium,omitempty"`
+
+	// OwnedByUs marks a taken domain that is in our own portfolio, by
+	// --portfolio, as opposed to a competitor's registration.
+	OwnedByUs bool `json:"ownedByUs,omitempty"`
+
+	// Confidence (0-1) in the verdict at CheckedAt; see DecayedConfidence.
+	Confidence float64   `json:"confidence,omitempty"`
+	CheckedAt  time.Time `json:"checkedAt,omitzero"`
+
+	// RunID identifies the run that checked the domain, to correlate
+	// results with that run's logs and webhook deliveries.
+	RunID string `json:"runId,omitempty"`
+
+	// Attempts counts the checks made of the domain, across runs.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Rationale and Keywords are the model's reasons for suggesting the
+	// domain (--suggest): a short explanation and the keywords it matched.
+	// Checks keep them as they are.
+	Rationale string   `json:"rationale,omitempty"`
+	Keywords  []string `json:"keywords,omitempty"`
+
+	// Favorite and Rejected are shortlisting marks set by hand in the file.
+	// Checks keep them as they are; --favorites-only and --exclude-rejected
+	// select by them.
+	Favorite bool `json:"favorite,omitempty"`
+	Rejected bool `json:"rejected,omitempty"`
+
+	// Notes is free text set by hand, such as why the domain is on the
+	// list. Checks keep it, and reports show it.
+	Notes string `json:"notes,omitempty"`
+
+	// Priority is set by hand; with --order=priority higher values are
+	// checked first. Checks keep it.
+	Priority int `json:"priority,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // GroupedDomain is a minimal record for grouped output.
 // We now include a Log field as well, so logs can be preserved in grouped mode.
+// Unknown JSON fields are preserved in Extra, as for DomainRecord.
 type GroupedDomain struct {
 	Domain string             `json:"domain"`
+	Status AvailabilityStatus `json:"status,omitempty"`
 	Reason AvailabilityReason `json:"reason"`
 	Log    string             `json:"log,omitempty"`
+
+	LogFile          string    `json:"logFile,omitempty"`
+	ResponseHash     string    `json:"responseHash,omitempty"`
+	PrivacyProtected bool      `json:"privacyProtected,omitempty"`
+	Registrar        string    `json:"registrar,omitempty"`
+	CreatedAt        time.Time `json:"createdAt,omitzero"`
+	ExpiresAt        time.Time `json:"expiresAt,omitzero"`
+	EPPStatus        []string  `json:"eppStatus,omitempty"`
+	OnHold           bool      `json:"onHold,omitempty"`
+	InRedemption     bool      `json:"inRedemption,omitempty"`
+	NameServers      []string  `json:"nameServers,omitempty"`
+	Parked           bool      `json:"parked,omitempty"`
+	HTTPStatus       int       `json:"httpStatus,omitempty"`
+	HTTPRedirect     string    `json:"httpRedirect,omitempty"`
+	HTTPError        string    `json:"httpError,omitempty"`
+	Alternatives     []string  `json:"alternatives,omitempty"`
+	EstimatedValue   float64   `json:"estimatedValue,omitempty"`
+	FirstYearPrice   float64   `json:"firstYearPrice,omitempty"`
+	PriceCurrency    string    `json:"priceCurrency,omitempty"`
+	Premium          bool      `json:"premium,omitempty"`
+	OwnedByUs        bool      `json:"ownedByUs,omitempty"`
+	Confidence       float64   `json:"confidence,omitempty"`
+	CheckedAt        time.Time `json:"checkedAt,omitzero"`
+	RunID            string    `json:"runId,omitempty"`
+	Attempts         int       `json:"attempts,omitempty"`
+	Rationale        string    `json:"rationale,omitempty"`
+	Keywords         []string  `json:"keywords,omitempty"`
+	Favorite         bool      `json:"favorite,omitempty"`
+	Rejected         bool      `json:"rejected,omitempty"`
+	Notes            string    `json:"notes,omitempty"`
+	Priority         int       `json:"priority,omitempty"`
+
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// grouped converts d to a GroupedDomain, keeping its extra fields.
+func (d DomainRecord) grouped() GroupedDomain {
+	return GroupedDomain{
+		Domain:           d.Domain,
+		Status:           d.Status,
+		Reason:           d.Reason,
+		Log:              d.Log,
+		LogFile:          d.LogFile,
+		ResponseHash:     d.ResponseHash,
+		PrivacyProtected: d.PrivacyProtected,
+		Registrar:        d.Registrar,
+		CreatedAt:        d.CreatedAt,
+		ExpiresAt:        d.ExpiresAt,
+		EPPStatus:        d.EPPStatus,
+		OnHold:           d.OnHold,
+		InRedemption:     d.InRedemption,
+		NameServers:      d.NameServers,
+		Parked:           d.Parked,
+		HTTPStatus:       d.HTTPStatus,
+		HTTPRedirect:     d.HTTPRedirect,
+		HTTPError:        d.HTTPError,
+		Alternatives:     d.Alternatives,
+		EstimatedValue:   d.EstimatedValue,
+		FirstYearPrice:   d.FirstYearPrice,
+		PriceCurrency:    d.PriceCurrency,
+		Premium:          d.Premium,
+		OwnedByUs:        d.OwnedByUs,
+		Confidence:       d.Confidence,
+		CheckedAt:        d.CheckedAt,
+		RunID:            d.RunID,
+		Attempts:         d.Attempts,
+		Rationale:        d.Rationale,
+		Keywords:         d.Keywords,
+		Favorite:         d.Favorite,
+		Rejected:         d.Rejected,
+		Notes:            d.Notes,
+		Priority:         d.Priority,
+		Extra:            d.Extra,
+	}
+}
+
+// keepInputFields copies the fields of the input record from that a check
+// doesn't produce, its extra fields, suggestion metadata, curation marks,
+// notes, and priority, onto d, the record made from the check's result.
+func (d *DomainRecord) keepInputFields(from DomainRecord) {
+	d.Extra = from.Extra
+	d.Rationale = from.Rationale
+	d.Keywords = from.Keywords
+	d.Favorite = from.Favorite
+	d.Rejected = from.Rejected
+	d.Notes = from.Notes
+	d.Priority = from.Priority
+}
+
+// record converts g to a DomainRecord, keeping its extra fields.
+func (g GroupedDomain) record() DomainRecord {
+	return DomainRecord{
+		Domain:           g.Domain,
+		Available:        g.Status == StatusAvailable,
+		Status:           g.Status,
+		Reason:           g.Reason,
+		Log:              g.Log,
+		LogFile:          g.LogFile,
+		ResponseHash:     g.ResponseHash,
+		PrivacyProtected: g.PrivacyProtected,
+		Registrar:        g.Registrar,
+		CreatedAt:        g.CreatedAt,
+		ExpiresAt:        g.ExpiresAt,
+		EPPStatus:        g.EPPStatus,
+		OnHold:           g.OnHold,
+		InRedemption:     g.InRedemption,
+		NameServers:      g.NameServers,
+		Parked:           g.Parked,
+		HTTPStatus:       g.HTTPStatus,
+		HTTPRedirect:     g.HTTPRedirect,
+		HTTPError:        g.HTTPError,
+		Alternatives:     g.Alternatives,
+		EstimatedValue:   g.EstimatedValue,
+		FirstYearPrice:   g.FirstYearPrice,
+		PriceCurrency:    g.PriceCurrency,
+		Premium:          g.Premium,
+		OwnedByUs:        g.OwnedByUs,
+		Confidence:       g.Confidence,
+		CheckedAt:        g.CheckedAt,
+		RunID:            g.RunID,
+		Attempts:         g.Attempts,
+		Rationale:        g.Rationale,
+		Keywords:         g.Keywords,
+		Favorite:         g.Favorite,
+		Rejected:         g.Rejected,
+		Notes:            g.Notes,
+		Priority:         g.Priority,
+		Extra:            g.Extra,
+	}
 }
 
 // GroupedData is the top-level object for grouped JSON. It has two arrays:
 // "available" and "unavailable", each containing objects with domain + reason.
+// With --retry-errors, domains whose check failed are kept in "unverified"
+// instead so the next run retries them.
 type GroupedData struct {
 	Available   []GroupedDomain `json:"available"`
 	Unavailable []GroupedDomain `json:"unavailable"`
+	Unverified  []DomainRecord  `json:"unverified,omitempty"`
 }
 
 // ExtendedGroupedData represents a grouped JSON file that may also contain
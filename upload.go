@@ -0,0 +1,226 @@
+package talia
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// uploadTarget is a parsed --upload destination: either an S3 object
+// (s3://bucket/key) or a URL to PUT to (WebDAV, presigned URLs, ...).
+type uploadTarget struct {
+	raw    string
+	bucket string // set for S3
+	key    string
+}
+
+// parseUploadTarget validates an --upload value.
+func parseUploadTarget(s string) (uploadTarget, error) {
+	if rest, ok := strings.CutPrefix(s, "s3://"); ok {
+		bucket, key, _ := strings.Cut(rest, "/")
+		if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+			return uploadTarget{}, fmt.Errorf("invalid --upload %q: want s3://bucket/key", s)
+		}
+		return uploadTarget{raw: s, bucket: bucket, key: key}, nil
+	}
+	if isURL(s) {
+		return uploadTarget{raw: s}, nil
+	}
+	return uploadTarget{}, fmt.Errorf("invalid --upload %q: want s3://bucket/key or an http(s) URL", s)
+}
+
+// s3Credentials are read from the standard AWS environment variables.
+type s3Credentials struct {
+	accessKey, secretKey, sessionToken string
+	region                             string
+	endpoint                           string // custom endpoint (MinIO, R2, ...), or "" for AWS
+}
+
+// s3CredentialsFromEnv reads AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN, AWS_REGION (or AWS_DEFAULT_REGION, else us-east-1) and
+// AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL).
+func s3CredentialsFromEnv() (s3Credentials, error) {
+	c := s3Credentials{
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		region:       cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		endpoint:     cmp.Or(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")),
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return s3Credentials{}, fmt.Errorf("uploading to S3 requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return c, nil
+}
+
+// objectURL returns the URL of bucket/key: virtual-hosted style on AWS,
+// path style on a custom endpoint, which is what S3-compatible stores expect.
+func (c s3Credentials) objectURL(bucket, key string) string {
+	if c.endpoint == "" {
+		return "https://" + bucket + ".s3." + c.region + ".amazonaws.com/" + s3EscapePath(key)
+	}
+	return strings.TrimSuffix(c.endpoint, "/") + "/" + bucket + "/" + s3EscapePath(key)
+}
+
+// uploadBody returns the contents of the file at path as uploaded: a grouped
+// file with a pending log (see WriteGroupedFile) is read through it, so the
+// upload holds the latest results; any other file is sent as it is.
+func uploadBody(path string) ([]byte, error) {
+	if _, err := os.Stat(pendingLogPath(path)); err != nil {
+		return os.ReadFile(path)
+	}
+	data, err := readGroupedFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// uploadFile PUTs the file at path to target (see uploadBody). HTTP targets
+// get auth (if any) as the Authorization header; S3 targets are signed with
+// Signature Version 4 using creds.
+func uploadFile(client httpDoer, target uploadTarget, path, auth string, creds s3Credentials, now time.Time) error {
+	body, err := uploadBody(path)
+	if err != nil {
+		return err
+	}
+	u := target.raw
+	if target.bucket != "" {
+		u = creds.objectURL(target.bucket, target.key)
+	}
+	req, err := http.NewRequest(http.MethodPut, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if target.bucket != "" {
+		signV4(req, body, creds, "s3", now)
+	} else if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload %s: %w", target.raw, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("upload %s: status %s", target.raw, resp.Status)
+	}
+	return nil
+}
+
+// signV4 adds the AWS Signature Version 4 headers to req, signing the host
+// and every header already set on req.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html.
+func signV4(req *http.Request, body []byte, creds s3Credentials, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := slices.Sorted(maps.Keys(headers))
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + creds.region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), day)
+	key = hmacSHA256(key, creds.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.accessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath percent-encodes everything in p except unreserved characters
+// and '/', as SigV4 requires for S3 object keys.
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// uploadRunResults uploads the file a run wrote to cfg.upload, if set. It
+// returns the process exit code.
+func uploadRunResults(cfg runConfig, path string) int {
+	if cfg.upload.raw == "" {
+		return 0
+	}
+	if err := uploadFile(http.DefaultClient, cfg.upload, path, cfg.uploadAuth, cfg.uploadCreds, time.Now()); err != nil {
+		return fail(cliError{Code: errCodeDelivery, Path: cfg.upload.raw}, "Error uploading results: %v", err)
+	}
+	fmt.Fprintln(cfg.status(), "Uploaded", path, "to", cfg.upload.raw)
+	return 0
+}
+
+// applyUpload validates --upload and stores the destination and its
+// credentials in cfg. TALIA_UPLOAD_AUTH is the Authorization header for
+// HTTP destinations.
+func applyUpload(cfg *runConfig, dest string) error {
+	if dest == "" {
+		return nil
+	}
+	target, err := parseUploadTarget(dest)
+	if err != nil {
+		return err
+	}
+	cfg.upload = target
+	if target.bucket == "" {
+		cfg.uploadAuth = os.Getenv("TALIA_UPLOAD_AUTH")
+		return nil
+	}
+	cfg.uploadCreds, err = s3CredentialsFromEnv()
+	return err
+}
@@ -0,0 +1,115 @@
+package talia
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// defaultValuationURL is GoDaddy's appraisal (GoValue) endpoint. "{domain}"
+// is replaced with the domain being valued.
+const defaultValuationURL = "https://api.godaddy.com/v1/appraisal/{domain}"
+
+// godaddyAuth returns the Authorization header for GoDaddy's API from
+// GODADDY_API_KEY and GODADDY_API_SECRET, or "" if they are not set.
+func godaddyAuth() string {
+	key, secret := os.Getenv("GODADDY_API_KEY"), os.Getenv("GODADDY_API_SECRET")
+	if key == "" || secret == "" {
+		return ""
+	}
+	return "sso-key " + key + ":" + secret
+}
+
+// getJSON fetches url with the given Authorization header (if any) and
+// decodes the JSON response into v.
+func getJSON(client httpDoer, url, auth string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// appraiseDomain asks the valuation API at urlTemplate for domain's
+// estimated value in USD. GoDaddy answers with "govalue"; other APIs may use
+// "estimatedValue" or "value".
+func appraiseDomain(client httpDoer, urlTemplate, auth, domain string) (float64, error) {
+	var body map[string]any
+	u := strings.ReplaceAll(urlTemplate, "{domain}", url.PathEscape(domain))
+	if err := getJSON(client, u, auth, &body); err != nil {
+		return 0, fmt.Errorf("valuation of %s: %w", domain, err)
+	}
+	for _, key := range []string{"govalue", "estimatedValue", "value"} {
+		if v, ok := body[key].(float64); ok {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("valuation of %s: no value in response", domain)
+}
+
+// addValuations stores the estimated value of each available domain, and of
+// each taken domain that is on hold or in redemption (it may soon drop).
+// Failed lookups are reported as warnings and leave the value unset.
+func addValuations(results []checkResult, cfg runConfig) {
+	n := 0
+	for i, res := range results {
+		dropping := hasStatus(res.EPPStatus, holdStatuses) || hasStatus(res.EPPStatus, redemptionStatuses)
+		if !res.Avail && !dropping {
+			continue
+		}
+		v, err := appraiseDomain(http.DefaultClient, cfg.valuationURL, cfg.valuationAuth, res.Domain)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+			continue
+		}
+		results[i].EstimatedValue = v
+		n++
+	}
+	if n > 0 {
+		fmt.Fprintf(cfg.status(), "Valued %d domains\n", n)
+	}
+}
+
+// valuationFlags are the --valuation flags of file mode and "talia check".
+type valuationFlags struct {
+	enabled bool
+	url     string
+}
+
+// addValuationFlags registers the valuation flags on fs.
+func addValuationFlags(fs *flag.FlagSet) *valuationFlags {
+	f := &valuationFlags{}
+	fs.BoolVar(&f.enabled, "valuation", false, "Store an 'estimatedValue' for available and dropping domains from a valuation API (GoDaddy by default; needs GODADDY_API_KEY and GODADDY_API_SECRET)")
+	fs.StringVar(&f.url, "valuation-url", defaultValuationURL, "Valuation API URL; {domain} is replaced with the domain")
+	return f
+}
+
+// apply copies the flags to cfg. The GoDaddy credentials are required only
+// for the GoDaddy endpoint.
+func (f *valuationFlags) apply(cfg *runConfig) error {
+	if !f.enabled {
+		return nil
+	}
+	cfg.valuationURL = f.url
+	cfg.valuationAuth = godaddyAuth()
+	if cfg.valuationAuth == "" && strings.Contains(f.url, "api.godaddy.com") {
+		return fmt.Errorf("--valuation requires GODADDY_API_KEY and GODADDY_API_SECRET")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package talia
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestAddValuations values available and dropping domains only, sending the
+// GoDaddy credentials, and warns about failed lookups.
+func TestAddValuations(t *testing.T) {
+	var asked []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "sso-key k:s" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		domain := strings.TrimPrefix(r.URL.Path, "/appraisal/")
+		asked = append(asked, domain)
+		switch domain {
+		case "free.com":
+			_, _ = w.Write([]byte(`{"domain":"free.com","govalue":1450}`))
+		case "held.com":
+			_, _ = w.Write([]byte(`{"estimatedValue":80.5}`))
+		default:
+			http.Error(w, "no", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("GODADDY_API_KEY", "k")
+	t.Setenv("GODADDY_API_SECRET", "s")
+
+	var cfg runConfig
+	f := valuationFlags{enabled: true, url: srv.URL + "/appraisal/{domain}"}
+	if err := f.apply(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	results := []checkResult{
+		{Domain: "free.com", Avail: true, Reason: ReasonNoMatch},
+		{Domain: "taken.com", Reason: ReasonTaken},
+		{Domain: "held.com", Reason: ReasonTaken, EPPStatus: []string{"clientHold"}},
+		{Domain: "broken.com", Avail: true, Reason: ReasonNoMatch},
+	}
+	_, stderr := captureOutput(t, func() { addValuations(results, cfg) })
+
+	if strings.Join(asked, " ") != "free.com held.com broken.com" {
+		t.Errorf("asked = %v", asked)
+	}
+	if results[0].EstimatedValue != 1450 || results[1].EstimatedValue != 0 || results[2].EstimatedValue != 80.5 || results[3].EstimatedValue != 0 {
+		t.Errorf("results = %+v", results)
+	}
+	if !strings.Contains(stderr, "valuation of broken.com: unexpected status 500") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if rec := results[0].record(); rec.EstimatedValue != 1450 || rec.grouped().record().EstimatedValue != 1450 {
+		t.Errorf("record = %+v", rec)
+	}
+}
+
+// TestValuationFlagsNeedCredentials rejects the GoDaddy endpoint without
+// credentials.
+func TestValuationFlagsNeedCredentials(t *testing.T) {
+	t.Setenv("GODADDY_API_KEY", "")
+	var cfg runConfig
+	f := valuationFlags{enabled: true, url: defaultValuationURL}
+	if err := f.apply(&cfg); err == nil || !strings.Contains(err.Error(), "GODADDY_API_KEY") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package talia
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// defaultWatchDays is the --days default of "talia watchlist".
+const defaultWatchDays = 30
+
+// grabCandidates returns the taken records that expire within days of now
+// (or already have) and show no sign of renewal, soonest first. Each is
+// reduced to an unverified entry: the domain, its expiry, and the user's own
+// fields.
+func grabCandidates(records []DomainRecord, days int, now time.Time) []DomainRecord {
+	cutoff := now.AddDate(0, 0, days)
+	var out []DomainRecord
+	for _, rec := range records {
+		taken := rec.Status == StatusTaken || (rec.Status == "" && rec.Reason == ReasonTaken)
+		if !taken || rec.ExpiresAt.IsZero() || rec.ExpiresAt.After(cutoff) {
+			continue
+		}
+		if hasStatus(rec.EPPStatus, renewalStatuses) {
+			continue
+		}
+		out = append(out, DomainRecord{Domain: rec.Domain, ExpiresAt: rec.ExpiresAt, Extra: rec.Extra})
+	}
+	slices.SortStableFunc(out, func(a, b DomainRecord) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+	return out
+}
+
+// runWatchlistCommand implements "talia watchlist <file>": taken domains
+// expiring within --days that lack renewal signals are written as a new
+// grouped file whose "unverified" list is ready for frequent re-checks.
+// The input file is not modified.
+func runWatchlistCommand(args []string) int {
+	fs := flag.NewFlagSet("talia watchlist", flag.ContinueOnError)
+	days := fs.Int("days", defaultWatchDays, "Include taken domains expiring within this many days (already expired ones too)")
+	output := fs.String("output", "", "Write the grab list to this file (replacing it) instead of stdout")
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(args) != 1 || *days < 0 {
+		fmt.Fprintln(os.Stderr, "Usage: talia watchlist [--days=30] [--output=grab.json] <json-file>")
+		return 1
+	}
+
+	records, err := readRecords(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error reading file:", err)
+		return 1
+	}
+	grab := grabCandidates(records, *days, time.Now())
+	out, err := json.MarshalIndent(ExtendedGroupedData{Unverified: grab}, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+		return 1
+	}
+	if *output == "" {
+		fmt.Println(string(out))
+	} else if err := auditWrite("watchlist", "", *output, func() error { return writeFileAtomic(*output, out, 0644) }); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *output, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d domains expire within %d days without renewal signals\n", len(grab), len(records), *days)
+	return 0
+}
@@ -0,0 +1,65 @@
+package talia
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestGrabCandidates keeps taken domains expiring soon without renewal
+// signals, soonest first.
+func TestGrabCandidates(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) time.Time { return now.AddDate(0, 0, n) }
+	records := []DomainRecord{
+		{Domain: "later.com", Status: StatusTaken, ExpiresAt: day(20)},
+		{Domain: "soon.com", Reason: ReasonTaken, ExpiresAt: day(3), Registrar: "X"},
+		{Domain: "expired.com", Status: StatusTaken, ExpiresAt: day(-5), EPPStatus: []string{"redemptionPeriod"}},
+		{Domain: "renewed.com", Status: StatusTaken, ExpiresAt: day(2), EPPStatus: []string{"autoRenewPeriod"}},
+		{Domain: "far.com", Status: StatusTaken, ExpiresAt: day(90)},
+		{Domain: "unknown.com", Status: StatusTaken},
+		{Domain: "free.com", Available: true, Status: StatusAvailable, ExpiresAt: day(1)},
+	}
+	var got []string
+	for _, rec := range grabCandidates(records, 30, now) {
+		got = append(got, rec.Domain)
+		if rec.Registrar != "" || rec.ExpiresAt.IsZero() {
+			t.Errorf("unexpected candidate fields: %+v", rec)
+		}
+	}
+	if strings.Join(got, " ") != "expired.com soon.com later.com" {
+		t.Errorf("candidates = %v", got)
+	}
+}
+
+// TestRunWatchlistCommand writes the grab list to a new grouped file.
+func TestRunWatchlistCommand(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "domains.json")
+	soon := time.Now().AddDate(0, 0, 3).UTC().Format(time.RFC3339)
+	data := `{"unavailable":[{"domain":"soon.com","reason":"TAKEN","status":"taken","expiresAt":"` + soon + `","owner":"me"},{"domain":"far.com","reason":"TAKEN","status":"taken","expiresAt":"2099-01-01T00:00:00Z"}]}`
+	if err := os.WriteFile(in, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "grab.json")
+	_, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"watchlist", in, "--days=7", "--output", out}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stderr, "1 of 2 domains expire within 7 days") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	var ext ExtendedGroupedData
+	raw, _ := os.ReadFile(out)
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		t.Fatal(err)
+	}
+	if len(ext.Unverified) != 1 || ext.Unverified[0].Domain != "soon.com" || string(ext.Unverified[0].Extra["owner"]) != `"me"` {
+		t.Errorf("grab list = %s", raw)
+	}
+}
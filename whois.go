@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"strings"
+	"time"
 )
 
 // WhoisClient abstracts a WHOIS lookup mechanism.
@@ -17,12 +18,40 @@ type WhoisClient interface {
 // NetWhoisClient performs WHOIS lookups over TCP.
 type NetWhoisClient struct {
 	Server string
+
+	// QueryFormat is the query line sent, with "%s" replaced by the domain,
+	// for registries that expect more than the bare domain (e.g. DENIC's
+	// "-T dn %s"). Empty sends the domain as is.
+	QueryFormat string
+
+	// Timeout bounds the whole lookup, connecting included. Zero waits as
+	// long as the server and the operating system allow.
+	Timeout time.Duration
+
+	// LocalAddr, if set, is the local address the connection is made from,
+	// for machines with several addresses.
+	LocalAddr net.Addr
+
+	// Network is "tcp4" or "tcp6" to use only that IP version. Empty is
+	// "tcp": either version, preferring IPv6 with a quick IPv4 fallback.
+	Network string
 }
 
 // Lookup queries the configured WHOIS server for the given domain and returns
 // the raw response string.
 func (c NetWhoisClient) Lookup(domain string) (string, error) {
-	conn, err := net.Dial("tcp", c.Server)
+	var deadline time.Time
+	if c.Timeout > 0 {
+		deadline = time.Now().Add(c.Timeout)
+	}
+	network := c.Network
+	if network == "" {
+		network = "tcp"
+	}
+	conn, err := (&net.Dialer{Deadline: deadline, LocalAddr: c.LocalAddr}).Dial(network, c.Server)
+	if isTimeout(err) {
+		return "", fmt.Errorf("no connection to WHOIS within %s", c.Timeout)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to connect to WHOIS: %w", err)
 	}
@@ -32,13 +61,17 @@ func (c NetWhoisClient) Lookup(domain string) (string, error) {
 		}
 	}()
 
-	_, _ = fmt.Fprintf(conn, "%s\r\n", domain)
+	_, _ = fmt.Fprintf(conn, "%s\r\n", whoisQuery(c.QueryFormat, domain))
 
 	if tcp, ok := conn.(*net.TCPConn); ok {
 		_ = tcp.CloseWrite()
 	}
 
+	_ = conn.SetDeadline(deadline)
 	data, err := io.ReadAll(conn)
+	if isTimeout(err) {
+		return "", fmt.Errorf("no WHOIS response within %s", c.Timeout)
+	}
 	if err != nil && !errors.Is(err, io.EOF) {
 		// Treat connection reset by peer and similar errors as empty WHOIS response
 		errStr := err.Error()
@@ -50,22 +83,62 @@ func (c NetWhoisClient) Lookup(domain string) (string, error) {
 	if len(data) == 0 {
 		return "", fmt.Errorf("empty WHOIS response")
 	}
-	return string(data), nil
+	return decodeWhoisResponse(data), nil
+}
+
+// isTimeout reports whether err is a network timeout, such as a passed
+// connection deadline.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
 }
 
 // CheckDomainAvailabilityWithClient queries the WHOIS client and interprets the
-// response to determine availability.
+// response to determine availability: a domain is available when the
+// response holds one of its registry's "not found" phrases (see phraseDB).
 func CheckDomainAvailabilityWithClient(domain string, client WhoisClient) (bool, AvailabilityReason, string, error) {
 	resp, err := client.Lookup(domain)
 	if err != nil {
 		return false, ReasonError, err.Error(), err
 	}
-	if strings.Contains(resp, "No match for") {
+	phrases, err := currentPhraseDB()
+	if err != nil {
+		return false, ReasonError, err.Error(), err
+	}
+	if phrases.notFound(domain, resp) {
 		return true, ReasonNoMatch, resp, nil
 	}
 	return false, ReasonTaken, resp, nil
 }
 
+// privacyMarkers are case-insensitive phrases registries and registrars use
+// when contact data is withheld under GDPR or a privacy service.
+var privacyMarkers = []string{
+	"redacted for privacy",
+	"data protected",
+	"redacted for gdpr",
+	"gdpr masked",
+	"not disclosed",
+	"withheld for privacy",
+	"privacy service",
+	"contact privacy",
+	"domains by proxy",
+	"whoisguard",
+}
+
+// isPrivacyProtected reports whether a WHOIS response withholds contact data
+// by policy. Such responses are sparse on purpose and should not be read as
+// errors or incomplete lookups.
+func isPrivacyProtected(resp string) bool {
+	lower := strings.ToLower(resp)
+	for _, m := range privacyMarkers {
+		if strings.Contains(lower, m) {
+			return true
+		}
+	}
+	return false
+}
+
 // CheckDomainAvailability queries a WHOIS server using NetWhoisClient.
 func CheckDomainAvailability(domain, server string) (bool, AvailabilityReason, string, error) {
 	return CheckDomainAvailabilityWithClient(domain, NetWhoisClient{Server: server})
@@ -2,10 +2,10 @@ package talia
 
 import (
 	"errors"
-	"io"
-	"net"
 	"strings"
 	"testing"
+
+	"github.com/sustanza/talia/taliatest"
 )
 
 type fakeWhoisClient struct {
@@ -65,21 +65,7 @@ func TestCheckDomainAvailabilityWithClient(t *testing.T) {
 }
 
 func TestNetWhoisClientLookupSuccess(t *testing.T) {
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer helperClose(t, ln, "listener")
-	go func() {
-		conn, _ := ln.Accept()
-		if conn != nil {
-			_, _ = io.Copy(io.Discard, conn)
-			_, _ = io.WriteString(conn, "Hello")
-			helperClose(nil, conn, "conn")
-		}
-	}()
-
-	c := NetWhoisClient{Server: ln.Addr().String()}
+	c := NetWhoisClient{Server: startWhoisServer(t, "Hello")}
 	resp, err := c.Lookup("example.com")
 	if err != nil {
 		t.Fatalf("Lookup error: %v", err)
@@ -97,21 +83,41 @@ func TestNetWhoisClientLookupDialError(t *testing.T) {
 }
 
 func TestNetWhoisClientLookupEmpty(t *testing.T) {
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer helperClose(t, ln, "listener")
-	go func() {
-		conn, _ := ln.Accept()
-		if conn != nil {
-			helperClose(nil, conn, "conn")
-		}
-	}()
+	srv := newWhoisServer(t)
+	srv.Handle("example.com", taliatest.Response{Fault: taliatest.Drop})
 
-	c := NetWhoisClient{Server: ln.Addr().String()}
-	_, err = c.Lookup("example.com")
+	c := NetWhoisClient{Server: srv.Addr}
+	_, err := c.Lookup("example.com")
 	if err == nil || !strings.Contains(err.Error(), "empty WHOIS") {
 		t.Fatalf("expected empty response error, got %v", err)
 	}
 }
+
+func TestIsPrivacyProtected(t *testing.T) {
+	t.Parallel()
+	tests := map[string]bool{
+		"Registrant Name: REDACTED FOR PRIVACY\n":           true,
+		"Registrant Organization: Data Protected\n":         true,
+		"Registrant Email: Please query the RDDS service\n": false,
+		"Domain Name: EXAMPLE.COM\nRegistrar: Foo Inc.\n":   false,
+	}
+	for resp, want := range tests {
+		if got := isPrivacyProtected(resp); got != want {
+			t.Errorf("isPrivacyProtected(%q) = %v, want %v", resp, got, want)
+		}
+	}
+}
+
+// TestCheckOnePrivacyProtected verifies the flag is set for redacted taken
+// domains and never for available ones.
+func TestCheckOnePrivacyProtected(t *testing.T) {
+	srv := newWhoisServer(t)
+	srv.Handle("taken.com", taliatest.Response{Body: "Domain Name: TAKEN.COM\nRegistrant Name: REDACTED FOR PRIVACY\n"})
+
+	if res := checkOne("taken.com", runConfig{whoisServer: srv.Addr}); !res.Privacy || res.Reason != ReasonTaken {
+		t.Errorf("taken.com: %+v", res)
+	}
+	if res := checkOne("free.com", runConfig{whoisServer: srv.Addr}); res.Privacy {
+		t.Errorf("free.com flagged as privacy protected: %+v", res)
+	}
+}
@@ -0,0 +1,204 @@
+package talia
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// whoisCache holds the WHOIS responses of a run. One cache is shared by all
+// workers of a run and by the passes that follow it (alternatives, the
+// suggestion pipeline): lookups are keyed by server and query line, and a
+// lookup of a key already seen, or still in flight, waits for the first one
+// instead of querying again, so a domain listed twice costs one network
+// lookup. With --whois-cache, successful responses are also kept on disk
+// under dir for ttl, so later runs reuse them. A nil *whoisCache caches
+// nothing.
+type whoisCache struct {
+	dir string // "" keeps responses for the run only
+	ttl time.Duration
+	clk clock
+
+	// replay answers only from dir, at any age, for --bench: a response
+	// that isn't stored fails with errNotCached instead of being fetched.
+	replay bool
+
+	// readOnly keeps responses for the run only, without writing dir
+	// (--read-only).
+	readOnly bool
+
+	mu      sync.Mutex
+	entries map[string]*whoisCacheEntry
+	shared  int // lookups answered by an earlier lookup of the run
+	stored  int // lookups answered from dir
+}
+
+// whoisCacheEntry is one response, or a lookup still in flight until done
+// is closed.
+type whoisCacheEntry struct {
+	done      chan struct{}
+	resp      string
+	fetchedAt time.Time
+	err       error
+}
+
+// whoisCacheFile is the on-disk form of a cached response.
+type whoisCacheFile struct {
+	Server    string    `json:"server"`
+	Query     string    `json:"query"`
+	Response  string    `json:"response"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// newWhoisCache returns a cache kept under dir for ttl, or for the run only
+// if dir is empty, with clk stamping new responses.
+func newWhoisCache(dir string, ttl time.Duration, clk clock) *whoisCache {
+	return &whoisCache{dir: dir, ttl: ttl, clk: clk, entries: make(map[string]*whoisCacheEntry)}
+}
+
+// lookup returns the response to query on server and when it was fetched,
+// calling fetch only if neither the run nor the disk has a fresh one. Failed
+// lookups are shared with the callers waiting on them but not kept, so a
+// later lookup tries again.
+func (c *whoisCache) lookup(server, query string, fetch func() (string, error)) (string, time.Time, error) {
+	if c == nil {
+		resp, err := fetch()
+		return resp, time.Time{}, err
+	}
+	key := server + "\n" + query
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.shared++
+		c.mu.Unlock()
+		<-e.done
+		return e.resp, e.fetchedAt, e.err
+	}
+	e := &whoisCacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	if cached, ok := c.read(key); ok {
+		e.resp, e.fetchedAt = cached.Response, cached.FetchedAt
+		c.mu.Lock()
+		c.stored++
+		c.mu.Unlock()
+	} else if c.replay {
+		e.err = errNotCached
+	} else {
+		e.resp, e.err = fetch()
+		e.fetchedAt = c.clk.Now().UTC()
+		if e.err == nil {
+			c.write(key, whoisCacheFile{Server: server, Query: query, Response: e.resp, FetchedAt: e.fetchedAt})
+		}
+	}
+	close(e.done)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	}
+	return e.resp, e.fetchedAt, e.err
+}
+
+// path returns the file of key, spread over 256 subdirectories.
+func (c *whoisCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name+".json")
+}
+
+// read returns the response stored for key if it is younger than the ttl.
+func (c *whoisCache) read(key string) (whoisCacheFile, bool) {
+	var f whoisCacheFile
+	if c.dir == "" {
+		return f, false
+	}
+	raw, err := os.ReadFile(c.path(key))
+	if err != nil || json.Unmarshal(raw, &f) != nil || (!c.replay && c.clk.Now().Sub(f.FetchedAt) > c.ttl) {
+		return whoisCacheFile{}, false
+	}
+	return f, true
+}
+
+// write stores f for key. A failed write only costs a query next time, so
+// it is reported as a warning.
+func (c *whoisCache) write(key string, f whoisCacheFile) {
+	if c.dir == "" || c.readOnly {
+		return
+	}
+	path := c.path(key)
+	raw, err := json.Marshal(f)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = writeFileAtomic(path, raw, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: could not cache WHOIS response:", err)
+	}
+}
+
+// cachedWhoisClient looks domains up through a whoisCache and remembers
+// when the last response was fetched. Lookups the cache can't answer
+// connect through dialer.
+type cachedWhoisClient struct {
+	cache     *whoisCache
+	client    NetWhoisClient
+	dialer    *whoisDialer
+	fetchedAt time.Time
+}
+
+// Lookup implements WhoisClient.
+func (c *cachedWhoisClient) Lookup(domain string) (string, error) {
+	query := whoisQuery(c.client.QueryFormat, domain)
+	resp, fetchedAt, err := c.cache.lookup(c.client.Server, query, func() (string, error) {
+		client := c.client
+		c.dialer.apply(&client)
+		return client.Lookup(domain)
+	})
+	c.fetchedAt = fetchedAt
+	return resp, err
+}
+
+// applyWhoisCache sets up the response cache of cfg's run: kept in the
+// "whois" directory of the cache directory for ttl with --whois-cache, else
+// for the run only.
+func applyWhoisCache(cfg *runConfig, ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("--whois-cache must be positive")
+	}
+	if ttl == 0 {
+		cfg.whoisCache = newWhoisCache("", 0, cfg.timeSource())
+		return nil
+	}
+	dir, err := cacheDir()
+	if err != nil {
+		return fmt.Errorf("--whois-cache: %w", err)
+	}
+	cfg.whoisCache = newWhoisCache(filepath.Join(dir, "whois"), ttl, cfg.timeSource())
+	cfg.whoisCache.readOnly = cfg.readOnly
+	return nil
+}
+
+// reportHits prints how many lookups of the run the cache answered.
+func (c *whoisCache) reportHits(cfg runConfig) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	shared, stored := c.shared, c.stored
+	c.mu.Unlock()
+	if shared > 0 {
+		fmt.Fprintf(cfg.status(), "Duplicate lookups: %d answered by an earlier query of the run\n", shared)
+	}
+	if stored > 0 {
+		fmt.Fprintf(cfg.status(), "WHOIS cache: %d lookups answered from the cache directory\n", stored)
+	}
+}
@@ -0,0 +1,135 @@
+package talia
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWhoisCacheSingleFlight makes concurrent lookups of one key wait for a
+// single fetch.
+func TestWhoisCacheSingleFlight(t *testing.T) {
+	t.Parallel()
+	cache := newWhoisCache("", time.Hour, systemClock{})
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	fetch := func() (string, error) {
+		fetches.Add(1)
+		<-release
+		return "Domain Name: A.COM\n", nil
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, _, err := cache.lookup("whois.example:43", "a.com", fetch); err != nil || resp == "" {
+				t.Errorf("lookup = %q, %v", resp, err)
+			}
+		}()
+	}
+	// Let the goroutines reach the cache before the fetch completes.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times, want 1", n)
+	}
+	if cache.shared != 7 {
+		t.Errorf("shared = %d, want 7", cache.shared)
+	}
+}
+
+// TestWhoisCacheDisk reuses stored responses until they expire and never
+// stores failures.
+func TestWhoisCacheDisk(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	clk := &fakeClock{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	var fetched []string
+	fetch := func(resp string, err error) func() (string, error) {
+		return func() (string, error) {
+			fetched = append(fetched, resp)
+			return resp, err
+		}
+	}
+
+	if _, _, err := newWhoisCache(dir, time.Hour, clk).lookup("s", "a.com", fetch("first", nil)); err != nil {
+		t.Fatal(err)
+	}
+	clk.Sleep(30 * time.Minute)
+	resp, fetchedAt, _ := newWhoisCache(dir, time.Hour, clk).lookup("s", "a.com", fetch("second", nil))
+	if resp != "first" || !fetchedAt.Equal(clk.now.Add(-30*time.Minute)) {
+		t.Errorf("cached lookup = %q at %v", resp, fetchedAt)
+	}
+	clk.Sleep(time.Hour)
+	if resp, _, _ := newWhoisCache(dir, time.Hour, clk).lookup("s", "a.com", fetch("third", nil)); resp != "third" {
+		t.Errorf("expired lookup = %q", resp)
+	}
+
+	cache := newWhoisCache(dir, time.Hour, clk)
+	if _, _, err := cache.lookup("s", "b.com", fetch("", errors.New("timeout"))); err == nil {
+		t.Error("error lost")
+	}
+	if resp, _, _ := cache.lookup("s", "b.com", fetch("retried", nil)); resp != "retried" {
+		t.Errorf("failed lookup was kept: %q", resp)
+	}
+	if want := []string{"first", "third", "", "retried"}; !slices.Equal(fetched, want) {
+		t.Errorf("fetched = %v, want %v", fetched, want)
+	}
+}
+
+// TestRunCLIDuplicateLookups queries a domain listed twice once, without
+// --whois-cache, and stores both records.
+func TestRunCLIDuplicateLookups(t *testing.T) {
+	srv := newWhoisServer(t)
+	path := filepath.Join(t.TempDir(), "list.json")
+	if err := os.WriteFile(path, []byte(`[{"domain":"a.com"},{"domain":"b.com"},{"domain":"a.com"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"--whois=" + srv.Addr, "--sleep=0s", path}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if got := srv.Queries(); !slices.Equal(got, []string{"a.com", "b.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+	if !strings.Contains(stdout, "Duplicate lookups: 1") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	records, err := readRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[2].Reason != records[0].Reason {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+// TestRunCheckCommandWhoisCache looks a duplicated domain up once across
+// parallel workers, and not at all on the next run.
+func TestRunCheckCommandWhoisCache(t *testing.T) {
+	t.Setenv("TALIA_CACHE_DIR", t.TempDir())
+	srv := newWhoisServer(t)
+	args := []string{"check", "--whois=" + srv.Addr, "--lightspeed=4", "--whois-cache=1h", "--format=json", "a.com", "a.com", "a.com", "b.com"}
+	for range 2 {
+		captureOutput(t, func() {
+			if code := RunCLI(args); code != 0 {
+				t.Errorf("exit %d", code)
+			}
+		})
+	}
+	got := srv.Queries()
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"a.com", "b.com"}) {
+		t.Errorf("queries = %v", got)
+	}
+}
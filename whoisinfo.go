@@ -0,0 +1,178 @@
+package talia
+
+import (
+	"iter"
+	"slices"
+	"strings"
+	"time"
+)
+
+// whoisInfo holds the fields Talia extracts from a WHOIS response.
+type whoisInfo struct {
+	Registrar string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	Statuses  []string // EPP status codes, e.g. "clientHold"
+
+	// Registrant is the registrant organization or name, where the
+	// response isn't redacted; NameServers are the delegated hosts,
+	// lowercased and without a trailing dot.
+	Registrant  string
+	NameServers []string
+}
+
+// statusKeys are the field names registries use for EPP status codes,
+// lowercased. Every occurrence is collected.
+var statusKeys = []string{"domain status", "status"}
+
+// EPP status codes that signal a domain may soon become registrable.
+var (
+	holdStatuses       = []string{"clienthold", "serverhold"}
+	redemptionStatuses = []string{"redemptionperiod", "pendingrestore", "pendingdelete"}
+)
+
+// renewalStatuses are EPP status codes showing a domain was just renewed or
+// transferred (which adds a year), so its expiry date is about to move.
+var renewalStatuses = []string{"autorenewperiod", "renewperiod", "transferperiod"}
+
+// hasStatus reports whether any of statuses is in codes, ignoring case.
+func hasStatus(statuses, codes []string) bool {
+	for _, st := range statuses {
+		if slices.Contains(codes, strings.ToLower(st)) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameServerContains reports whether any of servers contains text, which
+// must be lowercase.
+func nameServerContains(servers []string, text string) bool {
+	for _, ns := range servers {
+		if strings.Contains(strings.ToLower(ns), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// registrarKeys are the field names registries use for the sponsoring
+// registrar, lowercased.
+var registrarKeys = []string{"registrar", "sponsoring registrar", "registrar name"}
+
+// registrantKeys are the field names registries use for the registrant,
+// lowercased. The organization comes first: it names the company where the
+// name is often a person or a role.
+var registrantKeys = []string{"registrant organization", "registrant organisation", "registrant org", "registrant name", "registrant"}
+
+// nameServerKeys are the field names registries use for delegated name
+// servers, lowercased. Every occurrence is collected.
+var nameServerKeys = []string{"name server", "nameserver", "nameservers", "nserver", "name servers"}
+
+// createdKeys are the field names registries use for the creation date,
+// lowercased.
+var createdKeys = []string{"creation date", "created", "created on", "created date", "registered on", "registration time", "domain registration date"}
+
+// expiryKeys are the field names registries use for the expiration date,
+// lowercased. The registry's own "Registry Expiry Date" comes first in thin
+// responses and wins over the registrar's.
+var expiryKeys = []string{"registry expiry date", "registrar registration expiration date", "expiration date", "expiry date", "expires", "expires on", "expire date", "paid-till", "renewal date"}
+
+// whoisDateLayouts are the date formats seen in WHOIS dates, tried in order.
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2006.01.02",
+	"2006/01/02",
+	"02.01.2006",
+}
+
+// parseWhoisDate parses a WHOIS date value, ignoring any trailing text such as
+// a time zone name ("2000-01-01 00:00:00 (GMT)"). Dates without a zone are
+// taken as UTC.
+func parseWhoisDate(value string) (time.Time, bool) {
+	for _, layout := range whoisDateLayouts {
+		candidate := value
+		if len(candidate) > len(layout) && layout != time.RFC3339 {
+			candidate = candidate[:len(layout)]
+		}
+		if t, err := time.Parse(layout, candidate); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// whoisFields yields the "Key: value" pairs of a WHOIS response in order,
+// with keys lowercased and both sides trimmed. Lines without a colon or with
+// an empty value are skipped.
+func whoisFields(resp string) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for line := range strings.SplitSeq(resp, "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// parseWhois extracts structured fields from a raw WHOIS response made of
+// "Key: value" lines. Keys are matched case-insensitively and the first
+// non-empty value for a field wins, since thin registries append the
+// registrar's own (sometimes contradictory) record after theirs.
+func parseWhois(resp string) whoisInfo {
+	var info whoisInfo
+	registrantRank := len(registrantKeys)
+	for key, value := range whoisFields(resp) {
+		switch {
+		case slices.Contains(registrantKeys, key):
+			if rank := slices.Index(registrantKeys, key); rank < registrantRank {
+				info.Registrant, registrantRank = value, rank
+			}
+		case slices.Contains(nameServerKeys, key):
+			// "NS1.EXAMPLE.NET 192.0.2.1" -> "ns1.example.net"
+			host := strings.TrimSuffix(strings.ToLower(strings.Fields(value)[0]), ".")
+			if !slices.Contains(info.NameServers, host) {
+				info.NameServers = append(info.NameServers, host)
+			}
+		case info.Registrar == "" && slices.Contains(registrarKeys, key):
+			info.Registrar = value
+		case info.CreatedAt.IsZero() && slices.Contains(createdKeys, key):
+			if t, ok := parseWhoisDate(value); ok {
+				info.CreatedAt = t
+			}
+		case info.ExpiresAt.IsZero() && slices.Contains(expiryKeys, key):
+			if t, ok := parseWhoisDate(value); ok {
+				info.ExpiresAt = t
+			}
+		case slices.Contains(statusKeys, key):
+			// "clientHold https://icann.org/epp#clientHold" -> "clientHold"
+			code := strings.Fields(value)[0]
+			if !slices.Contains(info.Statuses, code) {
+				info.Statuses = append(info.Statuses, code)
+			}
+		}
+	}
+	return info
+}
+
+// domainAge returns the age in years of a domain created at created, or false
+// if the creation date is unknown.
+func domainAge(created, now time.Time) (float64, bool) {
+	if created.IsZero() {
+		return 0, false
+	}
+	return now.Sub(created).Hours() / 24 / 365.25, true
+}
@@ -0,0 +1,130 @@
+package talia
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseWhoisRegistrar picks the first registrar line under any of the
+// common key spellings.
+func TestParseWhoisRegistrar(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"Domain Name: EXAMPLE.COM\nRegistrar WHOIS Server: whois.godaddy.com\nRegistrar: GoDaddy.com, LLC\nRegistrar: Other\n": "GoDaddy.com, LLC",
+		"domain: example.io\nSponsoring Registrar: Key-Systems GmbH\n":                                                         "Key-Systems GmbH",
+		"Registrar:\n  Registrar Name: Gandi SAS\n":                                                                            "Gandi SAS",
+		"No match for \"FREE.COM\".\n":                                                                                         "",
+	}
+	for resp, want := range cases {
+		if got := parseWhois(resp).Registrar; got != want {
+			t.Errorf("parseWhois(%q).Registrar = %q, want %q", resp, got, want)
+		}
+	}
+}
+
+// TestCheckOneRegistrar stores the registrar of taken domains.
+func TestCheckOneRegistrar(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: TAKEN.COM\r\nRegistrar: NameCheap, Inc.\r\n")
+	res := checkOne("taken.com", runConfig{whoisServer: addr})
+	if res.Registrar != "NameCheap, Inc." {
+		t.Errorf("Registrar = %q", res.Registrar)
+	}
+	if rec := res.record(); rec.Registrar != res.Registrar || rec.grouped().Registrar != res.Registrar {
+		t.Errorf("registrar lost in conversion: %+v", rec)
+	}
+}
+
+// TestParseWhoisCreatedAt recognizes the common creation-date keys and
+// formats.
+func TestParseWhoisCreatedAt(t *testing.T) {
+	t.Parallel()
+	want := time.Date(1997, 9, 15, 0, 0, 0, 0, time.UTC)
+	for _, resp := range []string{
+		"Creation Date: 1997-09-15T00:00:00Z\n",
+		"Creation Date: 1997-09-15T00:00:00.000Z\n",
+		"created: 1997-09-15\n",
+		"Created On: 15-sep-1997\n",
+		"Registered on: 15-Sep-1997\n",
+		"Registration Time: 1997-09-15 00:00:00 (GMT)\n",
+		"Created: 1997.09.15\n",
+	} {
+		if got := parseWhois(resp).CreatedAt; !got.Equal(want) {
+			t.Errorf("parseWhois(%q).CreatedAt = %v", resp, got)
+		}
+	}
+	if got := parseWhois("Creation Date: sometime\n").CreatedAt; !got.IsZero() {
+		t.Errorf("unparseable date gave %v", got)
+	}
+}
+
+// TestParseWhoisExpiresAt prefers the registry's expiry date over the
+// registrar's and reads ccTLD spellings.
+func TestParseWhoisExpiresAt(t *testing.T) {
+	t.Parallel()
+	want := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, resp := range []string{
+		"Registry Expiry Date: 2027-03-01T00:00:00Z\nRegistrar Registration Expiration Date: 2028-01-01T00:00:00Z\n",
+		"Expiry date: 01-Mar-2027\n",
+		"paid-till: 2027-03-01T00:00:00Z\n",
+	} {
+		if got := parseWhois(resp).ExpiresAt; !got.Equal(want) {
+			t.Errorf("parseWhois(%q).ExpiresAt = %v", resp, got)
+		}
+	}
+}
+
+// TestDomainAge computes years since creation.
+func TestDomainAge(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if age, ok := domainAge(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), now); !ok || age < 9.99 || age > 10.01 {
+		t.Errorf("domainAge = %v, %v", age, ok)
+	}
+	if _, ok := domainAge(time.Time{}, now); ok {
+		t.Error("unknown creation date reported an age")
+	}
+}
+
+// TestParseWhoisStatuses collects EPP codes without their ICANN links and
+// flags hold and redemption states on the record.
+func TestParseWhoisStatuses(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: HELD.COM\n"+
+		"Domain Status: clientHold https://icann.org/epp#clientHold\n"+
+		"Domain Status: redemptionPeriod https://icann.org/epp#redemptionPeriod\n"+
+		"Domain Status: clientHold https://icann.org/epp#clientHold\n")
+	rec := checkOne("held.com", runConfig{whoisServer: addr}).record()
+	if got := strings.Join(rec.EPPStatus, ","); got != "clientHold,redemptionPeriod" {
+		t.Errorf("EPPStatus = %q", got)
+	}
+	if !rec.OnHold || !rec.InRedemption {
+		t.Errorf("flags not set: %+v", rec)
+	}
+
+	rec = DomainRecord{EPPStatus: parseWhois("Status: ok\n").Statuses}
+	if hasStatus(rec.EPPStatus, holdStatuses) || hasStatus(rec.EPPStatus, redemptionStatuses) {
+		t.Errorf("ok status flagged: %v", rec.EPPStatus)
+	}
+}
+
+// TestParseWhoisRegistrantAndNameServers prefers the registrant organization
+// to the name and normalizes name servers.
+func TestParseWhoisRegistrantAndNameServers(t *testing.T) {
+	info := parseWhois("Registrant Name: Jane Doe\nRegistrant Organization: Acme Corp\n" +
+		"Name Server: NS1.ACME-DNS.NET.\nName Server: ns2.acme-dns.net 192.0.2.1\n")
+	if info.Registrant != "Acme Corp" {
+		t.Errorf("Registrant = %q", info.Registrant)
+	}
+	if got := strings.Join(info.NameServers, ","); got != "ns1.acme-dns.net,ns2.acme-dns.net" {
+		t.Errorf("NameServers = %q", got)
+	}
+}
+
+// TestCheckOneNameServers stores the name servers of taken domains.
+func TestCheckOneNameServers(t *testing.T) {
+	addr := startWhoisServer(t, "Domain Name: PARKED.COM\r\nName Server: NS1.SEDOPARKING.COM\r\nName Server: NS2.SEDOPARKING.COM\r\n")
+	rec := checkOne("parked.com", runConfig{whoisServer: addr}).record()
+	if got := strings.Join(rec.grouped().record().NameServers, ","); got != "ns1.sedoparking.com,ns2.sedoparking.com" {
+		t.Errorf("NameServers = %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+package talia
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// importFilter selects domains during "talia import". The zero value
+// matches every domain.
+type importFilter struct {
+	minLength, maxLength int      // bounds on the first label's length; 0 is unbounded
+	keywords             []string // the label must contain one of these
+	exclude              []string // the label must contain none of these
+	tlds                 []string // the domain must be in one of these TLDs ("uk" or "co.uk")
+}
+
+// match reports whether domain passes the filter.
+func (f importFilter) match(domain string) bool {
+	label, _, _ := strings.Cut(domain, ".")
+	if (f.minLength > 0 && len(label) < f.minLength) || (f.maxLength > 0 && len(label) > f.maxLength) {
+		return false
+	}
+	if len(f.tlds) > 0 && !slices.Contains(f.tlds, tldOf(domain)) && !slices.Contains(f.tlds, publicSuffix(domain)) {
+		return false
+	}
+	contains := func(k string) bool { return strings.Contains(label, k) }
+	if len(f.keywords) > 0 && !slices.ContainsFunc(f.keywords, contains) {
+		return false
+	}
+	return !slices.ContainsFunc(f.exclude, contains)
+}
+
+// zoneLineDomain extracts the domain from one line of a drop list or zone
+// file, or returns "" for lines that hold none. It understands
+//
+//   - drop lists: the domain is the first field, whitespace- or
+//     comma-separated ("example.com,2026-05-01")
+//   - zone files: "example.com. 172800 IN NS ns1.example.net.", where a name
+//     without a trailing dot is relative to origin ("EXAMPLE NS NS1.EXAMPLE")
+//     and names below a registration (glue records) are skipped; a name
+//     under a second-level suffix (brand.co.uk in the uk zone) is a
+//     registration
+//   - zone diffs: lines prefixed with "-" (removed from the zone, so about to
+//     drop) are read; "+" lines (new registrations) are skipped
+//
+// Comments (";" or "#") and blank lines yield "".
+func zoneLineDomain(line, origin string) string {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "", line[0] == ';', line[0] == '#', line[0] == '+':
+		return ""
+	case line[0] == '-':
+		line = strings.TrimSpace(line[1:])
+	}
+	name, _, _ := strings.Cut(line, ",")
+	fields := strings.Fields(name)
+	if len(fields) == 0 {
+		return ""
+	}
+	name = strings.ToLower(fields[0])
+	if name == "@" {
+		return ""
+	}
+	if origin != "" {
+		if !strings.HasSuffix(name, ".") {
+			name += "." + origin
+		}
+		// Names more than one label below the origin are glue records
+		// (ns1.example.com. A ...), not registrations.
+		name = strings.TrimSuffix(name, ".")
+		if rel, ok := strings.CutSuffix(name, "."+origin); ok && strings.Contains(rel, ".") && registrableDomain(name) != name {
+			return ""
+		}
+	}
+	name = strings.TrimSuffix(name, ".")
+	label, rest, ok := strings.Cut(name, ".")
+	if !ok || rest == "" || !validDomainLabel.MatchString(label) || isPublicSuffix(name) {
+		return ""
+	}
+	return name
+}
+
+// importStats counts what "talia import" did with the input.
+type importStats struct {
+	lines, matched, duplicates int
+}
+
+// lineParser turns one line of an import file into the domains it holds.
+// Parsers may keep state across the lines of one file.
+type lineParser func(line string) ([]string, error)
+
+// zoneParser returns the parser for drop lists, zone files, and zone diffs
+// (see zoneLineDomain), which tracks the file's $ORIGIN.
+func zoneParser() lineParser {
+	origin := ""
+	return func(line string) ([]string, error) {
+		if fields := strings.Fields(line); len(fields) >= 2 && strings.EqualFold(fields[0], "$ORIGIN") {
+			origin = strings.ToLower(strings.Trim(fields[1], "."))
+			return nil, nil
+		}
+		if strings.HasPrefix(line, "$") {
+			return nil, nil
+		}
+		if domain := zoneLineDomain(line, origin); domain != "" {
+			return []string{domain}, nil
+		}
+		return nil, nil
+	}
+}
+
+// importZone reads an import file from r, gunzipping it first if it starts
+// with the gzip magic number, and calls emit for each new domain that parse
+// finds and that passes filter. seen deduplicates across inputs (zone files
+// list each domain once per NS record).
+func importZone(r io.Reader, parse lineParser, filter importFilter, seen domainSet, stats *importStats, emit func(string) error) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gz.Close() }()
+		br = bufio.NewReader(gz)
+	}
+
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		stats.lines++
+		domains, err := parse(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		for _, domain := range domains {
+			if !filter.match(domain) {
+				continue
+			}
+			added, err := seen.Add(domain)
+			if err != nil {
+				return err
+			}
+			if !added {
+				stats.duplicates++
+				continue
+			}
+			stats.matched++
+			if err := emit(domain); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// addUnverified appends domains to the "unverified" list of the grouped file
+// at path (created if missing), skipping domains already in any bucket. It
+// returns how many were added.
+func addUnverified(path string, domains []string) (int, error) {
+	var ext ExtendedGroupedData
+	if raw, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(raw, &ext); err != nil {
+			return 0, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	seen := make(map[string]bool)
+	for _, d := range ext.Available {
+		seen[strings.ToLower(d.Domain)] = true
+	}
+	for _, d := range ext.Unavailable {
+		seen[strings.ToLower(d.Domain)] = true
+	}
+	for _, d := range ext.Unverified {
+		seen[strings.ToLower(d.Domain)] = true
+	}
+	added := 0
+	for _, d := range domains {
+		if !seen[d] {
+			seen[d] = true
+			ext.Unverified = append(ext.Unverified, DomainRecord{Domain: d})
+			added++
+		}
+	}
+
+	out, err := json.MarshalIndent(ext, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return added, auditWrite("import", "", path, func() error { return writeFileAtomic(path, out, 0644) })
+}
+
+// runImportCommand implements "talia import <file>...": drop lists, zone
+// files, and zone diffs (plain or gzipped, "-" for stdin) are filtered and
+// turned into Talia input. With --output the domains are added to a grouped
+// file's "unverified" list; otherwise they are printed one per line.
+func runImportCommand(args []string) int {
+	fs := flag.NewFlagSet("talia import", flag.ContinueOnError)
+	var filter importFilter
+	fs.IntVar(&filter.minLength, "min-length", 0, "Skip names shorter than this (label only, without the TLD)")
+	fs.IntVar(&filter.maxLength, "max-length", 0, "Skip names longer than this (label only, without the TLD)")
+	keywords := fs.String("keywords", "", "Comma-separated keywords; keep only names containing one of them")
+	exclude := fs.String("exclude", "", "Comma-separated keywords; skip names containing any of them")
+	tlds := fs.String("tlds", "", "Comma-separated TLDs to keep, e.g. 'com,net' (default: all)")
+	output := fs.String("output", "", "Grouped JSON file to add the domains to as unverified (default: print them)")
+	format := fs.String("format", importFormatZone, "Input format: 'zone' (drop lists, zone files, one domain per line), 'comma' (many domains per line), or 'csv' (header row with a Domain column)")
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error parsing flags:", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: talia import [--format=zone|comma|csv] [--min-length=n] [--max-length=n] [--keywords=a,b] [--exclude=a,b] [--tlds=com,net] [--output=file.json] <file>...")
+		return 1
+	}
+	if !validImportFormat(*format) {
+		fmt.Fprintf(os.Stderr, "Error: --format must be %q, %q, or %q\n", importFormatZone, importFormatComma, importFormatCSV)
+		return 1
+	}
+	filter.keywords = splitList(*keywords)
+	filter.exclude = splitList(*exclude)
+	for _, t := range splitList(*tlds) {
+		filter.tlds = append(filter.tlds, strings.TrimPrefix(t, "."))
+	}
+
+	// Size the duplicate set from the inputs so huge zone files get the
+	// memory-bounded set; gzipped inputs are underestimated.
+	var size int64
+	for _, path := range files {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	seen, err := newDomainSet(int(size / avgDomainLineBytes))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	defer func() { _ = seen.Close() }()
+
+	var domains []string
+	emit := func(d string) error {
+		domains = append(domains, d)
+		return nil
+	}
+	if *output == "" {
+		w := bufio.NewWriter(os.Stdout)
+		defer func() { _ = w.Flush() }()
+		emit = func(d string) error {
+			_, err := fmt.Fprintln(w, d)
+			return err
+		}
+	}
+
+	var stats importStats
+	for _, path := range files {
+		in := os.Stdin
+		if path != "-" {
+			if in, err = os.Open(path); err != nil {
+				fmt.Fprintln(os.Stderr, "Error reading file:", err)
+				return 1
+			}
+		}
+		err := importZone(in, newLineParser(*format), filter, seen, &stats, emit)
+		if in != os.Stdin {
+			_ = in.Close()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error importing %s: %v\n", path, err)
+			return 1
+		}
+	}
+
+	if *output != "" {
+		added, err := addUnverified(*output, domains)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing file:", err)
+			return 1
+		}
+		fmt.Printf("Imported %d domains from %d lines (%d duplicates, %d already in the file), wrote to %s\n", added, stats.lines, stats.duplicates, len(domains)-added, *output)
+		return 0
+	}
+	// stdout holds only the domains.
+	fmt.Fprintf(os.Stderr, "Imported %d domains from %d lines (%d duplicates)\n", stats.matched, stats.lines, stats.duplicates)
+	return 0
+}
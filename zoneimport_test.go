@@ -0,0 +1,113 @@
+package talia
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestZoneLineDomain covers drop list, zone file, and zone diff lines.
+func TestZoneLineDomain(t *testing.T) {
+	t.Parallel()
+	cases := []struct{ line, origin, want string }{
+		{"example.com", "", "example.com"},
+		{"Example.COM,2026-05-01,12", "", "example.com"},
+		{"example.com. 172800 IN NS ns1.example.net.", "", "example.com"},
+		{"EXAMPLE NS NS1.EXAMPLE", "com", "example.com"},
+		{"EXAMPLE NS NS1.EXAMPLE", "", ""},
+		{"NS1.EXAMPLE A 192.0.2.1", "com", ""},
+		{"ns1.example.com. A 192.0.2.1", "com", ""},
+		{"example.com. NS ns1.host.", "com", "example.com"},
+		{"brand.co.uk. NS ns1.host.", "uk", "brand.co.uk"},
+		{"ns1.brand.uk. A 192.0.2.1", "uk", ""},
+		{"BRAND NS NS1.HOST.", "co.uk", "brand.co.uk"},
+		{"co.uk", "", ""},
+		{"-dropped.net", "", "dropped.net"},
+		{"+fresh.net", "", ""},
+		{"; comment", "", ""},
+		{"# comment", "", ""},
+		{"@ IN SOA a. b. 1 2 3 4 5", "com", ""},
+		{"-bad-.com", "", ""},
+		{"", "", ""},
+	}
+	for _, c := range cases {
+		if got := zoneLineDomain(c.line, c.origin); got != c.want {
+			t.Errorf("zoneLineDomain(%q, %q) = %q, want %q", c.line, c.origin, got, c.want)
+		}
+	}
+}
+
+// TestImportFilter checks the length, keyword, exclude, and TLD filters.
+func TestImportFilter(t *testing.T) {
+	t.Parallel()
+	f := importFilter{minLength: 3, maxLength: 6, keywords: []string{"app", "kit"}, exclude: []string{"xx"}, tlds: []string{"com"}}
+	for domain, want := range map[string]bool{
+		"myapp.com":   true,
+		"kit.com":     true,
+		"ap.com":      false, // too short
+		"bigapps.com": false, // too long
+		"hello.com":   false, // no keyword
+		"xxapp.com":   false, // excluded
+		"myapp.net":   false, // TLD
+	} {
+		if got := f.match(domain); got != want {
+			t.Errorf("match(%q) = %v, want %v", domain, got, want)
+		}
+	}
+}
+
+// TestRunCLIImportSubcommand imports a gzipped zone file into a grouped
+// file's unverified list and prints a plain drop list.
+func TestRunCLIImportSubcommand(t *testing.T) {
+	dir := t.TempDir()
+	zone := "$ORIGIN COM.\n$TTL 172800\nALPHA NS NS1.HOST\nALPHA NS NS2.HOST\nBETA NS NS1.HOST\nLONGERNAME NS NS1.HOST\n"
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte(zone))
+	_ = gz.Close()
+	zonePath := filepath.Join(dir, "com.zone.gz")
+	if err := os.WriteFile(zonePath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "drops.json")
+	if err := os.WriteFile(out, []byte(`{"unavailable":[{"domain":"beta.com","reason":"TAKEN"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _ := captureOutput(t, func() {
+		if code := RunCLI([]string{"import", "--max-length=5", "--output", out, zonePath}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if !strings.Contains(stdout, "Imported 1 domains from 6 lines (1 duplicates, 1 already in the file)") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	var ext ExtendedGroupedData
+	raw, _ := os.ReadFile(out)
+	if err := json.Unmarshal(raw, &ext); err != nil {
+		t.Fatal(err)
+	}
+	if len(ext.Unverified) != 1 || ext.Unverified[0].Domain != "alpha.com" || len(ext.Unavailable) != 1 {
+		t.Errorf("file = %s", raw)
+	}
+
+	list := filepath.Join(dir, "drops.txt")
+	if err := os.WriteFile(list, []byte("-gone.io\n+new.io\nshop.io,2026-05-01\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := captureOutput(t, func() {
+		if code := RunCLI([]string{"import", list}); code != 0 {
+			t.Errorf("exit %d", code)
+		}
+	})
+	if stdout != "gone.io\nshop.io\n" {
+		t.Errorf("stdout = %q", stdout)
+	}
+	if !strings.Contains(stderr, "Imported 2 domains from 3 lines") {
+		t.Errorf("stderr = %q", stderr)
+	}
+}